@@ -1,10 +1,17 @@
 package config
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"log"
 	"math"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -19,7 +26,7 @@ func Loadconfig(path string) IConfig {
 	if err != nil {
 		log.Fatalf("load env error: %v", err)
 	}
-	return &config{
+	cfg := &config{
 		app: &app{
 			host: envMap["APP_HOST"],
 			port: func() int {
@@ -60,6 +67,38 @@ func Loadconfig(path string) IConfig {
 				return f
 			}(),
 			gcpbucket: envMap["APP_GCP_BUCKET"],
+			domain:    envMap["APP_DOMAIN"],
+			maxRoyaltyBps: func() int {
+				m, err := strconv.Atoi(envMap["APP_MAX_ROYALTY_BPS"])
+				if err != nil {
+					log.Fatalf("load maxRoyaltyBps error: %v", err)
+				}
+				return m
+			}(),
+			platformFeeBps: func() int {
+				p, err := strconv.Atoi(envMap["APP_PLATFORM_FEE_BPS"])
+				if err != nil {
+					log.Fatalf("load platformFeeBps error: %v", err)
+				}
+				return p
+			}(),
+			chainId: func() int64 {
+				c, err := strconv.ParseInt(envMap["APP_CHAIN_ID"], 10, 64)
+				if err != nil {
+					log.Fatalf("load chainId error: %v", err)
+				}
+				return c
+			}(),
+			trustedProxies: func() []string {
+				proxies := make([]string, 0)
+				if envMap["APP_TRUSTED_PROXIES"] == "" {
+					return proxies
+				}
+				for _, proxy := range strings.Split(envMap["APP_TRUSTED_PROXIES"], ",") {
+					proxies = append(proxies, strings.TrimSpace(proxy))
+				}
+				return proxies
+			}(),
 		},
 		db: &db{
 			host: envMap["DB_HOST"],
@@ -101,20 +140,136 @@ func Loadconfig(path string) IConfig {
 				}
 				return rea
 			}(),
+			// access and refresh tokens are signed with HS256 and the secret key unless a private key is set
+			signingMethod: func() string {
+				switch envMap["JWT_SIGNING_ALG"] {
+				case "", "HS256":
+					return "HS256"
+				case "RS256", "EdDSA":
+					return envMap["JWT_SIGNING_ALG"]
+				default:
+					log.Fatalf("load signingMethod error: unsupported alg %s", envMap["JWT_SIGNING_ALG"])
+				}
+				return ""
+			}(),
+			signingKid: envMap["JWT_SIGNING_KID"],
+			signingKey: func() crypto.Signer {
+				if envMap["JWT_SIGNING_KEY"] == "" {
+					return nil
+				}
+				k, err := loadPrivateKey(envMap["JWT_SIGNING_KEY"])
+				if err != nil {
+					log.Fatalf("load signingKey error: %v", err)
+				}
+				return k
+			}(),
+			// retired public keys stay here as kid=path until the tokens they signed expire
+			verifyKeys: func() map[string]crypto.PublicKey {
+				keys := make(map[string]crypto.PublicKey)
+				if envMap["JWT_VERIFY_KEYS"] == "" {
+					return keys
+				}
+				for _, entry := range strings.Split(envMap["JWT_VERIFY_KEYS"], ",") {
+					kid, path, ok := strings.Cut(strings.TrimSpace(entry), "=")
+					if !ok || kid == "" {
+						log.Fatalf("load verifyKeys error: expected kid=path, got %q", entry)
+					}
+					k, err := loadPublicKey(path)
+					if err != nil {
+						log.Fatalf("load verifyKeys error: %v", err)
+					}
+					keys[kid] = k
+				}
+				return keys
+			}(),
 		},
+		indexer: &indexer{
+			source:   envMap["INDEXER_SOURCE"],
+			rpcUrl:   envMap["INDEXER_RPC_URL"],
+			filePath: envMap["INDEXER_FILE_PATH"],
+			contract: envMap["INDEXER_CONTRACT"],
+			// the indexer is optional, so unset numbers fall back to defaults instead of failing
+			startBlock: func() uint64 {
+				if envMap["INDEXER_START_BLOCK"] == "" {
+					return 0
+				}
+				s, err := strconv.ParseUint(envMap["INDEXER_START_BLOCK"], 10, 64)
+				if err != nil {
+					log.Fatalf("load startBlock error: %v", err)
+				}
+				return s
+			}(),
+			batchSize: func() int {
+				if envMap["INDEXER_BATCH_SIZE"] == "" {
+					return 100
+				}
+				b, err := strconv.Atoi(envMap["INDEXER_BATCH_SIZE"])
+				if err != nil {
+					log.Fatalf("load batchSize error: %v", err)
+				}
+				if b < 1 {
+					log.Fatalf("load batchSize error: INDEXER_BATCH_SIZE must be at least 1, got %d", b)
+				}
+				return b
+			}(),
+			interval: func() time.Duration {
+				if envMap["INDEXER_INTERVAL"] == "" {
+					return 15 * time.Second
+				}
+				i, err := strconv.Atoi(envMap["INDEXER_INTERVAL"])
+				if err != nil {
+					log.Fatalf("load interval error: %v", err)
+				}
+				return time.Duration(int64(i) * int64(math.Pow10(9)))
+			}(),
+		},
+		mail: &mail{
+			driver:   envMap["MAIL_DRIVER"],
+			smtpHost: envMap["MAIL_SMTP_HOST"],
+			smtpPort: func() int {
+				if envMap["MAIL_SMTP_PORT"] == "" {
+					return 587
+				}
+				p, err := strconv.Atoi(envMap["MAIL_SMTP_PORT"])
+				if err != nil {
+					log.Fatalf("load smtpPort error: %v", err)
+				}
+				return p
+			}(),
+			username: envMap["MAIL_USERNAME"],
+			password: envMap["MAIL_PASSWORD"],
+			from:     envMap["MAIL_FROM"],
+			outboxDir: func() string {
+				if envMap["MAIL_OUTBOX_DIR"] == "" {
+					return "./outbox"
+				}
+				return envMap["MAIL_OUTBOX_DIR"]
+			}(),
+		},
+	}
+	if err := cfg.app.validate(); err != nil {
+		log.Fatalf("load app error: %v", err)
+	}
+	if err := cfg.jwt.validate(); err != nil {
+		log.Fatalf("load jwt error: %v", err)
 	}
+	return cfg
 }
 
 type IConfig interface {
 	App() IAppConfig
 	Db() IDbConfig
 	Jwt() IJwtConfig
+	Indexer() IIndexerConfig
+	Mail() IMailConfig
 }
 
 type config struct {
-	app *app
-	db  *db
-	jwt *jwt
+	app     *app
+	db      *db
+	jwt     *jwt
+	indexer *indexer
+	mail    *mail
 }
 
 type IAppConfig interface {
@@ -127,18 +282,33 @@ type IAppConfig interface {
 	BodyLimit() int
 	FileLimit() int
 	GCPBucket() string
+	// host the frontend is served from, sign-in with ethereum messages must be issued for it
+	Domain() string
+	// basis points, 100 bps = 1%
+	MaxRoyaltyBps() int
+	PlatformFeeBps() int
+	// chain signed typed data is bound to
+	ChainId() int64
+	// ips or cidrs of the reverse proxies whose X-Forwarded-For is believed, empty means the
+	// client ip is the peer of the connection
+	TrustedProxies() []string
 }
 
 type app struct {
-	host         string
-	port         int
-	name         string
-	version      string
-	readTimeout  time.Duration
-	writeTimeout time.Duration
-	bodyLimit    int
-	fileLimit    int
-	gcpbucket    string
+	host           string
+	port           int
+	name           string
+	version        string
+	readTimeout    time.Duration
+	writeTimeout   time.Duration
+	bodyLimit      int
+	fileLimit      int
+	gcpbucket      string
+	domain         string
+	maxRoyaltyBps  int
+	platformFeeBps int
+	chainId        int64
+	trustedProxies []string
 }
 
 func (c *config) App() IAppConfig {
@@ -152,6 +322,11 @@ func (a *app) WriteTimeout() time.Duration { return a.writeTimeout }
 func (a *app) BodyLimit() int              { return a.bodyLimit }
 func (a *app) FileLimit() int              { return a.fileLimit }
 func (a *app) GCPBucket() string           { return a.gcpbucket }
+func (a *app) Domain() string              { return a.domain }
+func (a *app) MaxRoyaltyBps() int          { return a.maxRoyaltyBps }
+func (a *app) PlatformFeeBps() int         { return a.platformFeeBps }
+func (a *app) ChainId() int64              { return a.chainId }
+func (a *app) TrustedProxies() []string    { return a.trustedProxies }
 
 type IDbConfig interface {
 	Url() string
@@ -187,6 +362,12 @@ type IJwtConfig interface {
 	RefreshExpiresAt() int
 	SetJwtAccessExpires(t int)
 	SetJwtRefreshExpires(t int)
+	// HS256, RS256 or EdDSA for access and refresh tokens
+	SigningMethod() string
+	SigningKid() string
+	SigningKey() crypto.Signer
+	// every key tokens are accepted from by kid, the signing key included
+	VerifyKeys() map[string]crypto.PublicKey
 }
 
 type jwt struct {
@@ -195,6 +376,10 @@ type jwt struct {
 	apiKey           string
 	accessExpiresAt  int
 	refreshExpiresAt int
+	signingMethod    string
+	signingKid       string
+	signingKey       crypto.Signer
+	verifyKeys       map[string]crypto.PublicKey
 }
 
 func (c *config) Jwt() IJwtConfig {
@@ -208,3 +393,171 @@ func (j *jwt) AccessExpiresAt() int       { return j.accessExpiresAt }
 func (j *jwt) RefreshExpiresAt() int      { return j.refreshExpiresAt }
 func (j *jwt) SetJwtAccessExpires(t int)  { j.accessExpiresAt = t }
 func (j *jwt) SetJwtRefreshExpires(t int) { j.refreshExpiresAt = t }
+func (j *jwt) SigningMethod() string      { return j.signingMethod }
+func (j *jwt) SigningKid() string         { return j.signingKid }
+func (j *jwt) SigningKey() crypto.Signer  { return j.signingKey }
+
+func (j *jwt) VerifyKeys() map[string]crypto.PublicKey {
+	keys := make(map[string]crypto.PublicKey, len(j.verifyKeys)+1)
+	for kid, k := range j.verifyKeys {
+		keys[kid] = k
+	}
+	if j.signingKey != nil {
+		keys[j.signingKid] = j.signingKey.Public()
+	}
+	return keys
+}
+
+type IIndexerConfig interface {
+	// file or rpc, the indexer is disabled when empty
+	Source() string
+	RpcUrl() string
+	FilePath() string
+	// ERC-721 contract whose token ids are the local nft token ids
+	Contract() string
+	StartBlock() uint64
+	BatchSize() int
+	Interval() time.Duration
+}
+
+type indexer struct {
+	source     string
+	rpcUrl     string
+	filePath   string
+	contract   string
+	startBlock uint64
+	batchSize  int
+	interval   time.Duration
+}
+
+func (c *config) Indexer() IIndexerConfig {
+	return c.indexer
+}
+
+func (i *indexer) Source() string          { return i.source }
+func (i *indexer) RpcUrl() string          { return i.rpcUrl }
+func (i *indexer) FilePath() string        { return i.filePath }
+func (i *indexer) Contract() string        { return i.contract }
+func (i *indexer) StartBlock() uint64      { return i.startBlock }
+func (i *indexer) BatchSize() int          { return i.batchSize }
+func (i *indexer) Interval() time.Duration { return i.interval }
+
+type IMailConfig interface {
+	// smtp or outbox, anything else writes to the outbox
+	Driver() string
+	SmtpHost() string
+	SmtpPort() int
+	Username() string
+	Password() string
+	From() string
+	// directory the outbox driver writes .eml files to
+	OutboxDir() string
+}
+
+type mail struct {
+	driver    string
+	smtpHost  string
+	smtpPort  int
+	username  string
+	password  string
+	from      string
+	outboxDir string
+}
+
+func (c *config) Mail() IMailConfig {
+	return c.mail
+}
+
+func (m *mail) Driver() string    { return m.driver }
+func (m *mail) SmtpHost() string  { return m.smtpHost }
+func (m *mail) SmtpPort() int     { return m.smtpPort }
+func (m *mail) Username() string  { return m.username }
+func (m *mail) Password() string  { return m.password }
+func (m *mail) From() string      { return m.from }
+func (m *mail) OutboxDir() string { return m.outboxDir }
+
+// validate keeps the royalty cap and the platform fee within the sale price, the seller payout
+// would go negative otherwise
+func (a *app) validate() error {
+	if a.maxRoyaltyBps < 0 || a.platformFeeBps < 0 {
+		return fmt.Errorf("APP_MAX_ROYALTY_BPS and APP_PLATFORM_FEE_BPS must not be negative")
+	}
+	if a.maxRoyaltyBps+a.platformFeeBps > 10000 {
+		return fmt.Errorf("APP_MAX_ROYALTY_BPS + APP_PLATFORM_FEE_BPS must not exceed 10000, got %d", a.maxRoyaltyBps+a.platformFeeBps)
+	}
+	return nil
+}
+
+// validate makes sure an asymmetric alg comes with a matching private key and kid
+func (j *jwt) validate() error {
+	if j.signingMethod == "HS256" {
+		return nil
+	}
+	if j.signingKey == nil {
+		return fmt.Errorf("JWT_SIGNING_KEY is required for %s", j.signingMethod)
+	}
+	if j.signingKid == "" {
+		return fmt.Errorf("JWT_SIGNING_KID is required for %s", j.signingMethod)
+	}
+	for kid, k := range j.VerifyKeys() {
+		switch k.(type) {
+		case *rsa.PublicKey:
+			if j.signingMethod != "RS256" {
+				return fmt.Errorf("key %s is rsa but alg is %s", kid, j.signingMethod)
+			}
+		case ed25519.PublicKey:
+			if j.signingMethod != "EdDSA" {
+				return fmt.Errorf("key %s is ed25519 but alg is %s", kid, j.signingMethod)
+			}
+		default:
+			return fmt.Errorf("key %s has an unsupported type", kid)
+		}
+	}
+	return nil
+}
+
+func readPem(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a pem file", path)
+	}
+	return block, nil
+}
+
+// loadPrivateKey reads a PKCS#8 rsa or ed25519 key, or a PKCS#1 rsa key
+func loadPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPem(path)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch k := k.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("%s is not an rsa or ed25519 key", path)
+	}
+}
+
+func loadPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPem(path)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
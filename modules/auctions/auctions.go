@@ -0,0 +1,78 @@
+package auctions
+
+import "time"
+
+type AuctionStatus string
+
+const (
+	Active    AuctionStatus = "active"
+	Settled   AuctionStatus = "settled"
+	Unsold    AuctionStatus = "unsold"
+	Cancelled AuctionStatus = "cancelled"
+)
+
+type Auction struct {
+	Id           string  `json:"id" db:"id"`
+	NftId        string  `json:"nft_id" db:"nft_id"`
+	TokenId      int64   `json:"token_id" db:"token_id"`
+	SellerId     string  `json:"seller_id" db:"seller_id"`
+	StartPrice   float64 `json:"start_price" db:"start_price"`
+	ReservePrice float64 `json:"-" db:"reserve_price"`
+	ReserveMet   bool    `json:"reserve_met" db:"-"`
+	MinIncrement float64 `json:"min_increment" db:"min_increment"`
+	MinNextBid   float64 `json:"min_next_bid" db:"-"`
+	// anti-sniping: a bid within ExtensionWindow seconds of the end pushes the end ExtensionDuration seconds out
+	ExtensionWindow   int           `json:"extension_window" db:"extension_window"`
+	ExtensionDuration int           `json:"extension_duration" db:"extension_duration"`
+	StartTime         time.Time     `json:"start_time" db:"start_time"`
+	EndTime           time.Time     `json:"end_time" db:"end_time"`
+	Status            AuctionStatus `json:"status" db:"status"`
+	HighestBidId      string        `json:"highest_bid_id,omitempty" db:"highest_bid_id"`
+	HighestBidderId   string        `json:"highest_bidder_id,omitempty" db:"highest_bidder_id"`
+	HighestBid        float64       `json:"highest_bid" db:"highest_bid"`
+	BidCount          int           `json:"bid_count" db:"bid_count"`
+	CreatedAt         string        `json:"created_at" db:"created_at"`
+	UpdatedAt         string        `json:"updated_at" db:"update_at"`
+}
+
+type AuctionReq struct {
+	SellerId          string    `json:"-" db:"seller_id"`
+	TokenId           int64     `json:"token_id" db:"token_id" form:"token_id"`
+	StartPrice        float64   `json:"start_price" db:"start_price" form:"start_price"`
+	ReservePrice      float64   `json:"reserve_price" db:"reserve_price" form:"reserve_price"`
+	MinIncrement      float64   `json:"min_increment" db:"min_increment" form:"min_increment"`
+	StartTime         time.Time `json:"start_time" db:"start_time" form:"start_time"`
+	EndTime           time.Time `json:"end_time" db:"end_time" form:"end_time"`
+	ExtensionWindow   int       `json:"extension_window" db:"extension_window" form:"extension_window"`
+	ExtensionDuration int       `json:"extension_duration" db:"extension_duration" form:"extension_duration"`
+}
+
+type AuctionFilter struct {
+	SellerId string `query:"seller_id"`
+	TokenId  int64  `query:"token_id"`
+	Status   string `query:"status"`
+}
+
+type Bid struct {
+	Id        string  `json:"id" db:"id"`
+	AuctionId string  `json:"auction_id" db:"auction_id"`
+	BidderId  string  `json:"bidder_id" db:"bidder_id"`
+	Amount    float64 `json:"amount" db:"amount"`
+	CreatedAt string  `json:"created_at" db:"created_at"`
+}
+
+type BidReq struct {
+	AuctionId string  `json:"-" db:"auction_id"`
+	BidderId  string  `json:"-" db:"bidder_id"`
+	Amount    float64 `json:"amount" db:"amount" form:"amount"`
+}
+
+// SetComputed fills the fields derived from the bid state
+func (obj *Auction) SetComputed() {
+	obj.ReserveMet = obj.HighestBidId != "" && obj.HighestBid >= obj.ReservePrice
+	if obj.HighestBidId == "" {
+		obj.MinNextBid = obj.StartPrice
+	} else {
+		obj.MinNextBid = obj.HighestBid + obj.MinIncrement
+	}
+}
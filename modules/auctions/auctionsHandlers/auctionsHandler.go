@@ -0,0 +1,277 @@
+package auctionsHandlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/auctions"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/auctions/auctionsUsecases"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/entities"
+)
+
+type auctionsHandlersErrCode string
+
+const (
+	findOneAuctionErr auctionsHandlersErrCode = "auctions-001"
+	findAuctionsErr   auctionsHandlersErrCode = "auctions-002"
+	insertAuctionErr  auctionsHandlersErrCode = "auctions-003"
+	cancelAuctionErr  auctionsHandlersErrCode = "auctions-004"
+	findBidsErr       auctionsHandlersErrCode = "auctions-005"
+	placeBidErr       auctionsHandlersErrCode = "auctions-006"
+)
+
+type IAuctionsHandler interface {
+	FindOneAuction(c *fiber.Ctx) error
+	FindAuctions(c *fiber.Ctx) error
+	InsertAuction(c *fiber.Ctx) error
+	CancelAuction(c *fiber.Ctx) error
+	FindBids(c *fiber.Ctx) error
+	PlaceBid(c *fiber.Ctx) error
+}
+
+type auctionsHandler struct {
+	cfg             config.IConfig
+	auctionsUsecase auctionsUsecases.IAuctionsUsecase
+}
+
+func AuctionsHandler(cfg config.IConfig, auctionsUsecase auctionsUsecases.IAuctionsUsecase) IAuctionsHandler {
+	return &auctionsHandler{
+		cfg:             cfg,
+		auctionsUsecase: auctionsUsecase,
+	}
+}
+
+func auctionIdParam(c *fiber.Ctx) (string, bool) {
+	auctionId := strings.Trim(c.Params("auction_id"), " ")
+	if _, err := uuid.Parse(auctionId); err != nil {
+		return "", false
+	}
+	return auctionId, true
+}
+
+func (h *auctionsHandler) FindOneAuction(c *fiber.Ctx) error {
+	auctionId, ok := auctionIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findOneAuctionErr),
+			"invalid auction id",
+		).Res()
+	}
+
+	auction, err := h.auctionsUsecase.FindOneAuction(auctionId)
+	if err != nil {
+		switch err.Error() {
+		case "get auction failed: sql: no rows in result set":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findOneAuctionErr),
+				"auction not found",
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findOneAuctionErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, auction).Res()
+}
+
+func (h *auctionsHandler) FindAuctions(c *fiber.Ctx) error {
+	req := new(auctions.AuctionFilter)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findAuctionsErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.auctionsUsecase.FindAuctions(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findAuctionsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
+
+func (h *auctionsHandler) InsertAuction(c *fiber.Ctx) error {
+	req := new(auctions.AuctionReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertAuctionErr),
+			err.Error(),
+		).Res()
+	}
+	req.SellerId = c.Locals("userId").(string)
+
+	auction, err := h.auctionsUsecase.InsertAuction(req)
+	if err != nil {
+		switch err.Error() {
+		case "token id must be greater than zero",
+			"start price must be greater than zero",
+			"reserve price must not be negative",
+			"min increment must be greater than zero",
+			"end time must be after start time",
+			"auction must not run longer than 30 days",
+			"nft not found",
+			"nft is already listed",
+			"nft is already in an auction":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertAuctionErr),
+				err.Error(),
+			).Res()
+		case "nft belongs to another user":
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(insertAuctionErr),
+				err.Error(),
+			).Res()
+		default:
+			if strings.HasPrefix(err.Error(), "extension window") {
+				return entities.NewResponse(c).Error(
+					fiber.ErrBadRequest.Code,
+					string(insertAuctionErr),
+					err.Error(),
+				).Res()
+			}
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertAuctionErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, auction).Res()
+}
+
+func (h *auctionsHandler) CancelAuction(c *fiber.Ctx) error {
+	auctionId, ok := auctionIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(cancelAuctionErr),
+			"invalid auction id",
+		).Res()
+	}
+
+	if err := h.auctionsUsecase.CancelAuction(c.Locals("userId").(string), auctionId); err != nil {
+		switch err.Error() {
+		case "auction not found or already has bids":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(cancelAuctionErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(cancelAuctionErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, "auction cancelled successfully").Res()
+}
+
+func (h *auctionsHandler) FindBids(c *fiber.Ctx) error {
+	auctionId, ok := auctionIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findBidsErr),
+			"invalid auction id",
+		).Res()
+	}
+
+	bids, err := h.auctionsUsecase.FindBids(auctionId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findBidsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, bids).Res()
+}
+
+func (h *auctionsHandler) PlaceBid(c *fiber.Ctx) error {
+	auctionId, ok := auctionIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(placeBidErr),
+			"invalid auction id",
+		).Res()
+	}
+
+	req := new(auctions.BidReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(placeBidErr),
+			err.Error(),
+		).Res()
+	}
+	req.AuctionId = auctionId
+	req.BidderId = c.Locals("userId").(string)
+
+	bid, err := h.auctionsUsecase.PlaceBid(req)
+	if err != nil {
+		switch err.Error() {
+		case "auction not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(placeBidErr),
+				err.Error(),
+			).Res()
+		case "auction is not active",
+			"auction has not started",
+			"auction has ended",
+			"you are already the highest bidder":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(placeBidErr),
+				err.Error(),
+			).Res()
+		case "bid amount must be greater than zero",
+			"cannot bid on your own auction":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(placeBidErr),
+				err.Error(),
+			).Res()
+		case "insufficient funds":
+			return entities.NewResponse(c).Error(
+				fiber.ErrPaymentRequired.Code,
+				string(placeBidErr),
+				err.Error(),
+			).Res()
+		default:
+			// the minimum bid moves with every accepted bid, so the message carries the amount
+			if strings.HasPrefix(err.Error(), "bid must be at least") {
+				return entities.NewResponse(c).Error(
+					fiber.ErrBadRequest.Code,
+					string(placeBidErr),
+					err.Error(),
+				).Res()
+			}
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(placeBidErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, bid).Res()
+}
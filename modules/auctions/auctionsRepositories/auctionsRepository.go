@@ -0,0 +1,485 @@
+package auctionsRepositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/auctions"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/sales"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/sales/salesPatterns"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets/walletsPatterns"
+)
+
+type IAuctionsRepository interface {
+	FindOneAuction(auctionId string) (*auctions.Auction, error)
+	FindAuctions(req *auctions.AuctionFilter) ([]*auctions.Auction, error)
+	FindBids(auctionId string) ([]*auctions.Bid, error)
+	InsertAuction(req *auctions.AuctionReq) (*auctions.Auction, error)
+	CancelAuction(sellerId, auctionId string) error
+	InsertBid(req *auctions.BidReq) (*auctions.Bid, error)
+	FindEndedAuctionIds() ([]string, error)
+	SettleAuction(auctionId string, platformFeeBps int) (auctions.AuctionStatus, error)
+}
+
+type auctionsRepository struct {
+	db *sqlx.DB
+}
+
+func AuctionsRepository(db *sqlx.DB) IAuctionsRepository {
+	return &auctionsRepository{
+		db: db,
+	}
+}
+
+const auctionSelect = `
+	SELECT
+		"a"."id",
+		"a"."nft_id",
+		"n"."token_id",
+		"a"."seller_id",
+		"a"."start_price",
+		"a"."reserve_price",
+		"a"."min_increment",
+		"a"."extension_window",
+		"a"."extension_duration",
+		"a"."start_time",
+		"a"."end_time",
+		"a"."status",
+		COALESCE("b"."id"::text, '') AS "highest_bid_id",
+		COALESCE("b"."bidder_id", '') AS "highest_bidder_id",
+		COALESCE("b"."amount", 0) AS "highest_bid",
+		(
+			SELECT COUNT(*)
+			FROM "auction_bids" "ab"
+			WHERE "ab"."auction_id" = "a"."id"
+		) AS "bid_count",
+		"a"."created_at",
+		"a"."update_at"
+	FROM "auctions" "a"
+	JOIN "nfts" "n" ON "n"."id" = "a"."nft_id"
+	LEFT JOIN "auction_bids" "b" ON "b"."id" = "a"."highest_bid_id"
+`
+
+func (r *auctionsRepository) FindOneAuction(auctionId string) (*auctions.Auction, error) {
+	query := auctionSelect + `
+	WHERE "a"."id" = $1;`
+
+	auction := new(auctions.Auction)
+	if err := r.db.Get(auction, query, auctionId); err != nil {
+		return nil, fmt.Errorf("get auction failed: %v", err)
+	}
+	return auction, nil
+}
+
+func (r *auctionsRepository) FindAuctions(req *auctions.AuctionFilter) ([]*auctions.Auction, error) {
+	conditions := make([]string, 0)
+	filterValues := make([]any, 0)
+
+	if req.SellerId != "" {
+		filterValues = append(filterValues, req.SellerId)
+		conditions = append(conditions, fmt.Sprintf(`"a"."seller_id" = $%d`, len(filterValues)))
+	}
+	if req.TokenId > 0 {
+		filterValues = append(filterValues, req.TokenId)
+		conditions = append(conditions, fmt.Sprintf(`"n"."token_id" = $%d`, len(filterValues)))
+	}
+	if req.Status != "" {
+		filterValues = append(filterValues, req.Status)
+		conditions = append(conditions, fmt.Sprintf(`"a"."status" = $%d`, len(filterValues)))
+	}
+
+	query := auctionSelect
+	if len(conditions) > 0 {
+		query += `
+	WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += `
+	ORDER BY "a"."end_time" ASC;`
+
+	result := make([]*auctions.Auction, 0)
+	if err := r.db.Select(&result, query, filterValues...); err != nil {
+		return nil, fmt.Errorf("get auctions failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *auctionsRepository) FindBids(auctionId string) ([]*auctions.Bid, error) {
+	query := `
+	SELECT
+		"id",
+		"auction_id",
+		"bidder_id",
+		"amount",
+		"created_at"
+	FROM "auction_bids"
+	WHERE "auction_id" = $1
+	ORDER BY "created_at" DESC;`
+
+	result := make([]*auctions.Bid, 0)
+	if err := r.db.Select(&result, query, auctionId); err != nil {
+		return nil, fmt.Errorf("get bids failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *auctionsRepository) InsertAuction(req *auctions.AuctionReq) (*auctions.Auction, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	nft := new(struct {
+		Id      string `db:"id"`
+		OwnerId string `db:"owner_id"`
+		Listed  bool   `db:"listed"`
+	})
+	if err := tx.GetContext(ctx, nft, `
+	SELECT
+		"n"."id",
+		COALESCE("n"."owner_id", '') AS "owner_id",
+		EXISTS (
+			SELECT 1
+			FROM "listings" "l"
+			WHERE "l"."nft_id" = "n"."id"
+			AND "l"."status" = 'active'
+		) AS "listed"
+	FROM "nfts" "n"
+	WHERE "n"."token_id" = $1
+	AND "n"."deleted_at" IS NULL
+	FOR UPDATE OF "n";`, req.TokenId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("nft not found")
+	}
+	if nft.OwnerId != req.SellerId {
+		tx.Rollback()
+		return nil, fmt.Errorf("nft belongs to another user")
+	}
+	if nft.Listed {
+		tx.Rollback()
+		return nil, fmt.Errorf("nft is already listed")
+	}
+
+	var auctionId string
+	if err := tx.QueryRowxContext(ctx, `
+	INSERT INTO "auctions" (
+		"nft_id",
+		"seller_id",
+		"start_price",
+		"reserve_price",
+		"min_increment",
+		"start_time",
+		"end_time",
+		"extension_window",
+		"extension_duration"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING "id";`,
+		nft.Id,
+		req.SellerId,
+		req.StartPrice,
+		req.ReservePrice,
+		req.MinIncrement,
+		req.StartTime,
+		req.EndTime,
+		req.ExtensionWindow,
+		req.ExtensionDuration,
+	).Scan(&auctionId); err != nil {
+		tx.Rollback()
+		if strings.Contains(err.Error(), "auctions_active_nft_id_key") {
+			return nil, fmt.Errorf("nft is already in an auction")
+		}
+		return nil, fmt.Errorf("insert auction failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit auction failed: %v", err)
+	}
+	return r.FindOneAuction(auctionId)
+}
+
+func (r *auctionsRepository) CancelAuction(sellerId, auctionId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	// an auction can only be pulled before anyone has bid on it
+	query := `
+	UPDATE "auctions" SET
+		"status" = $1
+	WHERE "id" = $2
+	AND "seller_id" = $3
+	AND "status" = $4
+	AND "highest_bid_id" IS NULL;`
+
+	result, err := r.db.ExecContext(ctx, query, auctions.Cancelled, auctionId, sellerId, auctions.Active)
+	if err != nil {
+		return fmt.Errorf("cancel auction failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("auction not found or already has bids")
+	}
+	return nil
+}
+
+// InsertBid serialises bids on the auction row, so the minimum bid and the anti-sniping
+// extension are always computed against the latest highest bid; the bid amount is held in the
+// bidder's wallet and the outbid bidder's hold is released
+func (r *auctionsRepository) InsertBid(req *auctions.BidReq) (*auctions.Bid, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	auction := new(auctions.Auction)
+	if err := tx.GetContext(ctx, auction, `
+	SELECT
+		"a"."id",
+		"a"."seller_id",
+		"a"."start_price",
+		"a"."min_increment",
+		"a"."extension_window",
+		"a"."extension_duration",
+		"a"."start_time",
+		"a"."end_time",
+		"a"."status",
+		COALESCE("a"."highest_bid_id"::text, '') AS "highest_bid_id"
+	FROM "auctions" "a"
+	WHERE "a"."id" = $1
+	FOR UPDATE;`, req.AuctionId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("auction not found")
+	}
+
+	if auction.HighestBidId != "" {
+		if err := tx.GetContext(ctx, auction, `
+		SELECT
+			"bidder_id" AS "highest_bidder_id",
+			"amount" AS "highest_bid"
+		FROM "auction_bids"
+		WHERE "id" = $1;`, auction.HighestBidId); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("get highest bid failed: %v", err)
+		}
+	}
+	auction.SetComputed()
+
+	now := time.Now()
+	switch {
+	case auction.Status != auctions.Active:
+		tx.Rollback()
+		return nil, fmt.Errorf("auction is not active")
+	case now.Before(auction.StartTime):
+		tx.Rollback()
+		return nil, fmt.Errorf("auction has not started")
+	case !now.Before(auction.EndTime):
+		tx.Rollback()
+		return nil, fmt.Errorf("auction has ended")
+	case auction.SellerId == req.BidderId:
+		tx.Rollback()
+		return nil, fmt.Errorf("cannot bid on your own auction")
+	case auction.HighestBidderId == req.BidderId:
+		tx.Rollback()
+		return nil, fmt.Errorf("you are already the highest bidder")
+	case req.Amount < auction.MinNextBid:
+		tx.Rollback()
+		return nil, fmt.Errorf("bid must be at least %.2f", auction.MinNextBid)
+	}
+
+	bid := new(auctions.Bid)
+	if err := tx.GetContext(ctx, bid, `
+	INSERT INTO "auction_bids" (
+		"auction_id",
+		"bidder_id",
+		"amount"
+	)
+	VALUES ($1, $2, $3)
+	RETURNING "id", "auction_id", "bidder_id", "amount", "created_at";`,
+		auction.Id,
+		req.BidderId,
+		req.Amount,
+	); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("insert bid failed: %v", err)
+	}
+
+	// the hold and the release touch two users, both are locked before either is posted
+	bidderIds := []string{req.BidderId}
+	if auction.HighestBidderId != "" {
+		bidderIds = append(bidderIds, auction.HighestBidderId)
+	}
+	if err := walletsPatterns.LockUsers(ctx, tx, bidderIds...); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := walletsPatterns.Hold(ctx, tx, req.BidderId, req.Amount, auction.Id); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if auction.HighestBidderId != "" {
+		if err := walletsPatterns.Release(ctx, tx, auction.HighestBidderId, auction.HighestBid, auction.Id); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	endTime := auction.EndTime
+	if auction.EndTime.Sub(now) <= time.Duration(auction.ExtensionWindow)*time.Second {
+		if extended := now.Add(time.Duration(auction.ExtensionDuration) * time.Second); extended.After(endTime) {
+			endTime = extended
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE "auctions" SET
+		"highest_bid_id" = $1,
+		"end_time" = $2
+	WHERE "id" = $3;`,
+		bid.Id,
+		endTime,
+		auction.Id,
+	); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("update auction failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit bid failed: %v", err)
+	}
+	return bid, nil
+}
+
+func (r *auctionsRepository) FindEndedAuctionIds() ([]string, error) {
+	query := `
+	SELECT "id"
+	FROM "auctions"
+	WHERE "status" = $1
+	AND "end_time" <= now()
+	ORDER BY "end_time" ASC
+	LIMIT 100;`
+
+	result := make([]string, 0)
+	if err := r.db.Select(&result, query, auctions.Active); err != nil {
+		return nil, fmt.Errorf("get ended auctions failed: %v", err)
+	}
+	return result, nil
+}
+
+// SettleAuction closes an ended auction, transferring the nft to the highest bidder when the
+// reserve is met and otherwise releasing their hold; rows locked by another instance are skipped
+// and picked up on the next run
+func (r *auctionsRepository) SettleAuction(auctionId string, platformFeeBps int) (auctions.AuctionStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	auction := new(auctions.Auction)
+	if err := tx.GetContext(ctx, auction, `
+	SELECT
+		"a"."id",
+		"a"."nft_id",
+		"a"."seller_id",
+		"a"."reserve_price",
+		"a"."end_time",
+		"a"."status",
+		COALESCE("b"."id"::text, '') AS "highest_bid_id",
+		COALESCE("b"."bidder_id", '') AS "highest_bidder_id",
+		COALESCE("b"."amount", 0) AS "highest_bid"
+	FROM "auctions" "a"
+	LEFT JOIN "auction_bids" "b" ON "b"."id" = "a"."highest_bid_id"
+	WHERE "a"."id" = $1
+	AND "a"."status" = 'active'
+	AND "a"."end_time" <= now()
+	FOR UPDATE OF "a" SKIP LOCKED;`, auctionId); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("get auction failed: %v", err)
+	}
+	auction.SetComputed()
+
+	// the sale pays the seller and the creator out of the bidder's hold, all of them are locked
+	// before the first post
+	userIds := []string{auction.HighestBidderId}
+	if auction.ReserveMet {
+		var creatorId string
+		if err := tx.GetContext(ctx, &creatorId, `
+		SELECT "author_id"
+		FROM "nfts"
+		WHERE "id" = $1
+		FOR UPDATE;`, auction.NftId); err != nil {
+			tx.Rollback()
+			return "", fmt.Errorf("lock nft failed: %v", err)
+		}
+		userIds = append(userIds, auction.SellerId, creatorId)
+	}
+	if err := walletsPatterns.LockUsers(ctx, tx, userIds...); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	status := auctions.Unsold
+	if auction.ReserveMet {
+		sale := &sales.Sale{
+			NftId:    auction.NftId,
+			SellerId: auction.SellerId,
+			BuyerId:  auction.HighestBidderId,
+			Price:    auction.HighestBid,
+			Source:   sales.AuctionSale,
+			SourceId: auction.Id,
+
+			PlatformFeeBps: platformFeeBps,
+			PaidFrom:       wallets.Held,
+		}
+		switch err := salesPatterns.RecordSale(ctx, tx, sale); {
+		case err == nil:
+			status = auctions.Settled
+		case errors.Is(err, salesPatterns.ErrSellerNotOwner):
+			// nothing was written, the auction can no longer be honoured
+			status = auctions.Cancelled
+		default:
+			tx.Rollback()
+			return "", err
+		}
+	}
+
+	if status != auctions.Settled && auction.HighestBidderId != "" {
+		if err := walletsPatterns.Release(ctx, tx, auction.HighestBidderId, auction.HighestBid, auction.Id); err != nil {
+			tx.Rollback()
+			return "", err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE "auctions" SET
+		"status" = $1
+	WHERE "id" = $2
+	AND "status" = 'active';`,
+		status,
+		auction.Id,
+	); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("close auction failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("commit auction settlement failed: %v", err)
+	}
+	return status, nil
+}
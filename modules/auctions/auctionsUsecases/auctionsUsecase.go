@@ -0,0 +1,161 @@
+package auctionsUsecases
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/auctions"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/auctions/auctionsRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/utils"
+)
+
+const (
+	defaultExtensionSeconds = 300
+	maxExtensionSeconds     = 3600
+	maxAuctionDuration      = time.Hour * 24 * 30
+)
+
+type IAuctionsUsecase interface {
+	FindOneAuction(auctionId string) (*auctions.Auction, error)
+	FindAuctions(req *auctions.AuctionFilter) ([]*auctions.Auction, error)
+	FindBids(auctionId string) ([]*auctions.Bid, error)
+	InsertAuction(req *auctions.AuctionReq) (*auctions.Auction, error)
+	CancelAuction(sellerId, auctionId string) error
+	PlaceBid(req *auctions.BidReq) (*auctions.Bid, error)
+	SettleEndedAuctions() error
+}
+
+type auctionsUsecase struct {
+	cfg                config.IConfig
+	auctionsRepository auctionsRepositories.IAuctionsRepository
+}
+
+func AuctionsUsecase(cfg config.IConfig, auctionsRepository auctionsRepositories.IAuctionsRepository) IAuctionsUsecase {
+	return &auctionsUsecase{
+		cfg:                cfg,
+		auctionsRepository: auctionsRepository,
+	}
+}
+
+func (u *auctionsUsecase) FindOneAuction(auctionId string) (*auctions.Auction, error) {
+	auction, err := u.auctionsRepository.FindOneAuction(auctionId)
+	if err != nil {
+		return nil, err
+	}
+	auction.SetComputed()
+	return auction, nil
+}
+
+func (u *auctionsUsecase) FindAuctions(req *auctions.AuctionFilter) ([]*auctions.Auction, error) {
+	if req.Status == "" {
+		req.Status = string(auctions.Active)
+	}
+	result, err := u.auctionsRepository.FindAuctions(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, auction := range result {
+		auction.SetComputed()
+	}
+	return result, nil
+}
+
+func (u *auctionsUsecase) FindBids(auctionId string) ([]*auctions.Bid, error) {
+	bids, err := u.auctionsRepository.FindBids(auctionId)
+	if err != nil {
+		return nil, err
+	}
+	return bids, nil
+}
+
+func (u *auctionsUsecase) InsertAuction(req *auctions.AuctionReq) (*auctions.Auction, error) {
+	if req.TokenId < 1 {
+		return nil, fmt.Errorf("token id must be greater than zero")
+	}
+
+	req.StartPrice = utils.RoundPrice(req.StartPrice)
+	req.ReservePrice = utils.RoundPrice(req.ReservePrice)
+	req.MinIncrement = utils.RoundPrice(req.MinIncrement)
+	if req.StartPrice <= 0 {
+		return nil, fmt.Errorf("start price must be greater than zero")
+	}
+	if req.ReservePrice < 0 {
+		return nil, fmt.Errorf("reserve price must not be negative")
+	}
+	if req.MinIncrement <= 0 {
+		return nil, fmt.Errorf("min increment must be greater than zero")
+	}
+
+	now := time.Now()
+	if req.StartTime.IsZero() || req.StartTime.Before(now) {
+		req.StartTime = now
+	}
+	if !req.EndTime.After(req.StartTime) {
+		return nil, fmt.Errorf("end time must be after start time")
+	}
+	if req.EndTime.Sub(req.StartTime) > maxAuctionDuration {
+		return nil, fmt.Errorf("auction must not run longer than 30 days")
+	}
+
+	if req.ExtensionWindow == 0 {
+		req.ExtensionWindow = defaultExtensionSeconds
+	}
+	if req.ExtensionDuration == 0 {
+		req.ExtensionDuration = defaultExtensionSeconds
+	}
+	if req.ExtensionWindow < 0 || req.ExtensionWindow > maxExtensionSeconds ||
+		req.ExtensionDuration < 0 || req.ExtensionDuration > maxExtensionSeconds {
+		return nil, fmt.Errorf("extension window and duration must be between 0 and %d seconds", maxExtensionSeconds)
+	}
+
+	auction, err := u.auctionsRepository.InsertAuction(req)
+	if err != nil {
+		return nil, err
+	}
+	auction.SetComputed()
+	return auction, nil
+}
+
+func (u *auctionsUsecase) CancelAuction(sellerId, auctionId string) error {
+	if err := u.auctionsRepository.CancelAuction(sellerId, auctionId); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *auctionsUsecase) PlaceBid(req *auctions.BidReq) (*auctions.Bid, error) {
+	req.Amount = utils.RoundPrice(req.Amount)
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("bid amount must be greater than zero")
+	}
+
+	bid, err := u.auctionsRepository.InsertBid(req)
+	if err != nil {
+		return nil, err
+	}
+	return bid, nil
+}
+
+// SettleEndedAuctions is run by the scheduler to close every auction past its end time
+func (u *auctionsUsecase) SettleEndedAuctions() error {
+	auctionIds, err := u.auctionsRepository.FindEndedAuctionIds()
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, 0)
+	for _, auctionId := range auctionIds {
+		status, err := u.auctionsRepository.SettleAuction(auctionId, u.cfg.App().PlatformFeeBps())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("auction %s: %w", auctionId, err))
+			continue
+		}
+		if status != "" {
+			log.Printf("auction %s closed as %s", auctionId, status)
+		}
+	}
+	return errors.Join(errs...)
+}
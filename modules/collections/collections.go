@@ -0,0 +1,41 @@
+package collections
+
+import (
+	"github.com/muhammadfarhankt/nft-marketplace/modules/appinfo"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/files"
+)
+
+type CollectionStatus string
+
+const (
+	Active   CollectionStatus = "active"
+	Archived CollectionStatus = "archived"
+)
+
+type Collection struct {
+	Id          string            `json:"id" db:"id"`
+	OwnerId     string            `json:"owner_id" db:"owner_id"`
+	Category    *appinfo.Category `json:"category"`
+	Title       string            `json:"title" db:"title"`
+	Description string            `json:"description" db:"description"`
+	Cover       *files.FileRes    `json:"cover"`
+	Status      CollectionStatus  `json:"status" db:"status"`
+	CreatedAt   string            `json:"created_at" db:"created_at"`
+	UpdatedAt   string            `json:"updated_at" db:"update_at"`
+}
+
+type CollectionReq struct {
+	Id          string         `json:"-" db:"id"`
+	OwnerId     string         `json:"-" db:"owner_id"`
+	CategoryId  int            `json:"category_id" db:"category_id" form:"category_id"`
+	Title       string         `json:"title" db:"title" form:"title"`
+	Description string         `json:"description" db:"description" form:"description"`
+	Cover       *files.FileRes `json:"cover" form:"cover"`
+}
+
+type CollectionFilter struct {
+	Search     string `query:"search"`
+	OwnerId    string `query:"owner_id"`
+	CategoryId int    `query:"category_id"`
+	Status     string `query:"status"`
+}
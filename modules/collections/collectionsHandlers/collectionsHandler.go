@@ -0,0 +1,183 @@
+package collectionsHandlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/collections"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/collections/collectionsUsecases"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/entities"
+)
+
+type collectionsHandlersErrCode string
+
+const (
+	findOneCollectionErr collectionsHandlersErrCode = "collections-001"
+	findCollectionsErr   collectionsHandlersErrCode = "collections-002"
+	insertCollectionErr  collectionsHandlersErrCode = "collections-003"
+	updateCollectionErr  collectionsHandlersErrCode = "collections-004"
+	archiveCollectionErr collectionsHandlersErrCode = "collections-005"
+)
+
+type ICollectionsHandler interface {
+	FindOneCollection(c *fiber.Ctx) error
+	FindCollections(c *fiber.Ctx) error
+	InsertCollection(c *fiber.Ctx) error
+	UpdateCollection(c *fiber.Ctx) error
+	ArchiveCollection(c *fiber.Ctx) error
+}
+
+type collectionsHandler struct {
+	cfg                config.IConfig
+	collectionsUsecase collectionsUsecases.ICollectionsUsecase
+}
+
+func CollectionsHandler(cfg config.IConfig, collectionsUsecase collectionsUsecases.ICollectionsUsecase) ICollectionsHandler {
+	return &collectionsHandler{
+		cfg:                cfg,
+		collectionsUsecase: collectionsUsecase,
+	}
+}
+
+func (h *collectionsHandler) FindOneCollection(c *fiber.Ctx) error {
+	collectionId := strings.Trim(c.Params("collection_id"), " ")
+
+	collection, err := h.collectionsUsecase.FindOneCollection(collectionId)
+	if err != nil {
+		switch err.Error() {
+		case "get collection failed: sql: no rows in result set":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findOneCollectionErr),
+				"collection not found",
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findOneCollectionErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, collection).Res()
+}
+
+func (h *collectionsHandler) FindCollections(c *fiber.Ctx) error {
+	req := new(collections.CollectionFilter)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findCollectionsErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.collectionsUsecase.FindCollections(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findCollectionsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
+
+func (h *collectionsHandler) InsertCollection(c *fiber.Ctx) error {
+	req := new(collections.CollectionReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertCollectionErr),
+			err.Error(),
+		).Res()
+	}
+	req.OwnerId = c.Locals("userId").(string)
+
+	collection, err := h.collectionsUsecase.InsertCollection(req)
+	if err != nil {
+		switch err.Error() {
+		case "title is required",
+			"category id must be greater than zero",
+			"cover must be an uploaded file",
+			"category not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertCollectionErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertCollectionErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, collection).Res()
+}
+
+func (h *collectionsHandler) UpdateCollection(c *fiber.Ctx) error {
+	req := new(collections.CollectionReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateCollectionErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = strings.Trim(c.Params("collection_id"), " ")
+	req.OwnerId = c.Locals("userId").(string)
+
+	collection, err := h.collectionsUsecase.UpdateCollection(req)
+	if err != nil {
+		switch err.Error() {
+		case "nothing to update",
+			"cover must be an uploaded file",
+			"category not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(updateCollectionErr),
+				err.Error(),
+			).Res()
+		case "collection not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(updateCollectionErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(updateCollectionErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, collection).Res()
+}
+
+func (h *collectionsHandler) ArchiveCollection(c *fiber.Ctx) error {
+	collectionId := strings.Trim(c.Params("collection_id"), " ")
+	userId := c.Locals("userId").(string)
+
+	if err := h.collectionsUsecase.ArchiveCollection(userId, collectionId); err != nil {
+		switch err.Error() {
+		case "collection not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(archiveCollectionErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(archiveCollectionErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, "collection archived successfully").Res()
+}
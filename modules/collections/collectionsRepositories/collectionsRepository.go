@@ -0,0 +1,236 @@
+package collectionsRepositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/collections"
+)
+
+type ICollectionsRepository interface {
+	FindOneCollection(collectionId string) (*collections.Collection, error)
+	FindCollections(req *collections.CollectionFilter) ([]*collections.Collection, error)
+	InsertCollection(req *collections.CollectionReq) (*collections.Collection, error)
+	UpdateCollection(req *collections.CollectionReq) (*collections.Collection, error)
+	ArchiveCollection(ownerId, collectionId string) error
+}
+
+type collectionsRepository struct {
+	db *sqlx.DB
+}
+
+func CollectionsRepository(db *sqlx.DB) ICollectionsRepository {
+	return &collectionsRepository{
+		db: db,
+	}
+}
+
+// collectionSelect builds one collection row with its category and cover as nested objects
+const collectionSelect = `
+	SELECT
+		"c"."id",
+		"c"."owner_id",
+		json_build_object(
+			'id', "ct"."id",
+			'title', "ct"."title"
+		) AS "category",
+		"c"."title",
+		"c"."description",
+		(CASE WHEN "c"."cover_url" IS NULL THEN NULL
+		ELSE json_build_object(
+			'file_name', "c"."cover_filename",
+			'url', "c"."cover_url"
+		) END) AS "cover",
+		"c"."status",
+		"c"."created_at",
+		"c"."update_at" AS "updated_at"
+	FROM "collections" "c"
+	LEFT JOIN "categories" "ct" ON "ct"."id" = "c"."category_id"
+`
+
+func collectionErr(action string, err error) error {
+	switch {
+	case strings.Contains(err.Error(), "collections_category_id_fkey"):
+		return fmt.Errorf("category not found")
+	case strings.Contains(err.Error(), "collections_owner_id_fkey"):
+		return fmt.Errorf("owner not found")
+	default:
+		return fmt.Errorf("%s collection failed: %v", action, err)
+	}
+}
+
+func (r *collectionsRepository) FindOneCollection(collectionId string) (*collections.Collection, error) {
+	query := `
+	SELECT
+		row_to_json("t")
+	FROM (` + collectionSelect + `
+		WHERE "c"."id" = $1
+		AND "c"."deleted_at" IS NULL
+	) AS "t";`
+
+	data := make([]byte, 0)
+	if err := r.db.Get(&data, query, collectionId); err != nil {
+		return nil, fmt.Errorf("get collection failed: %v", err)
+	}
+
+	collection := new(collections.Collection)
+	if err := json.Unmarshal(data, collection); err != nil {
+		return nil, fmt.Errorf("unmarshal collection failed: %v", err)
+	}
+	return collection, nil
+}
+
+func (r *collectionsRepository) FindCollections(req *collections.CollectionFilter) ([]*collections.Collection, error) {
+	conditions := []string{`"c"."deleted_at" IS NULL`}
+	filterValues := make([]any, 0)
+
+	if req.Search != "" {
+		filterValues = append(filterValues, "%"+strings.ToLower(req.Search)+"%")
+		conditions = append(conditions, fmt.Sprintf(`(LOWER("c"."title") LIKE $%d OR LOWER("c"."description") LIKE $%d)`, len(filterValues), len(filterValues)))
+	}
+	if req.OwnerId != "" {
+		filterValues = append(filterValues, req.OwnerId)
+		conditions = append(conditions, fmt.Sprintf(`"c"."owner_id" = $%d`, len(filterValues)))
+	}
+	if req.CategoryId > 0 {
+		filterValues = append(filterValues, req.CategoryId)
+		conditions = append(conditions, fmt.Sprintf(`"c"."category_id" = $%d`, len(filterValues)))
+	}
+	if req.Status != "" {
+		filterValues = append(filterValues, req.Status)
+		conditions = append(conditions, fmt.Sprintf(`"c"."status" = $%d`, len(filterValues)))
+	}
+
+	query := `
+	SELECT
+		COALESCE(json_agg("t"), '[]'::json)
+	FROM (` + collectionSelect + `
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY "c"."created_at" DESC
+	) AS "t";`
+
+	data := make([]byte, 0)
+	if err := r.db.Get(&data, query, filterValues...); err != nil {
+		return nil, fmt.Errorf("get collections failed: %v", err)
+	}
+
+	result := make([]*collections.Collection, 0)
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal collections failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *collectionsRepository) InsertCollection(req *collections.CollectionReq) (*collections.Collection, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	INSERT INTO "collections" (
+		"owner_id",
+		"category_id",
+		"title",
+		"description",
+		"cover_filename",
+		"cover_url"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING "id";`
+
+	var coverFileName, coverUrl any
+	if req.Cover != nil {
+		coverFileName, coverUrl = req.Cover.FileName, req.Cover.Url
+	}
+
+	if err := r.db.QueryRowContext(
+		ctx,
+		query,
+		req.OwnerId,
+		req.CategoryId,
+		req.Title,
+		req.Description,
+		coverFileName,
+		coverUrl,
+	).Scan(&req.Id); err != nil {
+		return nil, collectionErr("insert", err)
+	}
+	return r.FindOneCollection(req.Id)
+}
+
+func (r *collectionsRepository) UpdateCollection(req *collections.CollectionReq) (*collections.Collection, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	setValues := make([]string, 0)
+	values := make([]any, 0)
+
+	if req.CategoryId > 0 {
+		values = append(values, req.CategoryId)
+		setValues = append(setValues, fmt.Sprintf(`"category_id" = $%d`, len(values)))
+	}
+	if req.Title != "" {
+		values = append(values, req.Title)
+		setValues = append(setValues, fmt.Sprintf(`"title" = $%d`, len(values)))
+	}
+	if req.Description != "" {
+		values = append(values, req.Description)
+		setValues = append(setValues, fmt.Sprintf(`"description" = $%d`, len(values)))
+	}
+	if req.Cover != nil {
+		values = append(values, req.Cover.FileName, req.Cover.Url)
+		setValues = append(setValues, fmt.Sprintf(`"cover_filename" = $%d, "cover_url" = $%d`, len(values)-1, len(values)))
+	}
+	if len(setValues) == 0 {
+		return nil, fmt.Errorf("nothing to update")
+	}
+
+	values = append(values, req.Id, req.OwnerId)
+	query := fmt.Sprintf(`
+	UPDATE "collections" SET
+		%s
+	WHERE "id" = $%d
+	AND "owner_id" = $%d
+	AND "status" = '%s'
+	AND "deleted_at" IS NULL;`,
+		strings.Join(setValues, ", "),
+		len(values)-1,
+		len(values),
+		collections.Active,
+	)
+
+	result, err := r.db.ExecContext(ctx, query, values...)
+	if err != nil {
+		return nil, collectionErr("update", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, fmt.Errorf("collection not found")
+	}
+	return r.FindOneCollection(req.Id)
+}
+
+func (r *collectionsRepository) ArchiveCollection(ownerId, collectionId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	UPDATE "collections" SET
+		"status" = $1
+	WHERE "id" = $2
+	AND "owner_id" = $3
+	AND "status" <> $1
+	AND "deleted_at" IS NULL;`
+
+	result, err := r.db.ExecContext(ctx, query, collections.Archived, collectionId, ownerId)
+	if err != nil {
+		return fmt.Errorf("archive collection failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("collection not found")
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package collectionsUsecases
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/collections"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/collections/collectionsRepositories"
+)
+
+type ICollectionsUsecase interface {
+	FindOneCollection(collectionId string) (*collections.Collection, error)
+	FindCollections(req *collections.CollectionFilter) ([]*collections.Collection, error)
+	InsertCollection(req *collections.CollectionReq) (*collections.Collection, error)
+	UpdateCollection(req *collections.CollectionReq) (*collections.Collection, error)
+	ArchiveCollection(ownerId, collectionId string) error
+}
+
+type collectionsUsecase struct {
+	cfg                   config.IConfig
+	collectionsRepository collectionsRepositories.ICollectionsRepository
+}
+
+func CollectionsUsecase(cfg config.IConfig, collectionsRepository collectionsRepositories.ICollectionsRepository) ICollectionsUsecase {
+	return &collectionsUsecase{
+		cfg:                   cfg,
+		collectionsRepository: collectionsRepository,
+	}
+}
+
+func (u *collectionsUsecase) FindOneCollection(collectionId string) (*collections.Collection, error) {
+	collection, err := u.collectionsRepository.FindOneCollection(collectionId)
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+func (u *collectionsUsecase) FindCollections(req *collections.CollectionFilter) ([]*collections.Collection, error) {
+	// archived collections are only listed when asked for explicitly
+	if req.Status == "" {
+		req.Status = string(collections.Active)
+	}
+	result, err := u.collectionsRepository.FindCollections(req)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *collectionsUsecase) InsertCollection(req *collections.CollectionReq) (*collections.Collection, error) {
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	if req.CategoryId < 1 {
+		return nil, fmt.Errorf("category id must be greater than zero")
+	}
+	if req.Cover != nil && !req.Cover.IsBucketObject(u.cfg.App().GCPBucket()) {
+		return nil, fmt.Errorf("cover must be an uploaded file")
+	}
+
+	collection, err := u.collectionsRepository.InsertCollection(req)
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+func (u *collectionsUsecase) UpdateCollection(req *collections.CollectionReq) (*collections.Collection, error) {
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Cover != nil && !req.Cover.IsBucketObject(u.cfg.App().GCPBucket()) {
+		return nil, fmt.Errorf("cover must be an uploaded file")
+	}
+
+	collection, err := u.collectionsRepository.UpdateCollection(req)
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+func (u *collectionsUsecase) ArchiveCollection(ownerId, collectionId string) error {
+	if err := u.collectionsRepository.ArchiveCollection(ownerId, collectionId); err != nil {
+		return err
+	}
+	return nil
+}
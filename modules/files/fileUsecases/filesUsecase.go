@@ -102,7 +102,7 @@ func (u *filesUsecase) streamFileUpload(ctx context.Context, client *storage.Cli
 		newFile := &filesPublic{
 			file: &files.FileRes{
 				FileName: job.FileName,
-				Url:      fmt.Sprintf("https://storage.googleapis.com/%s/%s", u.cfg.App().GCPBucket(), job.Destination),
+				Url:      files.ObjectUrl(u.cfg.App().GCPBucket(), job.Destination),
 			},
 			bucket:      u.cfg.App().GCPBucket(),
 			destination: job.Destination,
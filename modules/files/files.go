@@ -1,6 +1,10 @@
 package files
 
-import "mime/multipart"
+import (
+	"fmt"
+	"mime/multipart"
+	"strings"
+)
 
 type FileReq struct {
 	File        *multipart.FileHeader `json:"file" form:"file"`
@@ -17,3 +21,13 @@ type FileRes struct {
 type DeleteFileReq struct {
 	Destination string `json:"destination" form:"destination"`
 }
+
+// ObjectUrl is the public url of an object uploaded to the bucket
+func ObjectUrl(bucket, destination string) string {
+	return fmt.Sprintf("https://storage.googleapis.com/%s/%s", bucket, destination)
+}
+
+// IsBucketObject reports whether the file was uploaded to the given bucket
+func (f *FileRes) IsBucketObject(bucket string) bool {
+	return f != nil && f.FileName != "" && strings.HasPrefix(f.Url, ObjectUrl(bucket, ""))
+}
@@ -0,0 +1,29 @@
+package indexer
+
+import (
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftindexer"
+)
+
+// RetainedBlocks is how many block hashes are kept per contract, a reorg deeper than this
+// cannot be unwound and stops the indexer until it is resolved by hand
+const RetainedBlocks = 1000
+
+type Checkpoint struct {
+	Contract    string `db:"contract" json:"contract"`
+	BlockNumber uint64 `db:"block_number" json:"block_number"`
+	BlockHash   string `db:"block_hash" json:"block_hash"`
+	UpdatedAt   string `db:"update_at" json:"updated_at"`
+}
+
+// Batch is a run of consecutive blocks with the transfers they contain, applied atomically
+type Batch struct {
+	Blocks    []*nftindexer.Block
+	Transfers []*nftindexer.Transfer
+}
+
+type Status struct {
+	Contract    string      `json:"contract"`
+	Checkpoint  *Checkpoint `json:"checkpoint"`
+	LatestBlock uint64      `json:"latest_block"`
+	Lag         uint64      `json:"lag"`
+}
@@ -0,0 +1,43 @@
+package indexerHandlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/entities"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/indexer/indexerUsecases"
+)
+
+type indexerHandlersErrCode string
+
+const (
+	findStatusErr indexerHandlersErrCode = "indexer-001"
+)
+
+type IIndexerHandler interface {
+	FindStatus(c *fiber.Ctx) error
+}
+
+type indexerHandler struct {
+	cfg            config.IConfig
+	indexerUsecase indexerUsecases.IIndexerUsecase
+}
+
+func IndexerHandler(cfg config.IConfig, indexerUsecase indexerUsecases.IIndexerUsecase) IIndexerHandler {
+	return &indexerHandler{
+		cfg:            cfg,
+		indexerUsecase: indexerUsecase,
+	}
+}
+
+func (h *indexerHandler) FindStatus(c *fiber.Ctx) error {
+	status, err := h.indexerUsecase.FindStatus()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findStatusErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, status).Res()
+}
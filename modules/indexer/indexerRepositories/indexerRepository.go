@@ -0,0 +1,428 @@
+package indexerRepositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/auctions"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/indexer"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/listings"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/offers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets/walletsPatterns"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftindexer"
+)
+
+type IIndexerRepository interface {
+	FindCheckpoint(contract string) (*indexer.Checkpoint, error)
+	FindBlockHash(contract string, number uint64) (string, error)
+	ApplyBatch(contract string, batch *indexer.Batch) error
+	Rewind(contract string, to *nftindexer.Block) error
+}
+
+type indexerRepository struct {
+	db *sqlx.DB
+}
+
+func IndexerRepository(db *sqlx.DB) IIndexerRepository {
+	return &indexerRepository{
+		db: db,
+	}
+}
+
+func (r *indexerRepository) FindCheckpoint(contract string) (*indexer.Checkpoint, error) {
+	query := `
+	SELECT
+		"contract",
+		"block_number",
+		"block_hash",
+		"update_at"
+	FROM "indexer_checkpoints"
+	WHERE "contract" = $1;`
+
+	checkpoint := new(indexer.Checkpoint)
+	if err := r.db.Get(checkpoint, query, contract); err != nil {
+		return nil, fmt.Errorf("get checkpoint failed: %w", err)
+	}
+	return checkpoint, nil
+}
+
+func (r *indexerRepository) FindBlockHash(contract string, number uint64) (string, error) {
+	query := `
+	SELECT "hash"
+	FROM "indexer_blocks"
+	WHERE "contract" = $1
+	AND "number" = $2;`
+
+	var hash string
+	if err := r.db.Get(&hash, query, contract, number); err != nil {
+		return "", fmt.Errorf("get block failed: %w", err)
+	}
+	return hash, nil
+}
+
+// ApplyBatch records the blocks and their transfers, moves ownership in log order and advances
+// the checkpoint in one transaction, so a crash resumes from the last fully applied batch
+func (r *indexerRepository) ApplyBatch(contract string, batch *indexer.Batch) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, block := range batch.Blocks {
+		if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "indexer_blocks" (
+			"contract",
+			"number",
+			"hash",
+			"parent_hash"
+		)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT ("contract", "number") DO UPDATE SET
+			"hash" = EXCLUDED."hash",
+			"parent_hash" = EXCLUDED."parent_hash";`,
+			contract,
+			block.Number,
+			block.Hash,
+			block.ParentHash,
+		); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("insert block failed: %v", err)
+		}
+	}
+
+	for _, transfer := range batch.Transfers {
+		// the first transfer the indexer sees of a token keeps the owner it had before
+		if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "indexer_owner_snapshots" (
+			"contract",
+			"token_id",
+			"owner_id",
+			"owner_address"
+		)
+		SELECT $1, "token_id", "owner_id", "owner_address"
+		FROM "nfts"
+		WHERE "token_id" = $2
+		AND "deleted_at" IS NULL
+		AND NOT EXISTS (
+			SELECT 1
+			FROM "indexer_transfers"
+			WHERE "contract" = $1
+			AND "token_id" = $2
+		)
+		ON CONFLICT ("contract", "token_id") DO NOTHING;`,
+			contract,
+			transfer.TokenId,
+		); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("insert owner snapshot failed: %v", err)
+		}
+
+		// the local owner is the user the receiving address is linked to, a token sent to an
+		// unlinked address is left without one
+		moved := new(struct {
+			NftId           string  `db:"id"`
+			OwnerId         *string `db:"owner_id"`
+			PreviousOwnerId *string `db:"previous_owner_id"`
+		})
+		if err := tx.GetContext(ctx, moved, `
+		WITH "p" AS (
+			SELECT
+				"id",
+				"owner_id"
+			FROM "nfts"
+			WHERE "token_id" = $2
+			AND "deleted_at" IS NULL
+			FOR UPDATE
+		)
+		UPDATE "nfts" "n" SET
+			"owner_address" = $1,
+			"owner_id" = (
+				SELECT "user_id"
+				FROM "user_addresses"
+				WHERE "address" = $1
+			)
+		FROM "p"
+		WHERE "n"."id" = "p"."id"
+		RETURNING "n"."id", "n"."owner_id", "p"."owner_id" AS "previous_owner_id";`,
+			transfer.To,
+			transfer.TokenId,
+		); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			tx.Rollback()
+			return fmt.Errorf("update owner failed: %v", err)
+		}
+		if moved.NftId != "" && !sameOwner(moved.OwnerId, moved.PreviousOwnerId) {
+			if err := closeTrading(ctx, tx, moved.NftId); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "indexer_transfers" (
+			"contract",
+			"block_number",
+			"block_hash",
+			"tx_hash",
+			"log_index",
+			"token_id",
+			"from_address",
+			"to_address",
+			"owner_id"
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT ("contract", "block_hash", "log_index") DO NOTHING;`,
+			contract,
+			transfer.BlockNumber,
+			transfer.BlockHash,
+			transfer.TxHash,
+			transfer.LogIndex,
+			transfer.TokenId,
+			transfer.From,
+			transfer.To,
+			moved.OwnerId,
+		); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("insert transfer failed: %v", err)
+		}
+	}
+
+	last := batch.Blocks[len(batch.Blocks)-1]
+	if _, err := tx.ExecContext(ctx, `
+	INSERT INTO "indexer_checkpoints" (
+		"contract",
+		"block_number",
+		"block_hash"
+	)
+	VALUES ($1, $2, $3)
+	ON CONFLICT ("contract") DO UPDATE SET
+		"block_number" = EXCLUDED."block_number",
+		"block_hash" = EXCLUDED."block_hash";`,
+		contract,
+		last.Number,
+		last.Hash,
+	); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update checkpoint failed: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	DELETE FROM "indexer_blocks"
+	WHERE "contract" = $1
+	AND "number" < $2;`,
+		contract,
+		int64(last.Number)-indexer.RetainedBlocks,
+	); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("prune blocks failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit batch failed: %v", err)
+	}
+	return nil
+}
+
+// sameOwner compares owner ids that are nil for a token without a local owner
+func sameOwner(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// closeTrading ends everything the previous owner had open on an nft that moved on-chain, its
+// active listing and auction are cancelled and the held bid and offer prices go back to the buyers
+func closeTrading(ctx context.Context, tx *sqlx.Tx, nftId string) error {
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE "listings" SET
+		"status" = $1
+	WHERE "nft_id" = $2
+	AND "status" = $3;`,
+		listings.Cancelled,
+		nftId,
+		listings.Active,
+	); err != nil {
+		return fmt.Errorf("cancel listings failed: %v", err)
+	}
+
+	// holds are keyed on the auction and offer ids they were taken for
+	holds := make([]*struct {
+		Reference string  `db:"reference"`
+		UserId    string  `db:"user_id"`
+		Amount    float64 `db:"amount"`
+	}, 0)
+	if err := tx.SelectContext(ctx, &holds, `
+	WITH "a" AS (
+		UPDATE "auctions" SET
+			"status" = $1
+		WHERE "nft_id" = $2
+		AND "status" = $3
+		RETURNING "id", "highest_bid_id"
+	), "o" AS (
+		UPDATE "offers" SET
+			"status" = $4
+		WHERE "nft_id" = $2
+		AND "status" = $5
+		RETURNING "id", "buyer_id", "price"
+	)
+	SELECT
+		"a"."id"::text AS "reference",
+		"b"."bidder_id" AS "user_id",
+		"b"."amount"
+	FROM "a"
+	JOIN "auction_bids" "b" ON "b"."id" = "a"."highest_bid_id"
+	UNION ALL
+	SELECT
+		"o"."id"::text,
+		"o"."buyer_id",
+		"o"."price"
+	FROM "o";`,
+		auctions.Cancelled,
+		nftId,
+		auctions.Active,
+		offers.Cancelled,
+		offers.Pending,
+	); err != nil {
+		return fmt.Errorf("cancel auctions and offers failed: %v", err)
+	}
+
+	userIds := make([]string, 0, len(holds))
+	for _, hold := range holds {
+		userIds = append(userIds, hold.UserId)
+	}
+	if err := walletsPatterns.LockUsers(ctx, tx, userIds...); err != nil {
+		return err
+	}
+	for _, hold := range holds {
+		if err := walletsPatterns.Release(ctx, tx, hold.UserId, hold.Amount, hold.Reference); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rewind drops every block and transfer after the fork point and sets the owners of the
+// affected tokens back to the last transfer that is still on the canonical chain, or to the
+// owner they had before the indexer first moved them
+func (r *indexerRepository) Rewind(contract string, to *nftindexer.Block) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	tokenIds := make([]int64, 0)
+	if err := tx.SelectContext(ctx, &tokenIds, `
+	DELETE FROM "indexer_transfers"
+	WHERE "contract" = $1
+	AND "block_number" > $2
+	RETURNING "token_id";`,
+		contract,
+		to.Number,
+	); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("delete transfers failed: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	DELETE FROM "indexer_blocks"
+	WHERE "contract" = $1
+	AND "number" > $2;`,
+		contract,
+		to.Number,
+	); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("delete blocks failed: %v", err)
+	}
+
+	reverted := make(map[int64]bool)
+	for _, tokenId := range tokenIds {
+		if reverted[tokenId] {
+			continue
+		}
+		reverted[tokenId] = true
+
+		if err := revertOwner(ctx, tx, contract, tokenId); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE "indexer_checkpoints" SET
+		"block_number" = $1,
+		"block_hash" = $2
+	WHERE "contract" = $3;`,
+		to.Number,
+		to.Hash,
+		contract,
+	); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("rewind checkpoint failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit rewind failed: %v", err)
+	}
+	return nil
+}
+
+// revertOwner restores both owner fields of a token from its last surviving transfer, a token
+// left without transfers gets its snapshot back and the snapshot is retaken on the next transfer
+func revertOwner(ctx context.Context, tx *sqlx.Tx, contract string, tokenId int64) error {
+	result, err := tx.ExecContext(ctx, `
+	UPDATE "nfts" "n" SET
+		"owner_address" = "t"."to_address",
+		"owner_id" = "t"."owner_id"
+	FROM (
+		SELECT
+			"to_address",
+			"owner_id"
+		FROM "indexer_transfers"
+		WHERE "contract" = $1
+		AND "token_id" = $2
+		ORDER BY "block_number" DESC, "log_index" DESC
+		LIMIT 1
+	) "t"
+	WHERE "n"."token_id" = $2
+	AND "n"."deleted_at" IS NULL;`,
+		contract,
+		tokenId,
+	)
+	if err != nil {
+		return fmt.Errorf("revert owner failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	WITH "s" AS (
+		DELETE FROM "indexer_owner_snapshots"
+		WHERE "contract" = $1
+		AND "token_id" = $2
+		RETURNING "owner_id", "owner_address"
+	)
+	UPDATE "nfts" "n" SET
+		"owner_id" = "s"."owner_id",
+		"owner_address" = "s"."owner_address"
+	FROM "s"
+	WHERE "n"."token_id" = $2
+	AND "n"."deleted_at" IS NULL;`,
+		contract,
+		tokenId,
+	); err != nil {
+		return fmt.Errorf("restore owner snapshot failed: %v", err)
+	}
+	return nil
+}
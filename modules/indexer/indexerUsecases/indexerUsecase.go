@@ -0,0 +1,195 @@
+package indexerUsecases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/indexer"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/indexer/indexerRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nfteth"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftindexer"
+)
+
+type IIndexerUsecase interface {
+	Sync() error
+	FindStatus() (*indexer.Status, error)
+}
+
+type indexerUsecase struct {
+	cfg               config.IConfig
+	indexerRepository indexerRepositories.IIndexerRepository
+	source            nftindexer.ChainSource
+}
+
+func IndexerUsecase(cfg config.IConfig, indexerRepository indexerRepositories.IIndexerRepository, source nftindexer.ChainSource) IIndexerUsecase {
+	return &indexerUsecase{
+		cfg:               cfg,
+		indexerRepository: indexerRepository,
+		source:            source,
+	}
+}
+
+func (u *indexerUsecase) contract() (string, error) {
+	contract, err := nfteth.NormalizeAddress(u.cfg.Indexer().Contract())
+	if err != nil {
+		return "", fmt.Errorf("indexer contract: %v", err)
+	}
+	return contract, nil
+}
+
+// Sync applies at most one batch of blocks after the checkpoint, when a block no longer builds on
+// the checkpoint the chain has reorganised and the indexer rewinds to the fork point instead
+func (u *indexerUsecase) Sync() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	contract, err := u.contract()
+	if err != nil {
+		return err
+	}
+
+	next := u.cfg.Indexer().StartBlock()
+	var parentHash string
+	checkpoint, err := u.indexerRepository.FindCheckpoint(contract)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+	} else {
+		next = checkpoint.BlockNumber + 1
+		parentHash = checkpoint.BlockHash
+	}
+
+	latest, err := u.source.LatestBlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	if latest < next {
+		return nil
+	}
+	last := next + uint64(u.cfg.Indexer().BatchSize()) - 1
+	if last > latest {
+		last = latest
+	}
+
+	batch := &indexer.Batch{
+		Blocks:    make([]*nftindexer.Block, 0),
+		Transfers: make([]*nftindexer.Transfer, 0),
+	}
+	for number := next; number <= last; number++ {
+		block, err := u.source.BlockByNumber(ctx, number)
+		if err != nil {
+			return err
+		}
+		if parentHash != "" && !strings.EqualFold(block.ParentHash, parentHash) {
+			if len(batch.Blocks) == 0 {
+				return u.rewind(ctx, contract, number-1)
+			}
+			// the blocks so far are consistent, the next sync finds the fork
+			break
+		}
+		batch.Blocks = append(batch.Blocks, block)
+		parentHash = block.Hash
+	}
+	if len(batch.Blocks) == 0 {
+		return nil
+	}
+	last = batch.Blocks[len(batch.Blocks)-1].Number
+
+	logs, err := u.source.Logs(ctx, &nftindexer.LogFilter{
+		FromBlock: next,
+		ToBlock:   last,
+		Address:   contract,
+		Topic:     nftindexer.TransferTopic,
+	})
+	if err != nil {
+		return err
+	}
+
+	hashes := make(map[uint64]string)
+	for _, block := range batch.Blocks {
+		hashes[block.Number] = block.Hash
+	}
+	for _, log := range logs {
+		hash, ok := hashes[log.BlockNumber]
+		if !ok {
+			continue
+		}
+		if !strings.EqualFold(hash, log.BlockHash) {
+			return fmt.Errorf("logs of block %d belong to another fork, retrying", log.BlockNumber)
+		}
+		transfer, ok, err := nftindexer.DecodeTransfer(log)
+		if err != nil {
+			return err
+		}
+		if ok {
+			batch.Transfers = append(batch.Transfers, transfer)
+		}
+	}
+	sort.Slice(batch.Transfers, func(i, j int) bool {
+		if batch.Transfers[i].BlockNumber != batch.Transfers[j].BlockNumber {
+			return batch.Transfers[i].BlockNumber < batch.Transfers[j].BlockNumber
+		}
+		return batch.Transfers[i].LogIndex < batch.Transfers[j].LogIndex
+	})
+
+	return u.indexerRepository.ApplyBatch(contract, batch)
+}
+
+// rewind walks back from number until the stored hash matches the chain again
+func (u *indexerUsecase) rewind(ctx context.Context, contract string, number uint64) error {
+	for n := number; ; n-- {
+		stored, err := u.indexerRepository.FindBlockHash(contract, n)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("reorg at block %d is deeper than the retained history", number)
+			}
+			return err
+		}
+		block, err := u.source.BlockByNumber(ctx, n)
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(stored, block.Hash) {
+			return u.indexerRepository.Rewind(contract, block)
+		}
+		if n == 0 {
+			return fmt.Errorf("reorg at block %d reaches genesis", number)
+		}
+	}
+}
+
+func (u *indexerUsecase) FindStatus() (*indexer.Status, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	contract, err := u.contract()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &indexer.Status{
+		Contract: contract,
+	}
+	checkpoint, err := u.indexerRepository.FindCheckpoint(contract)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	status.Checkpoint = checkpoint
+
+	latest, err := u.source.LatestBlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status.LatestBlock = latest
+	if checkpoint != nil && latest > checkpoint.BlockNumber {
+		status.Lag = latest - checkpoint.BlockNumber
+	}
+	return status, nil
+}
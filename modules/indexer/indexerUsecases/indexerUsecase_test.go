@@ -0,0 +1,259 @@
+package indexerUsecases
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/indexer"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftindexer"
+)
+
+const (
+	testContract = "0x00000000000000000000000000000000000000c0"
+	addressZero  = "0x0000000000000000000000000000000000000000"
+	addressA     = "0x00000000000000000000000000000000000000a1"
+	addressB     = "0x00000000000000000000000000000000000000b2"
+	addressC     = "0x00000000000000000000000000000000000000c3"
+)
+
+type testIndexerConfig struct {
+	path string
+}
+
+func (c *testIndexerConfig) Source() string          { return "file" }
+func (c *testIndexerConfig) RpcUrl() string          { return "" }
+func (c *testIndexerConfig) FilePath() string        { return c.path }
+func (c *testIndexerConfig) Contract() string        { return testContract }
+func (c *testIndexerConfig) StartBlock() uint64      { return 1 }
+func (c *testIndexerConfig) BatchSize() int          { return 10 }
+func (c *testIndexerConfig) Interval() time.Duration { return time.Second }
+
+type testConfig struct {
+	config.IConfig
+	indexer *testIndexerConfig
+}
+
+func (c *testConfig) Indexer() config.IIndexerConfig { return c.indexer }
+
+// memoryRepository keeps the indexer tables in maps, the ownership is read back from the transfers
+type memoryRepository struct {
+	checkpoint *indexer.Checkpoint
+	blocks     map[uint64]string
+	transfers  []*nftindexer.Transfer
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		blocks:    make(map[uint64]string),
+		transfers: make([]*nftindexer.Transfer, 0),
+	}
+}
+
+func (r *memoryRepository) FindCheckpoint(contract string) (*indexer.Checkpoint, error) {
+	if r.checkpoint == nil {
+		return nil, fmt.Errorf("get checkpoint failed: %w", sql.ErrNoRows)
+	}
+	return r.checkpoint, nil
+}
+
+func (r *memoryRepository) FindBlockHash(contract string, number uint64) (string, error) {
+	hash, ok := r.blocks[number]
+	if !ok {
+		return "", fmt.Errorf("get block failed: %w", sql.ErrNoRows)
+	}
+	return hash, nil
+}
+
+func (r *memoryRepository) ApplyBatch(contract string, batch *indexer.Batch) error {
+	for _, block := range batch.Blocks {
+		r.blocks[block.Number] = block.Hash
+	}
+	r.transfers = append(r.transfers, batch.Transfers...)
+	last := batch.Blocks[len(batch.Blocks)-1]
+	r.checkpoint = &indexer.Checkpoint{
+		Contract:    contract,
+		BlockNumber: last.Number,
+		BlockHash:   last.Hash,
+	}
+	return nil
+}
+
+func (r *memoryRepository) Rewind(contract string, to *nftindexer.Block) error {
+	for number := range r.blocks {
+		if number > to.Number {
+			delete(r.blocks, number)
+		}
+	}
+	kept := make([]*nftindexer.Transfer, 0)
+	for _, transfer := range r.transfers {
+		if transfer.BlockNumber <= to.Number {
+			kept = append(kept, transfer)
+		}
+	}
+	r.transfers = kept
+	r.checkpoint.BlockNumber = to.Number
+	r.checkpoint.BlockHash = to.Hash
+	return nil
+}
+
+func (r *memoryRepository) owner(tokenId int64) string {
+	owner := ""
+	for _, transfer := range r.transfers {
+		if transfer.TokenId == tokenId {
+			owner = transfer.To
+		}
+	}
+	return owner
+}
+
+type testBlock struct {
+	Number     uint64     `json:"number"`
+	Hash       string     `json:"hash"`
+	ParentHash string     `json:"parent_hash"`
+	Logs       []*testLog `json:"logs"`
+}
+
+type testLog struct {
+	Address  string   `json:"address"`
+	Topics   []string `json:"topics"`
+	TxHash   string   `json:"tx_hash"`
+	LogIndex uint     `json:"log_index"`
+}
+
+func topic(hex string) string {
+	return "0x" + strings.Repeat("0", 64-len(strings.TrimPrefix(hex, "0x"))) + strings.TrimPrefix(hex, "0x")
+}
+
+func transferLog(from, to string, tokenId int64, logIndex uint) *testLog {
+	return &testLog{
+		Address:  testContract,
+		Topics:   []string{nftindexer.TransferTopic, topic(from), topic(to), topic(fmt.Sprintf("%x", tokenId))},
+		TxHash:   topic(fmt.Sprintf("%x", tokenId*100+int64(logIndex))),
+		LogIndex: logIndex,
+	}
+}
+
+func hash(name string) string {
+	return topic(fmt.Sprintf("%x", name))
+}
+
+func writeChain(t *testing.T, path string, blocks []*testBlock) {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"blocks": blocks})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestUsecase(t *testing.T) (*indexerUsecase, *memoryRepository, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "chain.json")
+	repo := newMemoryRepository()
+	usecase := IndexerUsecase(
+		&testConfig{indexer: &testIndexerConfig{path: path}},
+		repo,
+		nftindexer.NewFileSource(path),
+	).(*indexerUsecase)
+	return usecase, repo, path
+}
+
+func TestSyncIngestsTransfers(t *testing.T) {
+	usecase, repo, path := newTestUsecase(t)
+
+	erc20 := transferLog(addressA, addressB, 1, 1)
+	erc20.Topics = erc20.Topics[:3]
+	otherContract := transferLog(addressA, addressB, 7, 2)
+	otherContract.Address = addressC
+
+	writeChain(t, path, []*testBlock{
+		{Number: 1, Hash: hash("1"), ParentHash: hash("0")},
+		{Number: 2, Hash: hash("2"), ParentHash: hash("1"), Logs: []*testLog{
+			transferLog(addressZero, addressA, 7, 0),
+			erc20,
+			otherContract,
+		}},
+		{Number: 3, Hash: hash("3"), ParentHash: hash("2"), Logs: []*testLog{
+			transferLog(addressA, addressB, 7, 0),
+		}},
+	})
+
+	if err := usecase.Sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if repo.checkpoint == nil || repo.checkpoint.BlockNumber != 3 || repo.checkpoint.BlockHash != hash("3") {
+		t.Fatalf("checkpoint = %+v, want block 3", repo.checkpoint)
+	}
+	if len(repo.transfers) != 2 {
+		t.Fatalf("got %d transfers, want 2", len(repo.transfers))
+	}
+	if owner := repo.owner(7); !strings.EqualFold(owner, addressB) {
+		t.Fatalf("owner = %s, want %s", owner, addressB)
+	}
+
+	// nothing new on the chain
+	if err := usecase.Sync(); err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if len(repo.transfers) != 2 {
+		t.Fatalf("got %d transfers after idle sync, want 2", len(repo.transfers))
+	}
+}
+
+func TestSyncRewindsReorg(t *testing.T) {
+	usecase, repo, path := newTestUsecase(t)
+
+	writeChain(t, path, []*testBlock{
+		{Number: 1, Hash: hash("1"), ParentHash: hash("0")},
+		{Number: 2, Hash: hash("2"), ParentHash: hash("1"), Logs: []*testLog{
+			transferLog(addressZero, addressA, 7, 0),
+		}},
+		{Number: 3, Hash: hash("3"), ParentHash: hash("2"), Logs: []*testLog{
+			transferLog(addressA, addressB, 7, 0),
+		}},
+	})
+	if err := usecase.Sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	// block 3 is replaced by a fork that sends the token elsewhere
+	writeChain(t, path, []*testBlock{
+		{Number: 1, Hash: hash("1"), ParentHash: hash("0")},
+		{Number: 2, Hash: hash("2"), ParentHash: hash("1"), Logs: []*testLog{
+			transferLog(addressZero, addressA, 7, 0),
+		}},
+		{Number: 3, Hash: hash("3b"), ParentHash: hash("2"), Logs: []*testLog{
+			transferLog(addressA, addressC, 7, 0),
+		}},
+		{Number: 4, Hash: hash("4b"), ParentHash: hash("3b")},
+	})
+
+	if err := usecase.Sync(); err != nil {
+		t.Fatalf("rewind sync: %v", err)
+	}
+	if repo.checkpoint.BlockNumber != 2 || repo.checkpoint.BlockHash != hash("2") {
+		t.Fatalf("checkpoint = %+v, want fork point 2", repo.checkpoint)
+	}
+	if owner := repo.owner(7); !strings.EqualFold(owner, addressA) {
+		t.Fatalf("owner after rewind = %s, want %s", owner, addressA)
+	}
+
+	if err := usecase.Sync(); err != nil {
+		t.Fatalf("sync after rewind: %v", err)
+	}
+	if repo.checkpoint.BlockNumber != 4 || repo.checkpoint.BlockHash != hash("4b") {
+		t.Fatalf("checkpoint = %+v, want block 4 of the fork", repo.checkpoint)
+	}
+	if owner := repo.owner(7); !strings.EqualFold(owner, addressC) {
+		t.Fatalf("owner on the fork = %s, want %s", owner, addressC)
+	}
+}
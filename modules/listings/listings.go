@@ -0,0 +1,106 @@
+package listings
+
+import (
+	"math"
+	"time"
+
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/utils"
+)
+
+type ListingStatus string
+
+const (
+	Active    ListingStatus = "active"
+	Sold      ListingStatus = "sold"
+	Cancelled ListingStatus = "cancelled"
+)
+
+type ListingType string
+
+const (
+	Fixed ListingType = "fixed"
+	Dutch ListingType = "dutch"
+)
+
+type PriceCurve string
+
+const (
+	Linear      PriceCurve = "linear"
+	Exponential PriceCurve = "exponential"
+)
+
+type Listing struct {
+	Id       string      `json:"id" db:"id"`
+	NftId    string      `json:"nft_id" db:"nft_id"`
+	TokenId  int64       `json:"token_id" db:"token_id"`
+	SellerId string      `json:"seller_id" db:"seller_id"`
+	Type     ListingType `json:"type" db:"type"`
+	// Price is the fixed price, a dutch listing holds its start price until it sells at the decayed price
+	Price        float64       `json:"price" db:"price"`
+	CurrentPrice float64       `json:"current_price" db:"-"`
+	StartPrice   float64       `json:"start_price,omitempty" db:"start_price"`
+	FloorPrice   float64       `json:"floor_price,omitempty" db:"floor_price"`
+	Curve        PriceCurve    `json:"curve,omitempty" db:"curve"`
+	StartTime    *time.Time    `json:"start_time,omitempty" db:"start_time"`
+	EndTime      *time.Time    `json:"end_time,omitempty" db:"end_time"`
+	Status       ListingStatus `json:"status" db:"status"`
+	BuyerId      string        `json:"buyer_id,omitempty" db:"buyer_id"`
+	CreatedAt    string        `json:"created_at" db:"created_at"`
+	UpdatedAt    string        `json:"updated_at" db:"update_at"`
+}
+
+type ListingReq struct {
+	SellerId   string      `json:"-" db:"seller_id"`
+	TokenId    int64       `json:"token_id" db:"token_id" form:"token_id"`
+	Type       ListingType `json:"type" db:"type" form:"type"`
+	Price      float64     `json:"price" db:"price" form:"price"`
+	StartPrice float64     `json:"start_price" db:"start_price" form:"start_price"`
+	FloorPrice float64     `json:"floor_price" db:"floor_price" form:"floor_price"`
+	Curve      PriceCurve  `json:"curve" db:"curve" form:"curve"`
+	StartTime  time.Time   `json:"start_time" db:"start_time" form:"start_time"`
+	EndTime    time.Time   `json:"end_time" db:"end_time" form:"end_time"`
+}
+
+type ListingFilter struct {
+	SellerId string `query:"seller_id"`
+	TokenId  int64  `query:"token_id"`
+	Type     string `query:"type"`
+	Status   string `query:"status"`
+}
+
+type PurchaseReq struct {
+	ListingId string `json:"-"`
+	BuyerId   string `json:"-"`
+	// RequestedAt fixes the dutch price to the moment the purchase was requested
+	RequestedAt    time.Time `json:"-"`
+	PlatformFeeBps int       `json:"-"`
+}
+
+// PriceAt returns the asking price at t, a dutch listing decays from the start price
+// to the floor price over its duration and then stays at the floor
+func (obj *Listing) PriceAt(t time.Time) float64 {
+	if obj.Type != Dutch || obj.StartTime == nil || obj.EndTime == nil {
+		return obj.Price
+	}
+
+	progress := float64(t.Sub(*obj.StartTime)) / float64(obj.EndTime.Sub(*obj.StartTime))
+	progress = math.Max(0, math.Min(1, progress))
+
+	var price float64
+	switch obj.Curve {
+	case Exponential:
+		price = obj.StartPrice * math.Pow(obj.FloorPrice/obj.StartPrice, progress)
+	default:
+		price = obj.StartPrice - (obj.StartPrice-obj.FloorPrice)*progress
+	}
+	return math.Max(obj.FloorPrice, utils.RoundPrice(price))
+}
+
+// SetCurrentPrice fills CurrentPrice with the asking price at t, closed listings keep their final price
+func (obj *Listing) SetCurrentPrice(t time.Time) {
+	if obj.Status != Active {
+		obj.CurrentPrice = obj.Price
+		return
+	}
+	obj.CurrentPrice = obj.PriceAt(t)
+}
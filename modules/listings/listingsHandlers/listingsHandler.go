@@ -0,0 +1,231 @@
+package listingsHandlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/entities"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/listings"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/listings/listingsUsecases"
+)
+
+type listingsHandlersErrCode string
+
+const (
+	findOneListingErr  listingsHandlersErrCode = "listings-001"
+	findListingsErr    listingsHandlersErrCode = "listings-002"
+	insertListingErr   listingsHandlersErrCode = "listings-003"
+	cancelListingErr   listingsHandlersErrCode = "listings-004"
+	purchaseListingErr listingsHandlersErrCode = "listings-005"
+)
+
+type IListingsHandler interface {
+	FindOneListing(c *fiber.Ctx) error
+	FindListings(c *fiber.Ctx) error
+	InsertListing(c *fiber.Ctx) error
+	CancelListing(c *fiber.Ctx) error
+	PurchaseListing(c *fiber.Ctx) error
+}
+
+type listingsHandler struct {
+	cfg             config.IConfig
+	listingsUsecase listingsUsecases.IListingsUsecase
+}
+
+func ListingsHandler(cfg config.IConfig, listingsUsecase listingsUsecases.IListingsUsecase) IListingsHandler {
+	return &listingsHandler{
+		cfg:             cfg,
+		listingsUsecase: listingsUsecase,
+	}
+}
+
+func listingIdParam(c *fiber.Ctx) (string, bool) {
+	listingId := strings.Trim(c.Params("listing_id"), " ")
+	if _, err := uuid.Parse(listingId); err != nil {
+		return "", false
+	}
+	return listingId, true
+}
+
+func (h *listingsHandler) FindOneListing(c *fiber.Ctx) error {
+	listingId, ok := listingIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findOneListingErr),
+			"invalid listing id",
+		).Res()
+	}
+
+	listing, err := h.listingsUsecase.FindOneListing(listingId)
+	if err != nil {
+		switch err.Error() {
+		case "get listing failed: sql: no rows in result set":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findOneListingErr),
+				"listing not found",
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findOneListingErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, listing).Res()
+}
+
+func (h *listingsHandler) FindListings(c *fiber.Ctx) error {
+	req := new(listings.ListingFilter)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findListingsErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.listingsUsecase.FindListings(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findListingsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
+
+func (h *listingsHandler) InsertListing(c *fiber.Ctx) error {
+	req := new(listings.ListingReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertListingErr),
+			err.Error(),
+		).Res()
+	}
+	req.SellerId = c.Locals("userId").(string)
+
+	listing, err := h.listingsUsecase.InsertListing(req)
+	if err != nil {
+		switch err.Error() {
+		case "token id must be greater than zero",
+			"listing type must be fixed or dutch",
+			"price must be greater than zero",
+			"floor price must be greater than zero",
+			"start price must be greater than floor price",
+			"curve must be linear or exponential",
+			"end time must be after start time",
+			"dutch listing must not run longer than 30 days",
+			"nft not found",
+			"nft is already listed",
+			"nft is already in an auction":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertListingErr),
+				err.Error(),
+			).Res()
+		case "nft belongs to another user":
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(insertListingErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertListingErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, listing).Res()
+}
+
+func (h *listingsHandler) CancelListing(c *fiber.Ctx) error {
+	listingId, ok := listingIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(cancelListingErr),
+			"invalid listing id",
+		).Res()
+	}
+
+	if err := h.listingsUsecase.CancelListing(c.Locals("userId").(string), listingId); err != nil {
+		switch err.Error() {
+		case "listing not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(cancelListingErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(cancelListingErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, "listing cancelled successfully").Res()
+}
+
+func (h *listingsHandler) PurchaseListing(c *fiber.Ctx) error {
+	listingId, ok := listingIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(purchaseListingErr),
+			"invalid listing id",
+		).Res()
+	}
+
+	sale, err := h.listingsUsecase.PurchaseListing(&listings.PurchaseReq{
+		ListingId: listingId,
+		BuyerId:   c.Locals("userId").(string),
+	})
+	if err != nil {
+		switch err.Error() {
+		case "listing not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(purchaseListingErr),
+				err.Error(),
+			).Res()
+		case "listing is not active",
+			"listing has not started",
+			"seller no longer owns the nft":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(purchaseListingErr),
+				err.Error(),
+			).Res()
+		case "cannot buy your own listing":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(purchaseListingErr),
+				err.Error(),
+			).Res()
+		case "insufficient funds":
+			return entities.NewResponse(c).Error(
+				fiber.ErrPaymentRequired.Code,
+				string(purchaseListingErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(purchaseListingErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, sale).Res()
+}
@@ -0,0 +1,295 @@
+package listingsRepositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/listings"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/sales"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/sales/salesPatterns"
+)
+
+type IListingsRepository interface {
+	FindOneListing(listingId string) (*listings.Listing, error)
+	FindListings(req *listings.ListingFilter) ([]*listings.Listing, error)
+	InsertListing(req *listings.ListingReq) (*listings.Listing, error)
+	CancelListing(sellerId, listingId string) error
+	PurchaseListing(req *listings.PurchaseReq) (*sales.Sale, error)
+}
+
+type listingsRepository struct {
+	db *sqlx.DB
+}
+
+func ListingsRepository(db *sqlx.DB) IListingsRepository {
+	return &listingsRepository{
+		db: db,
+	}
+}
+
+const listingSelect = `
+	SELECT
+		"l"."id",
+		"l"."nft_id",
+		"n"."token_id",
+		"l"."seller_id",
+		"l"."type",
+		"l"."price",
+		COALESCE("l"."start_price", 0) AS "start_price",
+		COALESCE("l"."floor_price", 0) AS "floor_price",
+		COALESCE("l"."curve", '') AS "curve",
+		"l"."start_time",
+		"l"."end_time",
+		"l"."status",
+		COALESCE("l"."buyer_id", '') AS "buyer_id",
+		"l"."created_at",
+		"l"."update_at"
+	FROM "listings" "l"
+	JOIN "nfts" "n" ON "n"."id" = "l"."nft_id"
+`
+
+func (r *listingsRepository) FindOneListing(listingId string) (*listings.Listing, error) {
+	query := listingSelect + `
+	WHERE "l"."id" = $1;`
+
+	listing := new(listings.Listing)
+	if err := r.db.Get(listing, query, listingId); err != nil {
+		return nil, fmt.Errorf("get listing failed: %v", err)
+	}
+	return listing, nil
+}
+
+func (r *listingsRepository) FindListings(req *listings.ListingFilter) ([]*listings.Listing, error) {
+	conditions := make([]string, 0)
+	filterValues := make([]any, 0)
+
+	if req.SellerId != "" {
+		filterValues = append(filterValues, req.SellerId)
+		conditions = append(conditions, fmt.Sprintf(`"l"."seller_id" = $%d`, len(filterValues)))
+	}
+	if req.TokenId > 0 {
+		filterValues = append(filterValues, req.TokenId)
+		conditions = append(conditions, fmt.Sprintf(`"n"."token_id" = $%d`, len(filterValues)))
+	}
+	if req.Type != "" {
+		filterValues = append(filterValues, req.Type)
+		conditions = append(conditions, fmt.Sprintf(`"l"."type" = $%d`, len(filterValues)))
+	}
+	if req.Status != "" {
+		filterValues = append(filterValues, req.Status)
+		conditions = append(conditions, fmt.Sprintf(`"l"."status" = $%d`, len(filterValues)))
+	}
+
+	query := listingSelect
+	if len(conditions) > 0 {
+		query += `
+	WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += `
+	ORDER BY "l"."created_at" DESC;`
+
+	result := make([]*listings.Listing, 0)
+	if err := r.db.Select(&result, query, filterValues...); err != nil {
+		return nil, fmt.Errorf("get listings failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *listingsRepository) InsertListing(req *listings.ListingReq) (*listings.Listing, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	nft := new(struct {
+		Id        string `db:"id"`
+		OwnerId   string `db:"owner_id"`
+		Auctioned bool   `db:"auctioned"`
+	})
+	if err := tx.GetContext(ctx, nft, `
+	SELECT
+		"n"."id",
+		COALESCE("n"."owner_id", '') AS "owner_id",
+		EXISTS (
+			SELECT 1
+			FROM "auctions" "a"
+			WHERE "a"."nft_id" = "n"."id"
+			AND "a"."status" = 'active'
+		) AS "auctioned"
+	FROM "nfts" "n"
+	WHERE "n"."token_id" = $1
+	AND "n"."deleted_at" IS NULL
+	FOR UPDATE OF "n";`, req.TokenId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("nft not found")
+	}
+	if nft.OwnerId != req.SellerId {
+		tx.Rollback()
+		return nil, fmt.Errorf("nft belongs to another user")
+	}
+	if nft.Auctioned {
+		tx.Rollback()
+		return nil, fmt.Errorf("nft is already in an auction")
+	}
+
+	// the dutch columns stay null on fixed price listings
+	var startPrice, floorPrice, curve, startTime, endTime any
+	if req.Type == listings.Dutch {
+		startPrice, floorPrice, curve, startTime, endTime = req.StartPrice, req.FloorPrice, req.Curve, req.StartTime, req.EndTime
+	}
+
+	var listingId string
+	if err := tx.QueryRowxContext(ctx, `
+	INSERT INTO "listings" (
+		"nft_id",
+		"seller_id",
+		"type",
+		"price",
+		"start_price",
+		"floor_price",
+		"curve",
+		"start_time",
+		"end_time"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING "id";`,
+		nft.Id,
+		req.SellerId,
+		req.Type,
+		req.Price,
+		startPrice,
+		floorPrice,
+		curve,
+		startTime,
+		endTime,
+	).Scan(&listingId); err != nil {
+		tx.Rollback()
+		if strings.Contains(err.Error(), "listings_active_nft_id_key") {
+			return nil, fmt.Errorf("nft is already listed")
+		}
+		return nil, fmt.Errorf("insert listing failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit listing failed: %v", err)
+	}
+	return r.FindOneListing(listingId)
+}
+
+func (r *listingsRepository) CancelListing(sellerId, listingId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	UPDATE "listings" SET
+		"status" = $1
+	WHERE "id" = $2
+	AND "seller_id" = $3
+	AND "status" = $4;`
+
+	result, err := r.db.ExecContext(ctx, query, listings.Cancelled, listingId, sellerId, listings.Active)
+	if err != nil {
+		return fmt.Errorf("cancel listing failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("listing not found")
+	}
+	return nil
+}
+
+// PurchaseListing locks the listing row so concurrent buyers queue up behind the first one
+// and only see the listing once it is already sold, dutch listings sell at their price at req.RequestedAt
+func (r *listingsRepository) PurchaseListing(req *listings.PurchaseReq) (*sales.Sale, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	listing := new(listings.Listing)
+	if err := tx.GetContext(ctx, listing, `
+	SELECT
+		"id",
+		"nft_id",
+		"seller_id",
+		"type",
+		"price",
+		COALESCE("start_price", 0) AS "start_price",
+		COALESCE("floor_price", 0) AS "floor_price",
+		COALESCE("curve", '') AS "curve",
+		"start_time",
+		"end_time",
+		"status"
+	FROM "listings"
+	WHERE "id" = $1
+	FOR UPDATE;`, req.ListingId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("listing not found")
+	}
+	if listing.Status != listings.Active {
+		tx.Rollback()
+		return nil, fmt.Errorf("listing is not active")
+	}
+	if listing.SellerId == req.BuyerId {
+		tx.Rollback()
+		return nil, fmt.Errorf("cannot buy your own listing")
+	}
+	if listing.StartTime != nil && req.RequestedAt.Before(*listing.StartTime) {
+		tx.Rollback()
+		return nil, fmt.Errorf("listing has not started")
+	}
+	price := listing.PriceAt(req.RequestedAt)
+
+	if _, err := tx.ExecContext(ctx, `
+	SELECT "id"
+	FROM "nfts"
+	WHERE "id" = $1
+	FOR UPDATE;`, listing.NftId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("lock nft failed: %v", err)
+	}
+
+	sale := &sales.Sale{
+		NftId:    listing.NftId,
+		SellerId: listing.SellerId,
+		BuyerId:  req.BuyerId,
+		Price:    price,
+		Source:   sales.ListingSale,
+		SourceId: listing.Id,
+
+		PlatformFeeBps: req.PlatformFeeBps,
+	}
+	if err := salesPatterns.RecordSale(ctx, tx, sale); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE "listings" SET
+		"status" = $1,
+		"buyer_id" = $2,
+		"price" = $3
+	WHERE "id" = $4;`,
+		listings.Sold,
+		req.BuyerId,
+		price,
+		listing.Id,
+	); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("close listing failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit purchase failed: %v", err)
+	}
+	return sale, nil
+}
@@ -0,0 +1,141 @@
+package listingsUsecases
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/listings"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/listings/listingsRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/sales"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/utils"
+)
+
+type IListingsUsecase interface {
+	FindOneListing(listingId string) (*listings.Listing, error)
+	FindListings(req *listings.ListingFilter) ([]*listings.Listing, error)
+	InsertListing(req *listings.ListingReq) (*listings.Listing, error)
+	CancelListing(sellerId, listingId string) error
+	PurchaseListing(req *listings.PurchaseReq) (*sales.Sale, error)
+}
+
+const maxDutchDuration = time.Hour * 24 * 30
+
+type listingsUsecase struct {
+	cfg                config.IConfig
+	listingsRepository listingsRepositories.IListingsRepository
+}
+
+func ListingsUsecase(cfg config.IConfig, listingsRepository listingsRepositories.IListingsRepository) IListingsUsecase {
+	return &listingsUsecase{
+		cfg:                cfg,
+		listingsRepository: listingsRepository,
+	}
+}
+
+func validateDutchListing(req *listings.ListingReq) error {
+	req.StartPrice = utils.RoundPrice(req.StartPrice)
+	req.FloorPrice = utils.RoundPrice(req.FloorPrice)
+	if req.FloorPrice <= 0 {
+		return fmt.Errorf("floor price must be greater than zero")
+	}
+	if req.StartPrice <= req.FloorPrice {
+		return fmt.Errorf("start price must be greater than floor price")
+	}
+
+	if req.Curve == "" {
+		req.Curve = listings.Linear
+	}
+	if req.Curve != listings.Linear && req.Curve != listings.Exponential {
+		return fmt.Errorf("curve must be linear or exponential")
+	}
+
+	now := time.Now()
+	if req.StartTime.IsZero() || req.StartTime.Before(now) {
+		req.StartTime = now
+	}
+	if !req.EndTime.After(req.StartTime) {
+		return fmt.Errorf("end time must be after start time")
+	}
+	if req.EndTime.Sub(req.StartTime) > maxDutchDuration {
+		return fmt.Errorf("dutch listing must not run longer than 30 days")
+	}
+
+	// the price column holds the start price until the listing sells
+	req.Price = req.StartPrice
+	return nil
+}
+
+func (u *listingsUsecase) FindOneListing(listingId string) (*listings.Listing, error) {
+	listing, err := u.listingsRepository.FindOneListing(listingId)
+	if err != nil {
+		return nil, err
+	}
+	listing.SetCurrentPrice(time.Now())
+	return listing, nil
+}
+
+func (u *listingsUsecase) FindListings(req *listings.ListingFilter) ([]*listings.Listing, error) {
+	if req.Status == "" {
+		req.Status = string(listings.Active)
+	}
+	result, err := u.listingsRepository.FindListings(req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, listing := range result {
+		listing.SetCurrentPrice(now)
+	}
+	return result, nil
+}
+
+func (u *listingsUsecase) InsertListing(req *listings.ListingReq) (*listings.Listing, error) {
+	if req.TokenId < 1 {
+		return nil, fmt.Errorf("token id must be greater than zero")
+	}
+
+	if req.Type == "" {
+		req.Type = listings.Fixed
+	}
+	switch req.Type {
+	case listings.Fixed:
+		req.Price = utils.RoundPrice(req.Price)
+		if req.Price <= 0 {
+			return nil, fmt.Errorf("price must be greater than zero")
+		}
+	case listings.Dutch:
+		if err := validateDutchListing(req); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("listing type must be fixed or dutch")
+	}
+
+	listing, err := u.listingsRepository.InsertListing(req)
+	if err != nil {
+		return nil, err
+	}
+	listing.SetCurrentPrice(time.Now())
+	return listing, nil
+}
+
+func (u *listingsUsecase) CancelListing(sellerId, listingId string) error {
+	if err := u.listingsRepository.CancelListing(sellerId, listingId); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *listingsUsecase) PurchaseListing(req *listings.PurchaseReq) (*sales.Sale, error) {
+	// a dutch price keeps falling while the buyer waits on the row lock, the buyer pays the price they saw
+	req.RequestedAt = time.Now()
+	req.PlatformFeeBps = u.cfg.App().PlatformFeeBps()
+
+	sale, err := u.listingsRepository.PurchaseListing(req)
+	if err != nil {
+		return nil, err
+	}
+	return sale, nil
+}
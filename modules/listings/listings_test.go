@@ -0,0 +1,51 @@
+package listings
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPriceAt(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(100 * time.Hour)
+	dutch := func(curve PriceCurve, startPrice, floorPrice float64) *Listing {
+		return &Listing{
+			Type:       Dutch,
+			Price:      startPrice,
+			StartPrice: startPrice,
+			FloorPrice: floorPrice,
+			Curve:      curve,
+			StartTime:  &start,
+			EndTime:    &end,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		listing *Listing
+		at      time.Time
+		want    float64
+	}{
+		{"fixed", &Listing{Type: Fixed, Price: 42.5}, start, 42.5},
+		{"dutch without times", &Listing{Type: Dutch, Price: 10, StartPrice: 10, FloorPrice: 1}, start, 10},
+		{"linear before start", dutch(Linear, 100, 20), start.Add(-time.Hour), 100},
+		{"linear at start", dutch(Linear, 100, 20), start, 100},
+		{"linear quarter way", dutch(Linear, 100, 20), start.Add(25 * time.Hour), 80},
+		{"linear half way", dutch(Linear, 100, 20), start.Add(50 * time.Hour), 60},
+		{"linear at end", dutch(Linear, 100, 20), end, 20},
+		{"linear after end", dutch(Linear, 100, 20), end.Add(time.Hour), 20},
+		{"linear rounds to cents", dutch(Linear, 10, 0.01), start.Add(time.Hour), 9.9},
+		{"exponential before start", dutch(Exponential, 100, 25), start.Add(-time.Hour), 100},
+		{"exponential half way", dutch(Exponential, 100, 25), start.Add(50 * time.Hour), 50},
+		{"exponential quarter way", dutch(Exponential, 100, 6.25), start.Add(25 * time.Hour), 50},
+		{"exponential at end", dutch(Exponential, 100, 25), end, 25},
+		{"exponential after end", dutch(Exponential, 100, 25), end.Add(time.Hour), 25},
+		{"exponential rounds to cents", dutch(Exponential, 10, 1), start.Add(50 * time.Hour), 3.16},
+		{"rounding never goes below the floor", dutch(Linear, 1.003, 1.001), start.Add(99 * time.Hour), 1.001},
+	}
+	for _, tt := range tests {
+		if got := tt.listing.PriceAt(tt.at); got != tt.want {
+			t.Errorf("%s: PriceAt = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
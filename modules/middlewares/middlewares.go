@@ -1,6 +1,44 @@
 package middlewares
 
-type Role struct {
-	Id    int    `db:"id"`
-	Title string `db:"title"`
+import "time"
+
+// SessionMfa is the second factor state of the session a request is authenticated with
+type SessionMfa struct {
+	Enabled    bool       `db:"enabled"`
+	VerifiedAt *time.Time `db:"verified_at"`
+}
+
+// cache keys shared by the middlewares and the modules that invalidate them
+const (
+	tokenCachePrefix      = "token:"
+	permissionCachePrefix = "permission:"
+	verifiedCachePrefix   = "verified:"
+)
+
+func TokenCacheKey(userId, accessToken string) string {
+	return UserTokensCachePrefix(userId) + accessToken
+}
+
+// UserTokensCachePrefix covers every cached access token of the user
+func UserTokensCachePrefix(userId string) string {
+	return tokenCachePrefix + userId + ":"
+}
+
+func PermissionCacheKey(userId, permission string) string {
+	return UserPermissionsCachePrefix(userId) + permission
+}
+
+// UserPermissionsCachePrefix covers every cached permission check of the user
+func UserPermissionsCachePrefix(userId string) string {
+	return permissionCachePrefix + userId + ":"
+}
+
+// PermissionsCachePrefix covers the permission checks of all users
+func PermissionsCachePrefix() string {
+	return permissionCachePrefix
+}
+
+// VerifiedCacheKey only ever holds verified users, verification is never taken back
+func VerifiedCacheKey(userId string) string {
+	return verifiedCachePrefix + userId
 }
@@ -1,8 +1,8 @@
 package middlewareHandlers
 
 import (
-	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -14,7 +14,6 @@ import (
 	"github.com/muhammadfarhankt/nft-marketplace/modules/middlewares/middlewaresUsecases"
 
 	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftauth"
-	"github.com/muhammadfarhankt/nft-marketplace/pkg/utils"
 )
 
 type middlewareHandlersErrCode string
@@ -25,16 +24,25 @@ const (
 	paramsCheckErr middlewareHandlersErrCode = "middleware-003"
 	auhorizeErr    middlewareHandlersErrCode = "middleware-004"
 	apiKeyErr      middlewareHandlersErrCode = "middleware-005"
+	adminTokenErr  middlewareHandlersErrCode = "middleware-006"
+	verifiedErr    middlewareHandlersErrCode = "middleware-007"
+	stepUpErr      middlewareHandlersErrCode = "middleware-008"
 )
 
+// stepUpWindow is how long a second factor check on the session covers sensitive routes
+const stepUpWindow = time.Minute * 10
+
 type NMiddlewaresHandler interface {
 	Cors() fiber.Handler
 	RouterCheck() fiber.Handler
 	Logger() fiber.Handler
 	JwtAuth() fiber.Handler
 	ParamsCheck() fiber.Handler
-	Authorize(expectedRoleId ...int) fiber.Handler
+	RequirePermission(permission string) fiber.Handler
 	ApiKeyAuth() fiber.Handler
+	AdminTokenAuth() fiber.Handler
+	RequireVerified() fiber.Handler
+	RequireStepUp() fiber.Handler
 }
 
 type middlewaresHandler struct {
@@ -90,6 +98,13 @@ func (h *middlewaresHandler) JwtAuth() fiber.Handler {
 				err.Error(),
 			).Res()
 		}
+		if result.Claims == nil || result.Subject != "access-token" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrUnauthorized.Code,
+				string(jwtAuthErr),
+				"invalid access token",
+			).Res()
+		}
 		claims := result.Claims
 		if !h.middlewaresUsecase.FindAccessToken(claims.Id, token) {
 			return entities.NewResponse(c).Error(
@@ -101,6 +116,7 @@ func (h *middlewaresHandler) JwtAuth() fiber.Handler {
 		//set UserId
 		c.Locals("userId", claims.Id)
 		c.Locals("userRoleId", claims.RoleId)
+		c.Locals("accessJti", result.ID)
 		return c.Next()
 	}
 }
@@ -119,45 +135,34 @@ func (h *middlewaresHandler) ParamsCheck() fiber.Handler {
 	}
 }
 
-func (h *middlewaresHandler) Authorize(expectedRoleId ...int) fiber.Handler {
+// RequirePermission lets the request through when one of the user's roles grants permission
+func (h *middlewaresHandler) RequirePermission(permission string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userRoleId, ok := c.Locals("userRoleId").(int)
+		userId, ok := c.Locals("userId").(string)
 		if !ok {
 			return entities.NewResponse(c).Error(
 				fiber.ErrUnauthorized.Code,
 				string(auhorizeErr),
-				"userRoleId not int type",
+				"userId not string type",
 			).Res()
 		}
 
-		roles, err := h.middlewaresUsecase.FindRole()
-		fmt.Println("Roles : ", roles)
+		allowed, err := h.middlewaresUsecase.HasPermission(userId, permission)
 		if err != nil {
 			return entities.NewResponse(c).Error(
-				fiber.ErrUnauthorized.Code,
+				fiber.ErrInternalServerError.Code,
 				string(auhorizeErr),
 				err.Error(),
 			).Res()
 		}
-
-		sum := 0
-		for _, v := range expectedRoleId {
-			sum += v
-		}
-
-		expectedValueBinary := utils.BinaryConverter(sum, len(roles))
-		userValueBinary := utils.BinaryConverter(userRoleId, len(roles))
-
-		for i := range userValueBinary {
-			if userValueBinary[i]&expectedValueBinary[i] == 1 {
-				return c.Next()
-			}
+		if !allowed {
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(auhorizeErr),
+				"no permission to access this route",
+			).Res()
 		}
-		return entities.NewResponse(c).Error(
-			fiber.ErrUnauthorized.Code,
-			string(auhorizeErr),
-			"no permission to access this route",
-		).Res()
+		return c.Next()
 	}
 }
 
@@ -174,3 +179,75 @@ func (h *middlewaresHandler) ApiKeyAuth() fiber.Handler {
 		return c.Next()
 	}
 }
+
+// AdminTokenAuth verifies the admin token in X-Admin-Token, it runs after JwtAuth.
+// The token is spent by the admin sign up once the request is valid
+func (h *middlewaresHandler) AdminTokenAuth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, err := nftauth.ParseAdminToken(h.cfg.Jwt(), c.Get("X-Admin-Token"))
+		if err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrUnauthorized.Code,
+				string(adminTokenErr),
+				err.Error(),
+			).Res()
+		}
+		c.Locals("adminTokenJti", claims.ID)
+		return c.Next()
+	}
+}
+
+// RequireVerified keeps pending customers out of trading until their email is verified, it runs after JwtAuth
+func (h *middlewaresHandler) RequireVerified() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		verified, err := h.middlewaresUsecase.IsVerified(c.Locals("userId").(string))
+		if err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(verifiedErr),
+				err.Error(),
+			).Res()
+		}
+		if !verified {
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(verifiedErr),
+				"email is not verified",
+			).Res()
+		}
+		return c.Next()
+	}
+}
+
+// RequireStepUp guards sensitive routes behind a recent second factor check on the current session,
+// accounts without two-factor authentication are turned away until they enable it, it runs after JwtAuth
+func (h *middlewaresHandler) RequireStepUp() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		mfa, err := h.middlewaresUsecase.FindSessionMfa(
+			c.Locals("userId").(string),
+			c.Locals("accessJti").(string),
+		)
+		if err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(stepUpErr),
+				err.Error(),
+			).Res()
+		}
+		if !mfa.Enabled {
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(stepUpErr),
+				"two-factor authentication is required",
+			).Res()
+		}
+		if mfa.VerifiedAt == nil || time.Since(*mfa.VerifiedAt) > stepUpWindow {
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(stepUpErr),
+				"step-up verification required",
+			).Res()
+		}
+		return c.Next()
+	}
+}
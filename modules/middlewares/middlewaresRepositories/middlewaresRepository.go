@@ -4,12 +4,16 @@ import (
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
+
 	"github.com/muhammadfarhankt/nft-marketplace/modules/middlewares"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftauth"
 )
 
 type NMiddlewaresRepository interface {
 	FindAccessToken(userId, accessToken string) bool
-	FindRole() ([]*middlewares.Role, error)
+	HasPermission(userId, permission string) (bool, error)
+	IsVerified(userId string) (bool, error)
+	FindSessionMfa(userId, accessJti string) (*middlewares.SessionMfa, error)
 }
 
 type middlewaresRepository struct {
@@ -22,35 +26,67 @@ func MiddlewaresRepository(db *sqlx.DB) NMiddlewaresRepository {
 	}
 }
 
+// FindAccessToken also stamps the session as used, the middleware cache keeps this to one write per ttl
 func (m *middlewaresRepository) FindAccessToken(userId, accessToken string) bool {
 	query := `
-	SELECT
-		(CASE WHEN COUNT(*) = 1 THEN true ELSE false END)
-	FROM
-		"oauth"
-	WHERE "user_id" = $1 AND "access_token" = $2;
+	UPDATE "oauth"
+	SET "last_used_at" = now()
+	WHERE "user_id" = $1 AND "access_token_hash" = $2
+	RETURNING true;
 	`
 
 	var result bool
-	if err := m.db.Get(&result, query, userId, accessToken); err != nil {
+	if err := m.db.Get(&result, query, userId, nftauth.HashToken(accessToken)); err != nil {
 		return false
 	}
-	return true
+	return result
+}
+
+func (m *middlewaresRepository) HasPermission(userId, permission string) (bool, error) {
+	query := `
+	SELECT EXISTS (
+		SELECT 1
+		FROM "user_roles" "ur"
+		JOIN "role_permissions" "rp" ON "rp"."role_id" = "ur"."role_id"
+		JOIN "permissions" "p" ON "p"."id" = "rp"."permission_id"
+		WHERE "ur"."user_id" = $1
+		AND "p"."name" = $2
+	);`
+
+	var allowed bool
+	if err := m.db.Get(&allowed, query, userId, permission); err != nil {
+		return false, fmt.Errorf("get permission failed: %v", err)
+	}
+	return allowed, nil
 }
 
-func (m *middlewaresRepository) FindRole() ([]*middlewares.Role, error) {
+// IsVerified treats wallet accounts without an email as verified by their signature
+func (m *middlewaresRepository) IsVerified(userId string) (bool, error) {
+	query := `
+	SELECT ("email" IS NULL OR "email_verified_at" IS NOT NULL)
+	FROM "users"
+	WHERE "id" = $1;`
+
+	var verified bool
+	if err := m.db.Get(&verified, query, userId); err != nil {
+		return false, fmt.Errorf("get user failed: %v", err)
+	}
+	return verified, nil
+}
+
+func (m *middlewaresRepository) FindSessionMfa(userId, accessJti string) (*middlewares.SessionMfa, error) {
 	query := `
 	SELECT
-		"id",
-		"title"
-	FROM
-		"roles"
-	ORDER BY "id" DESC;
-	`
+		("m"."enabled_at" IS NOT NULL) AS "enabled",
+		"o"."mfa_verified_at" AS "verified_at"
+	FROM "oauth" "o"
+	LEFT JOIN "user_mfa" "m" ON "m"."user_id" = "o"."user_id"
+	WHERE "o"."user_id" = $1
+	AND "o"."access_jti" = $2;`
 
-	roles := make([]*middlewares.Role, 0)
-	if err := m.db.Select(&roles, query); err != nil {
-		return nil, fmt.Errorf("roles are empty: %v", err)
+	mfa := new(middlewares.SessionMfa)
+	if err := m.db.Get(mfa, query, userId, accessJti); err != nil {
+		return nil, fmt.Errorf("get session mfa failed: %v", err)
 	}
-	return roles, nil
+	return mfa, nil
 }
@@ -1,36 +1,75 @@
 package middlewaresUsecases
 
 import (
-	"fmt"
-
 	"github.com/muhammadfarhankt/nft-marketplace/modules/middlewares"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/middlewares/middlewaresRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftcache"
 )
 
 type NMiddlewaresUsecase interface {
 	FindAccessToken(userId, accessToken string) bool
-	FindRole() ([]*middlewares.Role, error)
+	HasPermission(userId, permission string) (bool, error)
+	IsVerified(userId string) (bool, error)
+	FindSessionMfa(userId, accessJti string) (*middlewares.SessionMfa, error)
 }
 
 type middlewaresUsecase struct {
 	middlewaresRepository middlewaresRepositories.NMiddlewaresRepository
+	cache                 nftcache.INftCache
 }
 
-func MiddlewaresUsecase(middlewaresRepository middlewaresRepositories.NMiddlewaresRepository) NMiddlewaresUsecase {
+func MiddlewaresUsecase(middlewaresRepository middlewaresRepositories.NMiddlewaresRepository, cache nftcache.INftCache) NMiddlewaresUsecase {
 	return &middlewaresUsecase{
 		middlewaresRepository: middlewaresRepository,
+		cache:                 cache,
 	}
 }
 
+// FindAccessToken only caches tokens that were found, sign out and refresh drop them again
 func (m *middlewaresUsecase) FindAccessToken(userId, accessToken string) bool {
-	return m.middlewaresRepository.FindAccessToken(userId, accessToken)
+	key := middlewares.TokenCacheKey(userId, accessToken)
+	if _, ok := m.cache.Get(key); ok {
+		return true
+	}
+
+	if !m.middlewaresRepository.FindAccessToken(userId, accessToken) {
+		return false
+	}
+	m.cache.Set(key, true)
+	return true
 }
 
-func (m *middlewaresUsecase) FindRole() ([]*middlewares.Role, error) {
-	roles, err := m.middlewaresRepository.FindRole()
-	fmt.Println(roles, err)
+func (m *middlewaresUsecase) HasPermission(userId, permission string) (bool, error) {
+	key := middlewares.PermissionCacheKey(userId, permission)
+	if allowed, ok := m.cache.Get(key); ok {
+		return allowed.(bool), nil
+	}
+
+	allowed, err := m.middlewaresRepository.HasPermission(userId, permission)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-	return roles, nil
+	m.cache.Set(key, allowed)
+	return allowed, nil
+}
+
+func (m *middlewaresUsecase) IsVerified(userId string) (bool, error) {
+	key := middlewares.VerifiedCacheKey(userId)
+	if _, ok := m.cache.Get(key); ok {
+		return true, nil
+	}
+
+	verified, err := m.middlewaresRepository.IsVerified(userId)
+	if err != nil {
+		return false, err
+	}
+	if verified {
+		m.cache.Set(key, true)
+	}
+	return verified, nil
+}
+
+// FindSessionMfa is never cached, a step-up has to expire on time
+func (m *middlewaresUsecase) FindSessionMfa(userId, accessJti string) (*middlewares.SessionMfa, error) {
+	return m.middlewaresRepository.FindSessionMfa(userId, accessJti)
 }
@@ -6,19 +6,23 @@ import (
 	"github.com/muhammadfarhankt/nft-marketplace/config"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/entities"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/monitor"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftcache"
 )
 
 type IMontitorHandler interface {
 	HealthCheck(c *fiber.Ctx) error
+	CacheStats(c *fiber.Ctx) error
 }
 
 type monitorHandler struct {
-	cfg config.IConfig
+	cfg   config.IConfig
+	cache nftcache.INftCache
 }
 
-func MonitorHandler(cfg config.IConfig) IMontitorHandler {
+func MonitorHandler(cfg config.IConfig, cache nftcache.INftCache) IMontitorHandler {
 	return &monitorHandler{
-		cfg: cfg,
+		cfg:   cfg,
+		cache: cache,
 	}
 }
 
@@ -30,3 +34,7 @@ func (h *monitorHandler) HealthCheck(c *fiber.Ctx) error {
 	// return c.Status(fiber.StatusOK).JSON(res)
 	return entities.NewResponse(c).Success(fiber.StatusOK, res).Res()
 }
+
+func (h *monitorHandler) CacheStats(c *fiber.Ctx) error {
+	return entities.NewResponse(c).Success(fiber.StatusOK, h.cache.Stats()).Res()
+}
@@ -0,0 +1,154 @@
+package nfts
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/files"
+)
+
+type Nft struct {
+	Id           string           `json:"id" db:"id"`
+	TokenId      int64            `json:"token_id" db:"token_id"`
+	CollectionId string           `json:"collection_id" db:"collection_id"`
+	Title        string           `json:"title" db:"title"`
+	Description  string           `json:"description" db:"description"`
+	CreatorId    string           `json:"creator_id" db:"author_id"`
+	OwnerId      string           `json:"owner_id" db:"owner_id"`
+	ImageUrl     string           `json:"image_url" db:"image_url"`
+	Metadata     *NftMetadata     `json:"metadata" db:"metadata"`
+	Media        []*files.FileRes `json:"media"`
+	RoyaltyBps   int              `json:"royalty_bps" db:"royalty_bps"`
+	CreatedAt    string           `json:"created_at" db:"created_at"`
+	UpdatedAt    string           `json:"updated_at" db:"update_at"`
+}
+
+// NftMetadata is the part of the ERC-721 metadata the creator controls,
+// name, description and image come from the nft itself
+type NftMetadata struct {
+	ExternalUrl     string          `json:"external_url,omitempty"`
+	AnimationUrl    string          `json:"animation_url,omitempty"`
+	BackgroundColor string          `json:"background_color,omitempty"`
+	Attributes      []*NftAttribute `json:"attributes"`
+}
+
+type NftAttribute struct {
+	TraitType   string   `json:"trait_type"`
+	DisplayType string   `json:"display_type,omitempty"`
+	Value       any      `json:"value"`
+	MaxValue    *float64 `json:"max_value,omitempty"`
+}
+
+// TokenMetadata is the OpenSea / ERC-721 metadata json served as the tokenURI
+type TokenMetadata struct {
+	Name            string          `json:"name"`
+	Description     string          `json:"description"`
+	Image           string          `json:"image"`
+	ExternalUrl     string          `json:"external_url,omitempty"`
+	AnimationUrl    string          `json:"animation_url,omitempty"`
+	BackgroundColor string          `json:"background_color,omitempty"`
+	Attributes      []*NftAttribute `json:"attributes"`
+}
+
+type MintReq struct {
+	CreatorId    string           `json:"-" db:"author_id"`
+	CollectionId string           `json:"collection_id" db:"collection_id" form:"collection_id"`
+	Title        string           `json:"title" db:"title" form:"title"`
+	Description  string           `json:"description" db:"description" form:"description"`
+	Metadata     *NftMetadata     `json:"metadata" db:"metadata" form:"metadata"`
+	Media        []*files.FileRes `json:"media" form:"media"`
+	RoyaltyBps   int              `json:"royalty_bps" db:"royalty_bps" form:"royalty_bps"`
+}
+
+type NftFilter struct {
+	OwnerId      string
+	CollectionId string
+}
+
+// Validate trims and checks a mint request, the same rules apply to lazy minted vouchers
+func (obj *MintReq) Validate(maxRoyaltyBps int, bucket string) error {
+	obj.Title = strings.TrimSpace(obj.Title)
+	if obj.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if obj.CollectionId == "" {
+		return fmt.Errorf("collection id is required")
+	}
+
+	if obj.RoyaltyBps < 0 || obj.RoyaltyBps > maxRoyaltyBps {
+		return fmt.Errorf("royalty bps must be between 0 and %d", maxRoyaltyBps)
+	}
+
+	if len(obj.Media) == 0 {
+		return fmt.Errorf("media is required")
+	}
+	for _, media := range obj.Media {
+		if !media.IsBucketObject(bucket) {
+			return fmt.Errorf("media must be uploaded files")
+		}
+	}
+
+	if obj.Metadata == nil {
+		obj.Metadata = new(NftMetadata)
+	}
+	if obj.Metadata.Attributes == nil {
+		obj.Metadata.Attributes = make([]*NftAttribute, 0)
+	}
+	return obj.Metadata.Validate()
+}
+
+var numericDisplayTypes = map[string]bool{
+	"number":           true,
+	"boost_number":     true,
+	"boost_percentage": true,
+	"date":             true,
+}
+
+func (obj *NftMetadata) Validate() error {
+	if obj.BackgroundColor != "" {
+		if match, _ := regexp.MatchString(`^[0-9a-fA-F]{6}$`, obj.BackgroundColor); !match {
+			return fmt.Errorf("background color must be a six character hexadecimal without #")
+		}
+	}
+
+	traitTypes := make(map[string]bool)
+	for _, attribute := range obj.Attributes {
+		if attribute == nil {
+			return fmt.Errorf("attribute must not be empty")
+		}
+		attribute.TraitType = strings.TrimSpace(attribute.TraitType)
+		if attribute.TraitType == "" {
+			return fmt.Errorf("attribute trait type is required")
+		}
+		if len(attribute.TraitType) > 64 {
+			return fmt.Errorf("attribute trait type %q is too long", attribute.TraitType)
+		}
+		if traitTypes[strings.ToLower(attribute.TraitType)] {
+			return fmt.Errorf("attribute trait type %q is duplicated", attribute.TraitType)
+		}
+		traitTypes[strings.ToLower(attribute.TraitType)] = true
+
+		if attribute.DisplayType != "" && !numericDisplayTypes[attribute.DisplayType] {
+			return fmt.Errorf("attribute %q has invalid display type %q", attribute.TraitType, attribute.DisplayType)
+		}
+
+		switch attribute.Value.(type) {
+		case float64:
+		case string, bool:
+			if attribute.DisplayType != "" {
+				return fmt.Errorf("attribute %q with display type %q must have a numeric value", attribute.TraitType, attribute.DisplayType)
+			}
+			if attribute.MaxValue != nil {
+				return fmt.Errorf("attribute %q with max value must have a numeric value", attribute.TraitType)
+			}
+		default:
+			return fmt.Errorf("attribute %q value must be a string, number or boolean", attribute.TraitType)
+		}
+
+		if attribute.MaxValue != nil && attribute.Value.(float64) > *attribute.MaxValue {
+			return fmt.Errorf("attribute %q value is greater than its max value", attribute.TraitType)
+		}
+	}
+	return nil
+}
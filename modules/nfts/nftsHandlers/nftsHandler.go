@@ -0,0 +1,195 @@
+package nftsHandlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/entities"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/nfts"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/nfts/nftsUsecases"
+)
+
+type nftsHandlersErrCode string
+
+const (
+	mintNftErr              nftsHandlersErrCode = "nfts-001"
+	findOneNftErr           nftsHandlersErrCode = "nfts-002"
+	findNftsByOwnerErr      nftsHandlersErrCode = "nfts-003"
+	findNftsByCollectionErr nftsHandlersErrCode = "nfts-004"
+	getTokenMetadataErr     nftsHandlersErrCode = "nfts-005"
+)
+
+type INftsHandler interface {
+	MintNFT(c *fiber.Ctx) error
+	FindOneNft(c *fiber.Ctx) error
+	GetTokenMetadata(c *fiber.Ctx) error
+	FindNftsByOwner(c *fiber.Ctx) error
+	FindNftsByCollection(c *fiber.Ctx) error
+}
+
+type nftsHandler struct {
+	cfg         config.IConfig
+	nftsUsecase nftsUsecases.INftsUsecase
+}
+
+func NftsHandler(cfg config.IConfig, nftsUsecase nftsUsecases.INftsUsecase) INftsHandler {
+	return &nftsHandler{
+		cfg:         cfg,
+		nftsUsecase: nftsUsecase,
+	}
+}
+
+func tokenIdParam(c *fiber.Ctx) (int64, error) {
+	tokenId, err := strconv.ParseInt(strings.Trim(c.Params("token_id"), " "), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("token id must be a number")
+	}
+	if tokenId < 1 {
+		return 0, fmt.Errorf("token id must be greater than zero")
+	}
+	return tokenId, nil
+}
+
+func (h *nftsHandler) MintNFT(c *fiber.Ctx) error {
+	req := new(nfts.MintReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(mintNftErr),
+			err.Error(),
+		).Res()
+	}
+	req.CreatorId = c.Locals("userId").(string)
+
+	nft, err := h.nftsUsecase.MintNFT(req)
+	if err != nil {
+		switch err.Error() {
+		case "title is required",
+			"collection id is required",
+			"media is required",
+			"media must be uploaded files",
+			"collection not found",
+			"collection is archived":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(mintNftErr),
+				err.Error(),
+			).Res()
+		case "collection belongs to another user":
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(mintNftErr),
+				err.Error(),
+			).Res()
+		default:
+			// validation errors for metadata and royalty carry the offending value
+			if strings.HasPrefix(err.Error(), "attribute") ||
+				strings.HasPrefix(err.Error(), "background color") ||
+				strings.HasPrefix(err.Error(), "royalty bps") {
+				return entities.NewResponse(c).Error(
+					fiber.ErrBadRequest.Code,
+					string(mintNftErr),
+					err.Error(),
+				).Res()
+			}
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(mintNftErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, nft).Res()
+}
+
+func (h *nftsHandler) FindOneNft(c *fiber.Ctx) error {
+	tokenId, err := tokenIdParam(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findOneNftErr),
+			err.Error(),
+		).Res()
+	}
+
+	nft, err := h.nftsUsecase.FindOneNft(tokenId)
+	if err != nil {
+		switch err.Error() {
+		case "get nft failed: sql: no rows in result set":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findOneNftErr),
+				"nft not found",
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findOneNftErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, nft).Res()
+}
+
+func (h *nftsHandler) GetTokenMetadata(c *fiber.Ctx) error {
+	tokenId, err := tokenIdParam(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(getTokenMetadataErr),
+			err.Error(),
+		).Res()
+	}
+
+	metadata, err := h.nftsUsecase.GetTokenMetadata(tokenId)
+	if err != nil {
+		switch err.Error() {
+		case "get nft failed: sql: no rows in result set":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(getTokenMetadataErr),
+				"nft not found",
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(getTokenMetadataErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, metadata).Res()
+}
+
+func (h *nftsHandler) FindNftsByOwner(c *fiber.Ctx) error {
+	ownerId := strings.Trim(c.Params("user_id"), " ")
+
+	result, err := h.nftsUsecase.FindNftsByOwner(ownerId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findNftsByOwnerErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
+
+func (h *nftsHandler) FindNftsByCollection(c *fiber.Ctx) error {
+	collectionId := strings.Trim(c.Params("collection_id"), " ")
+
+	result, err := h.nftsUsecase.FindNftsByCollection(collectionId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findNftsByCollectionErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
@@ -0,0 +1,208 @@
+package nftsRepositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/collections"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/nfts"
+)
+
+type INftsRepository interface {
+	FindOneNft(tokenId int64) (*nfts.Nft, error)
+	FindNfts(req *nfts.NftFilter) ([]*nfts.Nft, error)
+	InsertNft(req *nfts.MintReq) (*nfts.Nft, error)
+}
+
+type nftsRepository struct {
+	db *sqlx.DB
+}
+
+func NftsRepository(db *sqlx.DB) INftsRepository {
+	return &nftsRepository{
+		db: db,
+	}
+}
+
+// nftSelect builds one nft row with its media files as a nested array
+const nftSelect = `
+	SELECT
+		"n"."id",
+		"n"."token_id",
+		"n"."collection_id",
+		"n"."title",
+		"n"."description",
+		"n"."author_id" AS "creator_id",
+		COALESCE("n"."owner_id", '') AS "owner_id",
+		COALESCE("n"."image_url", '') AS "image_url",
+		"n"."metadata",
+		"n"."royalty_bps",
+		(
+			SELECT
+				COALESCE(json_agg(json_build_object(
+					'file_name', "i"."filename",
+					'url', "i"."url"
+				) ORDER BY "i"."created_at"), '[]'::json)
+			FROM "images" "i"
+			WHERE "i"."nft_id" = "n"."id"
+		) AS "media",
+		"n"."created_at",
+		"n"."update_at" AS "updated_at"
+	FROM "nfts" "n"
+`
+
+func (r *nftsRepository) FindOneNft(tokenId int64) (*nfts.Nft, error) {
+	query := `
+	SELECT
+		row_to_json("t")
+	FROM (` + nftSelect + `
+		WHERE "n"."token_id" = $1
+		AND "n"."deleted_at" IS NULL
+	) AS "t";`
+
+	data := make([]byte, 0)
+	if err := r.db.Get(&data, query, tokenId); err != nil {
+		return nil, fmt.Errorf("get nft failed: %v", err)
+	}
+
+	nft := new(nfts.Nft)
+	if err := json.Unmarshal(data, nft); err != nil {
+		return nil, fmt.Errorf("unmarshal nft failed: %v", err)
+	}
+	return nft, nil
+}
+
+func (r *nftsRepository) FindNfts(req *nfts.NftFilter) ([]*nfts.Nft, error) {
+	conditions := []string{`"n"."deleted_at" IS NULL`}
+	filterValues := make([]any, 0)
+
+	if req.OwnerId != "" {
+		filterValues = append(filterValues, req.OwnerId)
+		conditions = append(conditions, fmt.Sprintf(`"n"."owner_id" = $%d`, len(filterValues)))
+	}
+	if req.CollectionId != "" {
+		filterValues = append(filterValues, req.CollectionId)
+		conditions = append(conditions, fmt.Sprintf(`"n"."collection_id" = $%d`, len(filterValues)))
+	}
+
+	query := `
+	SELECT
+		COALESCE(json_agg("t"), '[]'::json)
+	FROM (` + nftSelect + `
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY "n"."token_id" DESC
+	) AS "t";`
+
+	data := make([]byte, 0)
+	if err := r.db.Get(&data, query, filterValues...); err != nil {
+		return nil, fmt.Errorf("get nfts failed: %v", err)
+	}
+
+	result := make([]*nfts.Nft, 0)
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal nfts failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *nftsRepository) InsertNft(req *nfts.MintReq) (*nfts.Nft, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// the collection must stay active and owned by the creator until the mint commits
+	collection := new(struct {
+		OwnerId string                       `db:"owner_id"`
+		Status  collections.CollectionStatus `db:"status"`
+	})
+	if err := tx.GetContext(ctx, collection, `
+	SELECT "owner_id", "status"
+	FROM "collections"
+	WHERE "id" = $1
+	AND "deleted_at" IS NULL
+	FOR SHARE;`, req.CollectionId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("collection not found")
+	}
+	if collection.OwnerId != req.CreatorId {
+		tx.Rollback()
+		return nil, fmt.Errorf("collection belongs to another user")
+	}
+	if collection.Status != collections.Active {
+		tx.Rollback()
+		return nil, fmt.Errorf("collection is archived")
+	}
+
+	var imageUrl any
+	if len(req.Media) > 0 {
+		imageUrl = req.Media[0].Url
+	}
+
+	metadata, err := json.Marshal(req.Metadata)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("marshal nft metadata failed: %v", err)
+	}
+
+	var nftId string
+	var tokenId int64
+	if err := tx.QueryRowxContext(ctx, `
+	INSERT INTO "nfts" (
+		"title",
+		"description",
+		"image_url",
+		"author_id",
+		"owner_id",
+		"collection_id",
+		"metadata",
+		"royalty_bps"
+	)
+	VALUES ($1, $2, $3, $4, $4, $5, $6, $7)
+	RETURNING "id", "token_id";`,
+		req.Title,
+		req.Description,
+		imageUrl,
+		req.CreatorId,
+		req.CollectionId,
+		string(metadata),
+		req.RoyaltyBps,
+	).Scan(&nftId, &tokenId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("insert nft failed: %v", err)
+	}
+
+	if len(req.Media) > 0 {
+		query := `
+		INSERT INTO "images" ("filename", "url", "nft_id")
+		VALUES
+		`
+		valueStack := make([]any, 0)
+		for i, media := range req.Media {
+			valueStack = append(valueStack, media.FileName, media.Url, nftId)
+			query += fmt.Sprintf(`($%d, $%d, $%d)`, i*3+1, i*3+2, i*3+3)
+			if i != len(req.Media)-1 {
+				query += `,`
+			}
+		}
+		query += `;`
+
+		if _, err := tx.ExecContext(ctx, query, valueStack...); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("insert nft media failed: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit nft failed: %v", err)
+	}
+	return r.FindOneNft(tokenId)
+}
@@ -0,0 +1,91 @@
+package nftsUsecases
+
+import (
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/nfts"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/nfts/nftsRepositories"
+)
+
+type INftsUsecase interface {
+	MintNFT(req *nfts.MintReq) (*nfts.Nft, error)
+	FindOneNft(tokenId int64) (*nfts.Nft, error)
+	GetTokenMetadata(tokenId int64) (*nfts.TokenMetadata, error)
+	FindNftsByOwner(ownerId string) ([]*nfts.Nft, error)
+	FindNftsByCollection(collectionId string) ([]*nfts.Nft, error)
+}
+
+type nftsUsecase struct {
+	cfg            config.IConfig
+	nftsRepository nftsRepositories.INftsRepository
+}
+
+func NftsUsecase(cfg config.IConfig, nftsRepository nftsRepositories.INftsRepository) INftsUsecase {
+	return &nftsUsecase{
+		cfg:            cfg,
+		nftsRepository: nftsRepository,
+	}
+}
+
+func (u *nftsUsecase) MintNFT(req *nfts.MintReq) (*nfts.Nft, error) {
+	if err := req.Validate(u.cfg.App().MaxRoyaltyBps(), u.cfg.App().GCPBucket()); err != nil {
+		return nil, err
+	}
+
+	nft, err := u.nftsRepository.InsertNft(req)
+	if err != nil {
+		return nil, err
+	}
+	return nft, nil
+}
+
+func (u *nftsUsecase) FindOneNft(tokenId int64) (*nfts.Nft, error) {
+	nft, err := u.nftsRepository.FindOneNft(tokenId)
+	if err != nil {
+		return nil, err
+	}
+	return nft, nil
+}
+
+func (u *nftsUsecase) GetTokenMetadata(tokenId int64) (*nfts.TokenMetadata, error) {
+	nft, err := u.nftsRepository.FindOneNft(tokenId)
+	if err != nil {
+		return nil, err
+	}
+
+	// image_url holds the first media file uploaded to the bucket at mint time
+	metadata := &nfts.TokenMetadata{
+		Name:        nft.Title,
+		Description: nft.Description,
+		Image:       nft.ImageUrl,
+		Attributes:  make([]*nfts.NftAttribute, 0),
+	}
+	if nft.Metadata != nil {
+		metadata.ExternalUrl = nft.Metadata.ExternalUrl
+		metadata.AnimationUrl = nft.Metadata.AnimationUrl
+		metadata.BackgroundColor = nft.Metadata.BackgroundColor
+		if nft.Metadata.Attributes != nil {
+			metadata.Attributes = nft.Metadata.Attributes
+		}
+	}
+	return metadata, nil
+}
+
+func (u *nftsUsecase) FindNftsByOwner(ownerId string) ([]*nfts.Nft, error) {
+	result, err := u.nftsRepository.FindNfts(&nfts.NftFilter{
+		OwnerId: ownerId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *nftsUsecase) FindNftsByCollection(collectionId string) ([]*nfts.Nft, error) {
+	result, err := u.nftsRepository.FindNfts(&nfts.NftFilter{
+		CollectionId: collectionId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
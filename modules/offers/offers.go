@@ -0,0 +1,47 @@
+package offers
+
+import "time"
+
+type OfferStatus string
+
+const (
+	Pending   OfferStatus = "pending"
+	Accepted  OfferStatus = "accepted"
+	Rejected  OfferStatus = "rejected"
+	Cancelled OfferStatus = "cancelled"
+	Expired   OfferStatus = "expired"
+)
+
+type Offer struct {
+	Id      string `json:"id" db:"id"`
+	NftId   string `json:"nft_id" db:"nft_id"`
+	TokenId int64  `json:"token_id" db:"token_id"`
+	BuyerId string `json:"buyer_id" db:"buyer_id"`
+	// OwnerId is the current owner of the nft, the only user who can accept or reject
+	OwnerId   string      `json:"owner_id" db:"owner_id"`
+	Price     float64     `json:"price" db:"price"`
+	ExpiresAt time.Time   `json:"expires_at" db:"expires_at"`
+	Status    OfferStatus `json:"status" db:"status"`
+	CreatedAt string      `json:"created_at" db:"created_at"`
+	UpdatedAt string      `json:"updated_at" db:"update_at"`
+}
+
+type OfferReq struct {
+	BuyerId   string    `json:"-" db:"buyer_id"`
+	TokenId   int64     `json:"token_id" db:"token_id" form:"token_id"`
+	Price     float64   `json:"price" db:"price" form:"price"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at" form:"expires_at"`
+}
+
+type OfferFilter struct {
+	BuyerId string `query:"-"`
+	OwnerId string `query:"-"`
+	TokenId int64  `query:"token_id"`
+	Status  string `query:"status"`
+}
+
+type AcceptReq struct {
+	OfferId        string `json:"-"`
+	OwnerId        string `json:"-"`
+	PlatformFeeBps int    `json:"-"`
+}
@@ -0,0 +1,279 @@
+package offersHandlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/entities"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/offers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/offers/offersUsecases"
+)
+
+type offersHandlersErrCode string
+
+const (
+	findOneOfferErr       offersHandlersErrCode = "offers-001"
+	findOffersMadeErr     offersHandlersErrCode = "offers-002"
+	findOffersReceivedErr offersHandlersErrCode = "offers-003"
+	insertOfferErr        offersHandlersErrCode = "offers-004"
+	cancelOfferErr        offersHandlersErrCode = "offers-005"
+	rejectOfferErr        offersHandlersErrCode = "offers-006"
+	acceptOfferErr        offersHandlersErrCode = "offers-007"
+)
+
+type IOffersHandler interface {
+	FindOneOffer(c *fiber.Ctx) error
+	FindOffersMade(c *fiber.Ctx) error
+	FindOffersReceived(c *fiber.Ctx) error
+	InsertOffer(c *fiber.Ctx) error
+	CancelOffer(c *fiber.Ctx) error
+	RejectOffer(c *fiber.Ctx) error
+	AcceptOffer(c *fiber.Ctx) error
+}
+
+type offersHandler struct {
+	cfg           config.IConfig
+	offersUsecase offersUsecases.IOffersUsecase
+}
+
+func OffersHandler(cfg config.IConfig, offersUsecase offersUsecases.IOffersUsecase) IOffersHandler {
+	return &offersHandler{
+		cfg:           cfg,
+		offersUsecase: offersUsecase,
+	}
+}
+
+func offerIdParam(c *fiber.Ctx) (string, bool) {
+	offerId := strings.Trim(c.Params("offer_id"), " ")
+	if _, err := uuid.Parse(offerId); err != nil {
+		return "", false
+	}
+	return offerId, true
+}
+
+func (h *offersHandler) FindOneOffer(c *fiber.Ctx) error {
+	offerId, ok := offerIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findOneOfferErr),
+			"invalid offer id",
+		).Res()
+	}
+
+	offer, err := h.offersUsecase.FindOneOffer(c.Locals("userId").(string), offerId)
+	if err != nil {
+		switch err.Error() {
+		case "get offer failed: sql: no rows in result set",
+			"offer not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findOneOfferErr),
+				"offer not found",
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findOneOfferErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, offer).Res()
+}
+
+func (h *offersHandler) FindOffersMade(c *fiber.Ctx) error {
+	req := new(offers.OfferFilter)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findOffersMadeErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.offersUsecase.FindOffersMade(c.Locals("userId").(string), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findOffersMadeErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
+
+func (h *offersHandler) FindOffersReceived(c *fiber.Ctx) error {
+	req := new(offers.OfferFilter)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findOffersReceivedErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.offersUsecase.FindOffersReceived(c.Locals("userId").(string), req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findOffersReceivedErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
+
+func (h *offersHandler) InsertOffer(c *fiber.Ctx) error {
+	req := new(offers.OfferReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertOfferErr),
+			err.Error(),
+		).Res()
+	}
+	req.BuyerId = c.Locals("userId").(string)
+
+	offer, err := h.offersUsecase.InsertOffer(req)
+	if err != nil {
+		switch err.Error() {
+		case "token id must be greater than zero",
+			"price must be greater than zero",
+			"expires at must be in the future",
+			"offer must not expire later than 30 days from now",
+			"nft not found",
+			"nft has no local owner",
+			"cannot make an offer on your own nft",
+			"nft is already listed",
+			"nft is already in an auction":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertOfferErr),
+				err.Error(),
+			).Res()
+		case "you already have a pending offer on this nft":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(insertOfferErr),
+				err.Error(),
+			).Res()
+		case "insufficient funds":
+			return entities.NewResponse(c).Error(
+				fiber.ErrPaymentRequired.Code,
+				string(insertOfferErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertOfferErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, offer).Res()
+}
+
+func (h *offersHandler) CancelOffer(c *fiber.Ctx) error {
+	offerId, ok := offerIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(cancelOfferErr),
+			"invalid offer id",
+		).Res()
+	}
+
+	if err := h.offersUsecase.CancelOffer(c.Locals("userId").(string), offerId); err != nil {
+		switch err.Error() {
+		case "offer not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(cancelOfferErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(cancelOfferErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, "offer cancelled successfully").Res()
+}
+
+func (h *offersHandler) RejectOffer(c *fiber.Ctx) error {
+	offerId, ok := offerIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(rejectOfferErr),
+			"invalid offer id",
+		).Res()
+	}
+
+	if err := h.offersUsecase.RejectOffer(c.Locals("userId").(string), offerId); err != nil {
+		switch err.Error() {
+		case "offer not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(rejectOfferErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(rejectOfferErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, "offer rejected successfully").Res()
+}
+
+func (h *offersHandler) AcceptOffer(c *fiber.Ctx) error {
+	offerId, ok := offerIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(acceptOfferErr),
+			"invalid offer id",
+		).Res()
+	}
+
+	sale, err := h.offersUsecase.AcceptOffer(&offers.AcceptReq{
+		OfferId: offerId,
+		OwnerId: c.Locals("userId").(string),
+	})
+	if err != nil {
+		switch err.Error() {
+		case "offer not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(acceptOfferErr),
+				err.Error(),
+			).Res()
+		case "offer is not pending",
+			"offer has expired",
+			"nft is already in an auction",
+			"seller no longer owns the nft":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(acceptOfferErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(acceptOfferErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, sale).Res()
+}
@@ -0,0 +1,442 @@
+package offersRepositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/offers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/sales"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/sales/salesPatterns"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets/walletsPatterns"
+)
+
+type IOffersRepository interface {
+	FindOneOffer(offerId string) (*offers.Offer, error)
+	FindOffers(req *offers.OfferFilter) ([]*offers.Offer, error)
+	InsertOffer(req *offers.OfferReq) (*offers.Offer, error)
+	CancelOffer(buyerId, offerId string) error
+	RejectOffer(ownerId, offerId string) error
+	AcceptOffer(req *offers.AcceptReq) (*sales.Sale, error)
+	ExpireOffers() (int64, error)
+}
+
+type offersRepository struct {
+	db *sqlx.DB
+}
+
+func OffersRepository(db *sqlx.DB) IOffersRepository {
+	return &offersRepository{
+		db: db,
+	}
+}
+
+const offerSelect = `
+	SELECT
+		"o"."id",
+		"o"."nft_id",
+		"n"."token_id",
+		"o"."buyer_id",
+		COALESCE("n"."owner_id", '') AS "owner_id",
+		"o"."price",
+		"o"."expires_at",
+		"o"."status",
+		"o"."created_at",
+		"o"."update_at"
+	FROM "offers" "o"
+	JOIN "nfts" "n" ON "n"."id" = "o"."nft_id"
+`
+
+func (r *offersRepository) FindOneOffer(offerId string) (*offers.Offer, error) {
+	query := offerSelect + `
+	WHERE "o"."id" = $1;`
+
+	offer := new(offers.Offer)
+	if err := r.db.Get(offer, query, offerId); err != nil {
+		return nil, fmt.Errorf("get offer failed: %v", err)
+	}
+	return offer, nil
+}
+
+func (r *offersRepository) FindOffers(req *offers.OfferFilter) ([]*offers.Offer, error) {
+	conditions := make([]string, 0)
+	filterValues := make([]any, 0)
+
+	if req.BuyerId != "" {
+		filterValues = append(filterValues, req.BuyerId)
+		conditions = append(conditions, fmt.Sprintf(`"o"."buyer_id" = $%d`, len(filterValues)))
+	}
+	if req.OwnerId != "" {
+		filterValues = append(filterValues, req.OwnerId)
+		conditions = append(conditions, fmt.Sprintf(`"n"."owner_id" = $%d`, len(filterValues)))
+	}
+	if req.TokenId > 0 {
+		filterValues = append(filterValues, req.TokenId)
+		conditions = append(conditions, fmt.Sprintf(`"n"."token_id" = $%d`, len(filterValues)))
+	}
+	if req.Status != "" {
+		filterValues = append(filterValues, req.Status)
+		conditions = append(conditions, fmt.Sprintf(`"o"."status" = $%d`, len(filterValues)))
+	}
+
+	query := offerSelect
+	if len(conditions) > 0 {
+		query += `
+	WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += `
+	ORDER BY "o"."price" DESC, "o"."created_at" ASC;`
+
+	result := make([]*offers.Offer, 0)
+	if err := r.db.Select(&result, query, filterValues...); err != nil {
+		return nil, fmt.Errorf("get offers failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *offersRepository) InsertOffer(req *offers.OfferReq) (*offers.Offer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	nft := new(struct {
+		Id        string `db:"id"`
+		OwnerId   string `db:"owner_id"`
+		Listed    bool   `db:"listed"`
+		Auctioned bool   `db:"auctioned"`
+	})
+	if err := tx.GetContext(ctx, nft, `
+	SELECT
+		"n"."id",
+		COALESCE("n"."owner_id", '') AS "owner_id",
+		EXISTS (
+			SELECT 1
+			FROM "listings" "l"
+			WHERE "l"."nft_id" = "n"."id"
+			AND "l"."status" = 'active'
+		) AS "listed",
+		EXISTS (
+			SELECT 1
+			FROM "auctions" "a"
+			WHERE "a"."nft_id" = "n"."id"
+			AND "a"."status" = 'active'
+		) AS "auctioned"
+	FROM "nfts" "n"
+	WHERE "n"."token_id" = $1
+	AND "n"."deleted_at" IS NULL
+	FOR SHARE OF "n";`, req.TokenId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("nft not found")
+	}
+	if nft.OwnerId == "" {
+		tx.Rollback()
+		return nil, fmt.Errorf("nft has no local owner")
+	}
+	if nft.OwnerId == req.BuyerId {
+		tx.Rollback()
+		return nil, fmt.Errorf("cannot make an offer on your own nft")
+	}
+	if nft.Listed {
+		tx.Rollback()
+		return nil, fmt.Errorf("nft is already listed")
+	}
+	if nft.Auctioned {
+		tx.Rollback()
+		return nil, fmt.Errorf("nft is already in an auction")
+	}
+
+	var offerId string
+	if err := tx.QueryRowxContext(ctx, `
+	INSERT INTO "offers" (
+		"nft_id",
+		"buyer_id",
+		"price",
+		"expires_at"
+	)
+	VALUES ($1, $2, $3, $4)
+	RETURNING "id";`,
+		nft.Id,
+		req.BuyerId,
+		req.Price,
+		req.ExpiresAt,
+	).Scan(&offerId); err != nil {
+		tx.Rollback()
+		if strings.Contains(err.Error(), "offers_pending_nft_id_buyer_id_key") {
+			return nil, fmt.Errorf("you already have a pending offer on this nft")
+		}
+		return nil, fmt.Errorf("insert offer failed: %v", err)
+	}
+
+	// the offer price stays held until the offer is accepted or closed
+	if err := walletsPatterns.Hold(ctx, tx, req.BuyerId, req.Price, offerId); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit offer failed: %v", err)
+	}
+	return r.FindOneOffer(offerId)
+}
+
+func (r *offersRepository) CancelOffer(buyerId, offerId string) error {
+	return r.closeOffer(offers.Cancelled, `
+	UPDATE "offers" SET
+		"status" = $1
+	WHERE "id" = $2
+	AND "buyer_id" = $3
+	AND "status" = $4
+	RETURNING "id", "buyer_id", "price";`, offerId, buyerId)
+}
+
+func (r *offersRepository) RejectOffer(ownerId, offerId string) error {
+	return r.closeOffer(offers.Rejected, `
+	UPDATE "offers" "o" SET
+		"status" = $1
+	FROM "nfts" "n"
+	WHERE "n"."id" = "o"."nft_id"
+	AND "o"."id" = $2
+	AND "n"."owner_id" = $3
+	AND "o"."status" = $4
+	RETURNING "o"."id", "o"."buyer_id", "o"."price";`, offerId, ownerId)
+}
+
+// closeOffer moves one pending offer to status and releases the buyer's hold, the query
+// must return the closed offer's id, buyer_id and price
+func (r *offersRepository) closeOffer(status offers.OfferStatus, query, offerId, userId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	closed := make([]*offers.Offer, 0)
+	if err := sqlx.SelectContext(ctx, tx, &closed, query, status, offerId, userId, offers.Pending); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("close offer failed: %v", err)
+	}
+	if len(closed) == 0 {
+		tx.Rollback()
+		return fmt.Errorf("offer not found")
+	}
+
+	if err := walletsPatterns.LockUsers(ctx, tx, buyerIds(closed)...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := releaseHolds(ctx, tx, closed); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit offer failed: %v", err)
+	}
+	return nil
+}
+
+func buyerIds(closed []*offers.Offer) []string {
+	userIds := make([]string, 0, len(closed))
+	for _, offer := range closed {
+		userIds = append(userIds, offer.BuyerId)
+	}
+	return userIds
+}
+
+func releaseHolds(ctx context.Context, tx *sqlx.Tx, closed []*offers.Offer) error {
+	for _, offer := range closed {
+		if err := walletsPatterns.Release(ctx, tx, offer.BuyerId, offer.Price, offer.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AcceptOffer sells the nft to the offer's buyer out of the held offer price, an open listing on
+// the nft is closed and every other pending offer on it is cancelled in the same transaction
+func (r *offersRepository) AcceptOffer(req *offers.AcceptReq) (*sales.Sale, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	offer := new(offers.Offer)
+	if err := tx.GetContext(ctx, offer, `
+	SELECT
+		"id",
+		"nft_id",
+		"buyer_id",
+		"price",
+		"expires_at",
+		"status"
+	FROM "offers"
+	WHERE "id" = $1
+	FOR UPDATE;`, req.OfferId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("offer not found")
+	}
+	if offer.Status != offers.Pending {
+		tx.Rollback()
+		return nil, fmt.Errorf("offer is not pending")
+	}
+	if !offer.ExpiresAt.After(time.Now()) {
+		tx.Rollback()
+		return nil, fmt.Errorf("offer has expired")
+	}
+
+	nft := new(struct {
+		OwnerId   string `db:"owner_id"`
+		CreatorId string `db:"author_id"`
+		Auctioned bool   `db:"auctioned"`
+	})
+	if err := tx.GetContext(ctx, nft, `
+	SELECT
+		COALESCE("n"."owner_id", '') AS "owner_id",
+		"n"."author_id",
+		EXISTS (
+			SELECT 1
+			FROM "auctions" "a"
+			WHERE "a"."nft_id" = "n"."id"
+			AND "a"."status" = 'active'
+		) AS "auctioned"
+	FROM "nfts" "n"
+	WHERE "n"."id" = $1
+	FOR UPDATE OF "n";`, offer.NftId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("lock nft failed: %v", err)
+	}
+	if nft.OwnerId != req.OwnerId {
+		tx.Rollback()
+		return nil, fmt.Errorf("offer not found")
+	}
+	if nft.Auctioned {
+		tx.Rollback()
+		return nil, fmt.Errorf("nft is already in an auction")
+	}
+
+	competing := make([]*offers.Offer, 0)
+	if err := tx.SelectContext(ctx, &competing, `
+	UPDATE "offers" SET
+		"status" = $1
+	WHERE "nft_id" = $2
+	AND "status" = $3
+	AND "id" <> $4
+	RETURNING "id", "buyer_id", "price";`,
+		offers.Cancelled,
+		offer.NftId,
+		offers.Pending,
+		offer.Id,
+	); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("cancel competing offers failed: %v", err)
+	}
+
+	// the sale and the released holds touch every competing buyer, all of them are locked before the first post
+	userIds := append(buyerIds(competing), offer.BuyerId, req.OwnerId, nft.CreatorId)
+	if err := walletsPatterns.LockUsers(ctx, tx, userIds...); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	sale := &sales.Sale{
+		NftId:    offer.NftId,
+		SellerId: req.OwnerId,
+		BuyerId:  offer.BuyerId,
+		Price:    offer.Price,
+		Source:   sales.OfferSale,
+		SourceId: offer.Id,
+
+		PlatformFeeBps: req.PlatformFeeBps,
+		PaidFrom:       wallets.Held,
+	}
+	if err := salesPatterns.RecordSale(ctx, tx, sale); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE "offers" SET
+		"status" = $1
+	WHERE "id" = $2;`,
+		offers.Accepted,
+		offer.Id,
+	); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("accept offer failed: %v", err)
+	}
+	if err := releaseHolds(ctx, tx, competing); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE "listings" SET
+		"status" = 'cancelled'
+	WHERE "nft_id" = $1
+	AND "status" = 'active';`, offer.NftId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("close listing failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit offer acceptance failed: %v", err)
+	}
+	return sale, nil
+}
+
+func (r *offersRepository) ExpireOffers() (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	// offers locked by an accept in flight are left for the next run
+	expired := make([]*offers.Offer, 0)
+	if err := tx.SelectContext(ctx, &expired, `
+	UPDATE "offers" SET
+		"status" = $1
+	WHERE "id" IN (
+		SELECT "id"
+		FROM "offers"
+		WHERE "status" = $2
+		AND "expires_at" <= now()
+		LIMIT 500
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING "id", "buyer_id", "price";`,
+		offers.Expired,
+		offers.Pending,
+	); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("expire offers failed: %v", err)
+	}
+	if err := walletsPatterns.LockUsers(ctx, tx, buyerIds(expired)...); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if err := releaseHolds(ctx, tx, expired); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit expired offers failed: %v", err)
+	}
+	return int64(len(expired)), nil
+}
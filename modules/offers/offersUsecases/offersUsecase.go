@@ -0,0 +1,132 @@
+package offersUsecases
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/offers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/offers/offersRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/sales"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/utils"
+)
+
+const maxOfferDuration = time.Hour * 24 * 30
+
+type IOffersUsecase interface {
+	FindOneOffer(userId, offerId string) (*offers.Offer, error)
+	FindOffersMade(buyerId string, req *offers.OfferFilter) ([]*offers.Offer, error)
+	FindOffersReceived(ownerId string, req *offers.OfferFilter) ([]*offers.Offer, error)
+	InsertOffer(req *offers.OfferReq) (*offers.Offer, error)
+	CancelOffer(buyerId, offerId string) error
+	RejectOffer(ownerId, offerId string) error
+	AcceptOffer(req *offers.AcceptReq) (*sales.Sale, error)
+	ExpireOffers() error
+}
+
+type offersUsecase struct {
+	cfg              config.IConfig
+	offersRepository offersRepositories.IOffersRepository
+}
+
+func OffersUsecase(cfg config.IConfig, offersRepository offersRepositories.IOffersRepository) IOffersUsecase {
+	return &offersUsecase{
+		cfg:              cfg,
+		offersRepository: offersRepository,
+	}
+}
+
+// FindOneOffer only shows an offer to its buyer and to the owner of the nft
+func (u *offersUsecase) FindOneOffer(userId, offerId string) (*offers.Offer, error) {
+	offer, err := u.offersRepository.FindOneOffer(offerId)
+	if err != nil {
+		return nil, err
+	}
+	if offer.BuyerId != userId && offer.OwnerId != userId {
+		return nil, fmt.Errorf("offer not found")
+	}
+	return offer, nil
+}
+
+func (u *offersUsecase) FindOffersMade(buyerId string, req *offers.OfferFilter) ([]*offers.Offer, error) {
+	req.BuyerId = buyerId
+	req.OwnerId = ""
+	result, err := u.offersRepository.FindOffers(req)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *offersUsecase) FindOffersReceived(ownerId string, req *offers.OfferFilter) ([]*offers.Offer, error) {
+	req.BuyerId = ""
+	req.OwnerId = ownerId
+	if req.Status == "" {
+		req.Status = string(offers.Pending)
+	}
+	result, err := u.offersRepository.FindOffers(req)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *offersUsecase) InsertOffer(req *offers.OfferReq) (*offers.Offer, error) {
+	if req.TokenId < 1 {
+		return nil, fmt.Errorf("token id must be greater than zero")
+	}
+	req.Price = utils.RoundPrice(req.Price)
+	if req.Price <= 0 {
+		return nil, fmt.Errorf("price must be greater than zero")
+	}
+
+	now := time.Now()
+	if !req.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("expires at must be in the future")
+	}
+	if req.ExpiresAt.Sub(now) > maxOfferDuration {
+		return nil, fmt.Errorf("offer must not expire later than 30 days from now")
+	}
+
+	offer, err := u.offersRepository.InsertOffer(req)
+	if err != nil {
+		return nil, err
+	}
+	return offer, nil
+}
+
+func (u *offersUsecase) CancelOffer(buyerId, offerId string) error {
+	if err := u.offersRepository.CancelOffer(buyerId, offerId); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *offersUsecase) RejectOffer(ownerId, offerId string) error {
+	if err := u.offersRepository.RejectOffer(ownerId, offerId); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *offersUsecase) AcceptOffer(req *offers.AcceptReq) (*sales.Sale, error) {
+	req.PlatformFeeBps = u.cfg.App().PlatformFeeBps()
+	sale, err := u.offersRepository.AcceptOffer(req)
+	if err != nil {
+		return nil, err
+	}
+	return sale, nil
+}
+
+// ExpireOffers is run by the scheduler to close pending offers past their expiry
+func (u *offersUsecase) ExpireOffers() error {
+	rows, err := u.offersRepository.ExpireOffers()
+	if err != nil {
+		return err
+	}
+	if rows > 0 {
+		log.Printf("%d offers expired", rows)
+	}
+	return nil
+}
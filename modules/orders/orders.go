@@ -0,0 +1,276 @@
+package orders
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nfteth"
+)
+
+type OrderStatus string
+
+const (
+	Open      OrderStatus = "open"
+	Cancelled OrderStatus = "cancelled"
+)
+
+// OrderSide is derived from the items, a sell order offers an nft and a buy order asks for one
+type OrderSide string
+
+const (
+	Sell OrderSide = "sell"
+	Buy  OrderSide = "buy"
+)
+
+// ItemType follows the seaport numbering
+type ItemType int
+
+const (
+	NativeItem ItemType = 0
+	Erc20Item  ItemType = 1
+	Erc721Item ItemType = 2
+)
+
+// NativeToken is the token address of native currency items
+const NativeToken = "0x0000000000000000000000000000000000000000"
+
+const OrderPrimaryType = "OrderComponents"
+
+var orderTypes = map[string][]*nfteth.TypedDataField{
+	OrderPrimaryType: {
+		{Name: "offerer", Type: "address"},
+		{Name: "offer", Type: "OfferItem[]"},
+		{Name: "consideration", Type: "ConsiderationItem[]"},
+		{Name: "startTime", Type: "uint256"},
+		{Name: "endTime", Type: "uint256"},
+		{Name: "salt", Type: "uint256"},
+		{Name: "counter", Type: "uint256"},
+	},
+	"OfferItem": {
+		{Name: "itemType", Type: "uint8"},
+		{Name: "token", Type: "address"},
+		{Name: "identifier", Type: "uint256"},
+		{Name: "amount", Type: "uint256"},
+	},
+	"ConsiderationItem": {
+		{Name: "itemType", Type: "uint8"},
+		{Name: "token", Type: "address"},
+		{Name: "identifier", Type: "uint256"},
+		{Name: "amount", Type: "uint256"},
+		{Name: "recipient", Type: "address"},
+	},
+}
+
+// OfferItem amounts and identifiers are decimal uint256 strings
+type OfferItem struct {
+	ItemType   ItemType `json:"item_type"`
+	Token      string   `json:"token"`
+	Identifier string   `json:"identifier"`
+	Amount     string   `json:"amount"`
+}
+
+type ConsiderationItem struct {
+	OfferItem
+	Recipient string `json:"recipient"`
+}
+
+// OrderComponents is the signed part of an order
+type OrderComponents struct {
+	Offerer       string               `json:"offerer" db:"offerer"`
+	Offer         []*OfferItem         `json:"offer" db:"offer"`
+	Consideration []*ConsiderationItem `json:"consideration" db:"consideration"`
+	StartTime     time.Time            `json:"start_time" db:"start_time"`
+	EndTime       time.Time            `json:"end_time" db:"end_time"`
+	Salt          string               `json:"salt" db:"salt"`
+	Counter       int64                `json:"counter" db:"counter"`
+}
+
+type Order struct {
+	Id        string `json:"id" db:"id"`
+	OrderHash string `json:"order_hash" db:"order_hash"`
+	OffererId string `json:"offerer_id" db:"offerer_id"`
+	OrderComponents
+	Side      OrderSide   `json:"side" db:"side"`
+	Signature string      `json:"signature" db:"signature"`
+	Status    OrderStatus `json:"status" db:"status"`
+	CreatedAt string      `json:"created_at" db:"created_at"`
+	UpdatedAt string      `json:"updated_at" db:"update_at"`
+}
+
+type OrderReq struct {
+	OffererId string `json:"-"`
+	OrderComponents
+	Side      OrderSide `json:"-"`
+	OrderHash string    `json:"-"`
+	Signature string    `json:"signature" form:"signature"`
+}
+
+// TokenOrders are the orders that can be filled right now for one token
+type TokenOrders struct {
+	Token      string   `json:"token"`
+	Identifier string   `json:"identifier"`
+	Sell       []*Order `json:"sell"`
+	Buy        []*Order `json:"buy"`
+}
+
+type TokenFilter struct {
+	Token      string
+	Identifier string
+	// CheckOwner restricts sell orders to offerers that still own the nft, only possible for
+	// the contract the indexer mirrors
+	CheckOwner bool
+}
+
+type Counter struct {
+	Address string `json:"address" db:"address"`
+	Counter int64  `json:"counter" db:"counter"`
+}
+
+func (obj *OfferItem) typedData() map[string]any {
+	return map[string]any{
+		"itemType":   int(obj.ItemType),
+		"token":      obj.Token,
+		"identifier": obj.Identifier,
+		"amount":     obj.Amount,
+	}
+}
+
+// BuildTypedData is the eth_signTypedData_v4 payload of the order
+func (obj *OrderComponents) BuildTypedData(domain *nfteth.TypedDataDomain) *nfteth.TypedData {
+	offer := make([]any, 0, len(obj.Offer))
+	for _, item := range obj.Offer {
+		offer = append(offer, item.typedData())
+	}
+	consideration := make([]any, 0, len(obj.Consideration))
+	for _, item := range obj.Consideration {
+		data := item.OfferItem.typedData()
+		data["recipient"] = item.Recipient
+		consideration = append(consideration, data)
+	}
+
+	return nfteth.NewTypedData(domain, OrderPrimaryType, orderTypes, map[string]any{
+		"offerer":       obj.Offerer,
+		"offer":         offer,
+		"consideration": consideration,
+		"startTime":     strconv.FormatInt(obj.StartTime.Unix(), 10),
+		"endTime":       strconv.FormatInt(obj.EndTime.Unix(), 10),
+		"salt":          obj.Salt,
+		"counter":       strconv.FormatInt(obj.Counter, 10),
+	})
+}
+
+// Normalize checksums every address and rewrites every number in canonical decimal, so the
+// stored order hashes the same way it was signed and the json containment lookups match
+func (obj *OrderComponents) Normalize() error {
+	offerer, err := nfteth.NormalizeAddress(obj.Offerer)
+	if err != nil {
+		return fmt.Errorf("offerer: %v", err)
+	}
+	obj.Offerer = offerer
+
+	if obj.Salt, err = Uint256(obj.Salt); err != nil {
+		return fmt.Errorf("salt: %v", err)
+	}
+
+	if len(obj.Offer) == 0 {
+		return fmt.Errorf("offer must not be empty")
+	}
+	if len(obj.Consideration) == 0 {
+		return fmt.Errorf("consideration must not be empty")
+	}
+	for _, item := range obj.Offer {
+		if item == nil {
+			return fmt.Errorf("offer item must not be empty")
+		}
+		if err := item.normalize(); err != nil {
+			return fmt.Errorf("offer item: %v", err)
+		}
+	}
+	for _, item := range obj.Consideration {
+		if item == nil {
+			return fmt.Errorf("consideration item must not be empty")
+		}
+		if err := item.normalize(); err != nil {
+			return fmt.Errorf("consideration item: %v", err)
+		}
+		recipient, err := nfteth.NormalizeAddress(item.Recipient)
+		if err != nil {
+			return fmt.Errorf("consideration item: recipient: %v", err)
+		}
+		item.Recipient = recipient
+	}
+	return nil
+}
+
+func (obj *OfferItem) normalize() error {
+	token, err := nfteth.NormalizeAddress(obj.Token)
+	if err != nil {
+		return fmt.Errorf("token: %v", err)
+	}
+	obj.Token = token
+
+	if obj.Identifier == "" {
+		obj.Identifier = "0"
+	}
+	if obj.Identifier, err = Uint256(obj.Identifier); err != nil {
+		return fmt.Errorf("identifier: %v", err)
+	}
+	if obj.Amount, err = Uint256(obj.Amount); err != nil {
+		return fmt.Errorf("amount: %v", err)
+	}
+	if obj.Amount == "0" {
+		return fmt.Errorf("amount must be greater than zero")
+	}
+
+	switch obj.ItemType {
+	case NativeItem:
+		if obj.Token != NativeToken {
+			return fmt.Errorf("native items must use the zero token address")
+		}
+	case Erc20Item:
+		if obj.Token == NativeToken {
+			return fmt.Errorf("erc20 items must have a token address")
+		}
+	case Erc721Item:
+		if obj.Token == NativeToken {
+			return fmt.Errorf("erc721 items must have a token address")
+		}
+		if obj.Amount != "1" {
+			return fmt.Errorf("erc721 items must have an amount of 1")
+		}
+	default:
+		return fmt.Errorf("item type must be 0 (native), 1 (erc20) or 2 (erc721)")
+	}
+	return nil
+}
+
+// DeriveSide tells sell orders, that offer an nft, from buy orders, that ask for one
+func (obj *OrderComponents) DeriveSide() (OrderSide, error) {
+	for _, item := range obj.Offer {
+		if item.ItemType == Erc721Item {
+			return Sell, nil
+		}
+	}
+	for _, item := range obj.Consideration {
+		if item.ItemType == Erc721Item && item.Recipient == obj.Offerer {
+			return Buy, nil
+		}
+	}
+	return "", fmt.Errorf("order must offer an nft or ask for one")
+}
+
+// Uint256 parses a decimal or 0x prefixed hex uint256 and returns it in decimal
+func Uint256(s string) (string, error) {
+	base := 10
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		s, base = s[2:], 16
+	}
+	n, ok := new(big.Int).SetString(s, base)
+	if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+		return "", fmt.Errorf("must be a uint256")
+	}
+	return n.String(), nil
+}
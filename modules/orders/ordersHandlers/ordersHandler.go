@@ -0,0 +1,279 @@
+package ordersHandlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/entities"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/orders"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/orders/ordersUsecases"
+)
+
+type ordersHandlersErrCode string
+
+const (
+	findOneOrderErr     ordersHandlersErrCode = "orders-001"
+	findTokenOrdersErr  ordersHandlersErrCode = "orders-002"
+	findCounterErr      ordersHandlersErrCode = "orders-003"
+	buildTypedDataErr   ordersHandlersErrCode = "orders-004"
+	insertOrderErr      ordersHandlersErrCode = "orders-005"
+	cancelOrderErr      ordersHandlersErrCode = "orders-006"
+	incrementCounterErr ordersHandlersErrCode = "orders-007"
+)
+
+type IOrdersHandler interface {
+	FindOneOrder(c *fiber.Ctx) error
+	FindTokenOrders(c *fiber.Ctx) error
+	FindCounter(c *fiber.Ctx) error
+	BuildTypedData(c *fiber.Ctx) error
+	InsertOrder(c *fiber.Ctx) error
+	CancelOrder(c *fiber.Ctx) error
+	IncrementCounter(c *fiber.Ctx) error
+}
+
+type ordersHandler struct {
+	cfg           config.IConfig
+	ordersUsecase ordersUsecases.IOrdersUsecase
+}
+
+func OrdersHandler(cfg config.IConfig, ordersUsecase ordersUsecases.IOrdersUsecase) IOrdersHandler {
+	return &ordersHandler{
+		cfg:           cfg,
+		ordersUsecase: ordersUsecase,
+	}
+}
+
+func orderIdParam(c *fiber.Ctx) (string, bool) {
+	orderId := strings.Trim(c.Params("order_id"), " ")
+	if _, err := uuid.Parse(orderId); err != nil {
+		return "", false
+	}
+	return orderId, true
+}
+
+// isOrderValidationErr reports errors caused by the submitted order components
+func isOrderValidationErr(err error) bool {
+	for _, prefix := range []string{
+		"offerer",
+		"salt",
+		"offer",
+		"consideration",
+		"end time",
+		"order must",
+	} {
+		if strings.HasPrefix(err.Error(), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *ordersHandler) FindOneOrder(c *fiber.Ctx) error {
+	orderId, ok := orderIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findOneOrderErr),
+			"invalid order id",
+		).Res()
+	}
+
+	order, err := h.ordersUsecase.FindOneOrder(orderId)
+	if err != nil {
+		switch err.Error() {
+		case "get order failed: sql: no rows in result set":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findOneOrderErr),
+				"order not found",
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findOneOrderErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, order).Res()
+}
+
+func (h *ordersHandler) FindTokenOrders(c *fiber.Ctx) error {
+	result, err := h.ordersUsecase.FindTokenOrders(
+		strings.Trim(c.Params("contract"), " "),
+		strings.Trim(c.Params("identifier"), " "),
+	)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "token") || strings.HasPrefix(err.Error(), "identifier") {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(findTokenOrdersErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findTokenOrdersErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
+
+func (h *ordersHandler) FindCounter(c *fiber.Ctx) error {
+	counter, err := h.ordersUsecase.FindCounter(strings.Trim(c.Params("address"), " "))
+	if err != nil {
+		switch err.Error() {
+		case "invalid address",
+			"invalid address checksum":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(findCounterErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findCounterErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, counter).Res()
+}
+
+func (h *ordersHandler) BuildTypedData(c *fiber.Ctx) error {
+	req := new(orders.OrderReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(buildTypedDataErr),
+			err.Error(),
+		).Res()
+	}
+
+	typedData, err := h.ordersUsecase.BuildTypedData(req)
+	if err != nil {
+		if isOrderValidationErr(err) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(buildTypedDataErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(buildTypedDataErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, typedData).Res()
+}
+
+func (h *ordersHandler) InsertOrder(c *fiber.Ctx) error {
+	req := new(orders.OrderReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertOrderErr),
+			err.Error(),
+		).Res()
+	}
+	req.OffererId = c.Locals("userId").(string)
+
+	order, err := h.ordersUsecase.InsertOrder(req)
+	if err != nil {
+		switch err.Error() {
+		case "offerer is not linked to the user":
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(insertOrderErr),
+				err.Error(),
+			).Res()
+		case "order counter is stale",
+			"order already exists":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(insertOrderErr),
+				err.Error(),
+			).Res()
+		default:
+			if isOrderValidationErr(err) || strings.HasPrefix(err.Error(), "invalid signature") {
+				return entities.NewResponse(c).Error(
+					fiber.ErrBadRequest.Code,
+					string(insertOrderErr),
+					err.Error(),
+				).Res()
+			}
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertOrderErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, order).Res()
+}
+
+func (h *ordersHandler) CancelOrder(c *fiber.Ctx) error {
+	orderId, ok := orderIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(cancelOrderErr),
+			"invalid order id",
+		).Res()
+	}
+
+	if err := h.ordersUsecase.CancelOrder(c.Locals("userId").(string), orderId); err != nil {
+		switch err.Error() {
+		case "order not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(cancelOrderErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(cancelOrderErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, "order cancelled successfully").Res()
+}
+
+func (h *ordersHandler) IncrementCounter(c *fiber.Ctx) error {
+	counter, err := h.ordersUsecase.IncrementCounter(
+		c.Locals("userId").(string),
+		strings.Trim(c.Params("address"), " "),
+	)
+	if err != nil {
+		switch err.Error() {
+		case "invalid address",
+			"invalid address checksum":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(incrementCounterErr),
+				err.Error(),
+			).Res()
+		case "address is not linked to the user":
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(incrementCounterErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(incrementCounterErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, counter).Res()
+}
@@ -0,0 +1,315 @@
+package ordersRepositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/orders"
+)
+
+type IOrdersRepository interface {
+	FindOneOrder(orderId string) (*orders.Order, error)
+	FindTokenOrders(req *orders.TokenFilter) (*orders.TokenOrders, error)
+	FindCounter(address string) (*orders.Counter, error)
+	IsUserAddress(userId, address string) (bool, error)
+	InsertOrder(req *orders.OrderReq) (*orders.Order, error)
+	CancelOrder(offererId, orderId string) error
+	IncrementCounter(address string) (*orders.Counter, error)
+}
+
+type ordersRepository struct {
+	db *sqlx.DB
+}
+
+func OrdersRepository(db *sqlx.DB) IOrdersRepository {
+	return &ordersRepository{
+		db: db,
+	}
+}
+
+const orderSelect = `
+	SELECT
+		"o"."id",
+		"o"."order_hash",
+		"o"."offerer_id",
+		"o"."offerer",
+		"o"."side",
+		"o"."offer",
+		"o"."consideration",
+		"o"."start_time",
+		"o"."end_time",
+		"o"."salt",
+		"o"."counter",
+		"o"."signature",
+		"o"."status",
+		"o"."created_at",
+		"o"."update_at" AS "updated_at"
+	FROM "orders" "o"
+`
+
+// fulfillableCondition keeps open orders inside their time window whose counter is still current
+const fulfillableCondition = `
+		"o"."status" = 'open'
+		AND "o"."start_time" <= now()
+		AND "o"."end_time" > now()
+		AND "o"."counter" = COALESCE((
+			SELECT "c"."counter"
+			FROM "order_counters" "c"
+			WHERE "c"."address" = "o"."offerer"
+		), 0)`
+
+func (r *ordersRepository) FindOneOrder(orderId string) (*orders.Order, error) {
+	query := `
+	SELECT
+		row_to_json("t")
+	FROM (` + orderSelect + `
+		WHERE "o"."id" = $1
+	) AS "t";`
+
+	data := make([]byte, 0)
+	if err := r.db.Get(&data, query, orderId); err != nil {
+		return nil, fmt.Errorf("get order failed: %v", err)
+	}
+
+	order := new(orders.Order)
+	if err := json.Unmarshal(data, order); err != nil {
+		return nil, fmt.Errorf("unmarshal order failed: %v", err)
+	}
+	return order, nil
+}
+
+func (r *ordersRepository) findOrders(query string, args ...any) ([]*orders.Order, error) {
+	data := make([]byte, 0)
+	if err := r.db.Get(&data, query, args...); err != nil {
+		return nil, fmt.Errorf("get orders failed: %v", err)
+	}
+
+	result := make([]*orders.Order, 0)
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal orders failed: %v", err)
+	}
+	return result, nil
+}
+
+// FindTokenOrders matches the token against the signed items with jsonb containment, sell orders
+// offer it and buy orders ask for it to be sent to the offerer
+func (r *ordersRepository) FindTokenOrders(req *orders.TokenFilter) (*orders.TokenOrders, error) {
+	item, err := json.Marshal([]map[string]any{{
+		"item_type":  orders.Erc721Item,
+		"token":      req.Token,
+		"identifier": req.Identifier,
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("marshal token item failed: %v", err)
+	}
+
+	sellConditions := []string{fulfillableCondition, `"o"."offer" @> $1::jsonb`}
+	if req.CheckOwner {
+		sellConditions = append(sellConditions, `EXISTS (
+			SELECT 1
+			FROM "nfts" "n"
+			WHERE "n"."token_id" = $2::bigint
+			AND "n"."deleted_at" IS NULL
+			AND (
+				"n"."owner_address" = "o"."offerer"
+				OR ("n"."owner_address" IS NULL AND "n"."owner_id" = "o"."offerer_id")
+			)
+		)`)
+	}
+
+	sellQuery := `
+	SELECT
+		COALESCE(json_agg("t"), '[]'::json)
+	FROM (` + orderSelect + `
+		WHERE ` + strings.Join(sellConditions, " AND ") + `
+		ORDER BY "o"."created_at" DESC
+	) AS "t";`
+
+	sellArgs := []any{string(item)}
+	if req.CheckOwner {
+		sellArgs = append(sellArgs, req.Identifier)
+	}
+	sell, err := r.findOrders(sellQuery, sellArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	buyQuery := `
+	SELECT
+		COALESCE(json_agg("t"), '[]'::json)
+	FROM (` + orderSelect + `
+		WHERE ` + fulfillableCondition + `
+		AND "o"."side" = 'buy'
+		AND "o"."consideration" @> $1::jsonb
+		ORDER BY "o"."created_at" DESC
+	) AS "t";`
+
+	buy, err := r.findOrders(buyQuery, string(item))
+	if err != nil {
+		return nil, err
+	}
+
+	return &orders.TokenOrders{
+		Token:      req.Token,
+		Identifier: req.Identifier,
+		Sell:       sell,
+		Buy:        buy,
+	}, nil
+}
+
+func (r *ordersRepository) FindCounter(address string) (*orders.Counter, error) {
+	query := `
+	SELECT COALESCE((
+		SELECT "counter"
+		FROM "order_counters"
+		WHERE "address" = $1
+	), 0);`
+
+	counter := &orders.Counter{
+		Address: address,
+	}
+	if err := r.db.Get(&counter.Counter, query, address); err != nil {
+		return nil, fmt.Errorf("get counter failed: %v", err)
+	}
+	return counter, nil
+}
+
+func (r *ordersRepository) IsUserAddress(userId, address string) (bool, error) {
+	query := `
+	SELECT EXISTS (
+		SELECT 1
+		FROM "user_addresses"
+		WHERE "user_id" = $1
+		AND "address" = $2
+	);`
+
+	var linked bool
+	if err := r.db.Get(&linked, query, userId, address); err != nil {
+		return false, fmt.Errorf("get user address failed: %v", err)
+	}
+	return linked, nil
+}
+
+func (r *ordersRepository) InsertOrder(req *orders.OrderReq) (*orders.Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	offer, err := json.Marshal(req.Offer)
+	if err != nil {
+		return nil, fmt.Errorf("marshal offer failed: %v", err)
+	}
+	consideration, err := json.Marshal(req.Consideration)
+	if err != nil {
+		return nil, fmt.Errorf("marshal consideration failed: %v", err)
+	}
+
+	var orderId string
+	if err := r.db.QueryRowxContext(ctx, `
+	INSERT INTO "orders" (
+		"order_hash",
+		"offerer_id",
+		"offerer",
+		"side",
+		"offer",
+		"consideration",
+		"start_time",
+		"end_time",
+		"salt",
+		"counter",
+		"signature"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	RETURNING "id";`,
+		req.OrderHash,
+		req.OffererId,
+		req.Offerer,
+		req.Side,
+		string(offer),
+		string(consideration),
+		req.StartTime,
+		req.EndTime,
+		req.Salt,
+		req.Counter,
+		req.Signature,
+	).Scan(&orderId); err != nil {
+		if strings.Contains(err.Error(), "orders_order_hash_key") {
+			return nil, fmt.Errorf("order already exists")
+		}
+		return nil, fmt.Errorf("insert order failed: %v", err)
+	}
+	return r.FindOneOrder(orderId)
+}
+
+func (r *ordersRepository) CancelOrder(offererId, orderId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	UPDATE "orders" SET
+		"status" = $1
+	WHERE "id" = $2
+	AND "offerer_id" = $3
+	AND "status" = $4;`
+
+	result, err := r.db.ExecContext(ctx, query, orders.Cancelled, orderId, offererId, orders.Open)
+	if err != nil {
+		return fmt.Errorf("cancel order failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("order not found")
+	}
+	return nil
+}
+
+// IncrementCounter bumps the offerer's counter and closes the open orders signed under older values,
+// the fulfillable lookups already skip them by counter so this only keeps the status honest
+func (r *ordersRepository) IncrementCounter(address string) (*orders.Counter, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	counter := &orders.Counter{
+		Address: address,
+	}
+	if err := tx.QueryRowxContext(ctx, `
+	INSERT INTO "order_counters" (
+		"address",
+		"counter"
+	)
+	VALUES ($1, 1)
+	ON CONFLICT ("address") DO UPDATE SET
+		"counter" = "order_counters"."counter" + 1
+	RETURNING "counter";`, address).Scan(&counter.Counter); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("increment counter failed: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE "orders" SET
+		"status" = $1
+	WHERE "offerer" = $2
+	AND "status" = $3
+	AND "counter" < $4;`,
+		orders.Cancelled,
+		address,
+		orders.Open,
+		counter.Counter,
+	); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("cancel orders failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit counter failed: %v", err)
+	}
+	return counter, nil
+}
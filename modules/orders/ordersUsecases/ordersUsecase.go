@@ -0,0 +1,186 @@
+package ordersUsecases
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/orders"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/orders/ordersRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nfteth"
+)
+
+type IOrdersUsecase interface {
+	FindOneOrder(orderId string) (*orders.Order, error)
+	FindTokenOrders(token, identifier string) (*orders.TokenOrders, error)
+	FindCounter(address string) (*orders.Counter, error)
+	BuildTypedData(req *orders.OrderReq) (*nfteth.TypedData, error)
+	InsertOrder(req *orders.OrderReq) (*orders.Order, error)
+	CancelOrder(offererId, orderId string) error
+	IncrementCounter(userId, address string) (*orders.Counter, error)
+}
+
+type ordersUsecase struct {
+	cfg              config.IConfig
+	ordersRepository ordersRepositories.IOrdersRepository
+}
+
+func OrdersUsecase(cfg config.IConfig, ordersRepository ordersRepositories.IOrdersRepository) IOrdersUsecase {
+	return &ordersUsecase{
+		cfg:              cfg,
+		ordersRepository: ordersRepository,
+	}
+}
+
+// domain binds order signatures to this marketplace and chain
+func (u *ordersUsecase) domain() *nfteth.TypedDataDomain {
+	return &nfteth.TypedDataDomain{
+		Name:    u.cfg.App().Name(),
+		Version: "1",
+		ChainId: u.cfg.App().ChainId(),
+	}
+}
+
+func (u *ordersUsecase) FindOneOrder(orderId string) (*orders.Order, error) {
+	order, err := u.ordersRepository.FindOneOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (u *ordersUsecase) FindTokenOrders(token, identifier string) (*orders.TokenOrders, error) {
+	token, err := nfteth.NormalizeAddress(token)
+	if err != nil {
+		return nil, fmt.Errorf("token: %v", err)
+	}
+	identifier, err = orders.Uint256(identifier)
+	if err != nil {
+		return nil, fmt.Errorf("identifier: %v", err)
+	}
+
+	req := &orders.TokenFilter{
+		Token:      token,
+		Identifier: identifier,
+	}
+	// ownership is only known for the contract the indexer mirrors into the nfts table
+	if contract, err := nfteth.NormalizeAddress(u.cfg.Indexer().Contract()); err == nil && contract == token {
+		if _, err := strconv.ParseInt(identifier, 10, 64); err == nil {
+			req.CheckOwner = true
+		}
+	}
+
+	result, err := u.ordersRepository.FindTokenOrders(req)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *ordersUsecase) FindCounter(address string) (*orders.Counter, error) {
+	address, err := nfteth.NormalizeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	counter, err := u.ordersRepository.FindCounter(address)
+	if err != nil {
+		return nil, err
+	}
+	return counter, nil
+}
+
+// BuildTypedData returns what the offerer's wallet has to sign, filled with the current counter
+func (u *ordersUsecase) BuildTypedData(req *orders.OrderReq) (*nfteth.TypedData, error) {
+	if err := req.Normalize(); err != nil {
+		return nil, err
+	}
+	counter, err := u.ordersRepository.FindCounter(req.Offerer)
+	if err != nil {
+		return nil, err
+	}
+	req.Counter = counter.Counter
+	return req.BuildTypedData(u.domain()), nil
+}
+
+func (u *ordersUsecase) InsertOrder(req *orders.OrderReq) (*orders.Order, error) {
+	if err := req.Normalize(); err != nil {
+		return nil, err
+	}
+
+	// the signed times are whole seconds
+	req.StartTime = req.StartTime.Truncate(time.Second)
+	req.EndTime = req.EndTime.Truncate(time.Second)
+	if !req.EndTime.After(req.StartTime) {
+		return nil, fmt.Errorf("end time must be after start time")
+	}
+	if !req.EndTime.After(time.Now()) {
+		return nil, fmt.Errorf("end time must be in the future")
+	}
+
+	side, err := req.DeriveSide()
+	if err != nil {
+		return nil, err
+	}
+	req.Side = side
+
+	linked, err := u.ordersRepository.IsUserAddress(req.OffererId, req.Offerer)
+	if err != nil {
+		return nil, err
+	}
+	if !linked {
+		return nil, fmt.Errorf("offerer is not linked to the user")
+	}
+
+	counter, err := u.ordersRepository.FindCounter(req.Offerer)
+	if err != nil {
+		return nil, err
+	}
+	if req.Counter != counter.Counter {
+		return nil, fmt.Errorf("order counter is stale")
+	}
+
+	typedData := req.BuildTypedData(u.domain())
+	if err := nfteth.VerifyTypedData(req.Offerer, typedData, req.Signature); err != nil {
+		return nil, fmt.Errorf("invalid signature: %v", err)
+	}
+	orderHash, err := typedData.StructHash()
+	if err != nil {
+		return nil, err
+	}
+	req.OrderHash = fmt.Sprintf("0x%x", orderHash)
+
+	order, err := u.ordersRepository.InsertOrder(req)
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (u *ordersUsecase) CancelOrder(offererId, orderId string) error {
+	if err := u.ordersRepository.CancelOrder(offererId, orderId); err != nil {
+		return err
+	}
+	return nil
+}
+
+// IncrementCounter cancels every order the address signed so far in one step
+func (u *ordersUsecase) IncrementCounter(userId, address string) (*orders.Counter, error) {
+	address, err := nfteth.NormalizeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	linked, err := u.ordersRepository.IsUserAddress(userId, address)
+	if err != nil {
+		return nil, err
+	}
+	if !linked {
+		return nil, fmt.Errorf("address is not linked to the user")
+	}
+
+	counter, err := u.ordersRepository.IncrementCounter(address)
+	if err != nil {
+		return nil, err
+	}
+	return counter, nil
+}
@@ -0,0 +1,34 @@
+package roles
+
+// built-in roles seeded with the schema, users sign up with one of them
+const (
+	CustomerRoleId = 1
+	AdminRoleId    = 2
+)
+
+type Role struct {
+	Id          int      `db:"id" json:"id"`
+	Title       string   `db:"title" json:"title"`
+	Permissions []string `db:"permissions" json:"permissions"`
+}
+
+type Permission struct {
+	Id          int    `db:"id" json:"id"`
+	Name        string `db:"name" json:"name"`
+	Description string `db:"description" json:"description"`
+}
+
+type RoleReq struct {
+	Title       string   `json:"title" form:"title"`
+	Permissions []string `json:"permissions" form:"permissions"`
+}
+
+type PermissionsReq struct {
+	RoleId      int      `json:"-"`
+	Permissions []string `json:"permissions" form:"permissions"`
+}
+
+type UserRoleReq struct {
+	UserId string `json:"-"`
+	RoleId int    `json:"role_id" form:"role_id"`
+}
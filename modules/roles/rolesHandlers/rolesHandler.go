@@ -0,0 +1,331 @@
+package rolesHandlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/entities"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/roles"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/roles/rolesUsecases"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/users"
+)
+
+type rolesHandlersErrCode string
+
+const (
+	findRolesErr             rolesHandlersErrCode = "roles-001"
+	findPermissionsErr       rolesHandlersErrCode = "roles-002"
+	insertRoleErr            rolesHandlersErrCode = "roles-003"
+	updateRolePermissionsErr rolesHandlersErrCode = "roles-004"
+	deleteRoleErr            rolesHandlersErrCode = "roles-005"
+	findUserRolesErr         rolesHandlersErrCode = "roles-006"
+	assignRoleErr            rolesHandlersErrCode = "roles-007"
+	revokeRoleErr            rolesHandlersErrCode = "roles-008"
+)
+
+type IRolesHandler interface {
+	FindRoles(c *fiber.Ctx) error
+	FindPermissions(c *fiber.Ctx) error
+	InsertRole(c *fiber.Ctx) error
+	UpdateRolePermissions(c *fiber.Ctx) error
+	DeleteRole(c *fiber.Ctx) error
+	FindUserRoles(c *fiber.Ctx) error
+	AssignRole(c *fiber.Ctx) error
+	RevokeRole(c *fiber.Ctx) error
+}
+
+type rolesHandler struct {
+	cfg          config.IConfig
+	rolesUsecase rolesUsecases.IRolesUsecase
+}
+
+func RolesHandler(cfg config.IConfig, rolesUsecase rolesUsecases.IRolesUsecase) IRolesHandler {
+	return &rolesHandler{
+		cfg:          cfg,
+		rolesUsecase: rolesUsecase,
+	}
+}
+
+func roleIdParam(c *fiber.Ctx) (int, bool) {
+	roleId, err := strconv.Atoi(strings.Trim(c.Params("role_id"), " "))
+	if err != nil || roleId <= 0 {
+		return 0, false
+	}
+	return roleId, true
+}
+
+func userIdParam(c *fiber.Ctx) (string, bool) {
+	userId := strings.Trim(c.Params("user_id"), " ")
+	if !users.IsUserId(userId) {
+		return "", false
+	}
+	return userId, true
+}
+
+func (h *rolesHandler) FindRoles(c *fiber.Ctx) error {
+	result, err := h.rolesUsecase.FindRoles()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findRolesErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
+
+func (h *rolesHandler) FindPermissions(c *fiber.Ctx) error {
+	result, err := h.rolesUsecase.FindPermissions()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findPermissionsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
+
+func (h *rolesHandler) InsertRole(c *fiber.Ctx) error {
+	req := new(roles.RoleReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertRoleErr),
+			err.Error(),
+		).Res()
+	}
+
+	role, err := h.rolesUsecase.InsertRole(req)
+	if err != nil {
+		switch err.Error() {
+		case "title is required":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertRoleErr),
+				err.Error(),
+			).Res()
+		case "role already exists":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(insertRoleErr),
+				err.Error(),
+			).Res()
+		default:
+			if strings.HasPrefix(err.Error(), "unknown permission") {
+				return entities.NewResponse(c).Error(
+					fiber.ErrBadRequest.Code,
+					string(insertRoleErr),
+					err.Error(),
+				).Res()
+			}
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertRoleErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, role).Res()
+}
+
+func (h *rolesHandler) UpdateRolePermissions(c *fiber.Ctx) error {
+	roleId, ok := roleIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateRolePermissionsErr),
+			"invalid role id",
+		).Res()
+	}
+
+	req := new(roles.PermissionsReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateRolePermissionsErr),
+			err.Error(),
+		).Res()
+	}
+	req.RoleId = roleId
+
+	role, err := h.rolesUsecase.UpdateRolePermissions(req)
+	if err != nil {
+		switch err.Error() {
+		case "role not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(updateRolePermissionsErr),
+				err.Error(),
+			).Res()
+		default:
+			if strings.HasPrefix(err.Error(), "unknown permission") ||
+				strings.HasPrefix(err.Error(), "admin role must keep") {
+				return entities.NewResponse(c).Error(
+					fiber.ErrBadRequest.Code,
+					string(updateRolePermissionsErr),
+					err.Error(),
+				).Res()
+			}
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(updateRolePermissionsErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, role).Res()
+}
+
+func (h *rolesHandler) DeleteRole(c *fiber.Ctx) error {
+	roleId, ok := roleIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(deleteRoleErr),
+			"invalid role id",
+		).Res()
+	}
+
+	if err := h.rolesUsecase.DeleteRole(roleId); err != nil {
+		switch err.Error() {
+		case "built-in roles cannot be deleted":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(deleteRoleErr),
+				err.Error(),
+			).Res()
+		case "get role failed: sql: no rows in result set":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(deleteRoleErr),
+				"role not found",
+			).Res()
+		case "role is in use":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(deleteRoleErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(deleteRoleErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, "role deleted successfully").Res()
+}
+
+func (h *rolesHandler) FindUserRoles(c *fiber.Ctx) error {
+	userId, ok := userIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findUserRolesErr),
+			"invalid user id",
+		).Res()
+	}
+
+	result, err := h.rolesUsecase.FindUserRoles(userId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findUserRolesErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
+
+func (h *rolesHandler) AssignRole(c *fiber.Ctx) error {
+	userId, ok := userIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(assignRoleErr),
+			"invalid user id",
+		).Res()
+	}
+
+	req := new(roles.UserRoleReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(assignRoleErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = userId
+
+	result, err := h.rolesUsecase.AssignRole(req)
+	if err != nil {
+		switch err.Error() {
+		case "user not found",
+			"role not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(assignRoleErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(assignRoleErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
+
+func (h *rolesHandler) RevokeRole(c *fiber.Ctx) error {
+	userId, ok := userIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(revokeRoleErr),
+			"invalid user id",
+		).Res()
+	}
+	roleId, ok := roleIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(revokeRoleErr),
+			"invalid role id",
+		).Res()
+	}
+
+	result, err := h.rolesUsecase.RevokeRole(&roles.UserRoleReq{
+		UserId: userId,
+		RoleId: roleId,
+	})
+	if err != nil {
+		switch err.Error() {
+		case "user role not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(revokeRoleErr),
+				err.Error(),
+			).Res()
+		case "cannot revoke the last admin":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(revokeRoleErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(revokeRoleErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
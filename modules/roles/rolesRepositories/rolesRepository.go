@@ -0,0 +1,296 @@
+package rolesRepositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/roles"
+)
+
+type IRolesRepository interface {
+	FindRoles() ([]*roles.Role, error)
+	FindOneRole(roleId int) (*roles.Role, error)
+	FindPermissions() ([]*roles.Permission, error)
+	InsertRole(req *roles.RoleReq) (*roles.Role, error)
+	UpdateRolePermissions(req *roles.PermissionsReq) (*roles.Role, error)
+	DeleteRole(roleId int) error
+	FindUserRoles(userId string) ([]*roles.Role, error)
+	AssignRole(req *roles.UserRoleReq) error
+	RevokeRole(req *roles.UserRoleReq) error
+}
+
+type rolesRepository struct {
+	db *sqlx.DB
+}
+
+func RolesRepository(db *sqlx.DB) IRolesRepository {
+	return &rolesRepository{
+		db: db,
+	}
+}
+
+const roleSelect = `
+	SELECT
+		"r"."id",
+		"r"."title",
+		(
+			SELECT
+				COALESCE(json_agg("p"."name" ORDER BY "p"."name"), '[]'::json)
+			FROM "role_permissions" "rp"
+			JOIN "permissions" "p" ON "p"."id" = "rp"."permission_id"
+			WHERE "rp"."role_id" = "r"."id"
+		) AS "permissions"
+	FROM "roles" "r"
+`
+
+func (r *rolesRepository) findRoles(query string, args ...any) ([]*roles.Role, error) {
+	data := make([]byte, 0)
+	if err := r.db.Get(&data, query, args...); err != nil {
+		return nil, fmt.Errorf("get roles failed: %v", err)
+	}
+
+	result := make([]*roles.Role, 0)
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal roles failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *rolesRepository) FindRoles() ([]*roles.Role, error) {
+	query := `
+	SELECT
+		COALESCE(json_agg("t"), '[]'::json)
+	FROM (` + roleSelect + `
+		ORDER BY "r"."id"
+	) AS "t";`
+
+	return r.findRoles(query)
+}
+
+func (r *rolesRepository) FindOneRole(roleId int) (*roles.Role, error) {
+	query := `
+	SELECT
+		row_to_json("t")
+	FROM (` + roleSelect + `
+		WHERE "r"."id" = $1
+	) AS "t";`
+
+	data := make([]byte, 0)
+	if err := r.db.Get(&data, query, roleId); err != nil {
+		return nil, fmt.Errorf("get role failed: %v", err)
+	}
+
+	role := new(roles.Role)
+	if err := json.Unmarshal(data, role); err != nil {
+		return nil, fmt.Errorf("unmarshal role failed: %v", err)
+	}
+	return role, nil
+}
+
+func (r *rolesRepository) FindPermissions() ([]*roles.Permission, error) {
+	query := `
+	SELECT
+		"id",
+		"name",
+		"description"
+	FROM "permissions"
+	ORDER BY "name";`
+
+	permissions := make([]*roles.Permission, 0)
+	if err := r.db.Select(&permissions, query); err != nil {
+		return nil, fmt.Errorf("get permissions failed: %v", err)
+	}
+	return permissions, nil
+}
+
+func (r *rolesRepository) setPermissions(ctx context.Context, tx *sqlx.Tx, roleId int, permissions []string) error {
+	if _, err := tx.ExecContext(ctx, `
+	DELETE FROM "role_permissions"
+	WHERE "role_id" = $1;`, roleId); err != nil {
+		return fmt.Errorf("delete role permissions failed: %v", err)
+	}
+
+	for _, permission := range permissions {
+		if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "role_permissions" ("role_id", "permission_id")
+		SELECT $1, "id"
+		FROM "permissions"
+		WHERE "name" = $2;`, roleId, permission); err != nil {
+			return fmt.Errorf("insert role permission failed: %v", err)
+		}
+	}
+	return nil
+}
+
+func (r *rolesRepository) InsertRole(req *roles.RoleReq) (*roles.Role, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var roleId int
+	if err := tx.QueryRowxContext(ctx, `
+	INSERT INTO "roles" ("title")
+	VALUES ($1)
+	RETURNING "id";`, req.Title).Scan(&roleId); err != nil {
+		tx.Rollback()
+		if strings.Contains(err.Error(), "roles_title_key") {
+			return nil, fmt.Errorf("role already exists")
+		}
+		return nil, fmt.Errorf("insert role failed: %v", err)
+	}
+
+	if err := r.setPermissions(ctx, tx, roleId, req.Permissions); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit role failed: %v", err)
+	}
+	return r.FindOneRole(roleId)
+}
+
+// UpdateRolePermissions replaces the permissions of a role, every holder gains or loses them at once
+func (r *rolesRepository) UpdateRolePermissions(req *roles.PermissionsReq) (*roles.Role, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var roleId int
+	if err := tx.GetContext(ctx, &roleId, `
+	SELECT "id"
+	FROM "roles"
+	WHERE "id" = $1
+	FOR UPDATE;`, req.RoleId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("role not found")
+	}
+
+	if err := r.setPermissions(ctx, tx, req.RoleId, req.Permissions); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit role failed: %v", err)
+	}
+	return r.FindOneRole(req.RoleId)
+}
+
+func (r *rolesRepository) DeleteRole(roleId int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	DELETE FROM "roles"
+	WHERE "id" = $1
+	AND NOT EXISTS (
+		SELECT 1 FROM "user_roles" WHERE "role_id" = $1
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM "users" WHERE "role_id" = $1
+	);`
+
+	result, err := r.db.ExecContext(ctx, query, roleId)
+	if err != nil {
+		return fmt.Errorf("delete role failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("role is in use")
+	}
+	return nil
+}
+
+func (r *rolesRepository) FindUserRoles(userId string) ([]*roles.Role, error) {
+	query := `
+	SELECT
+		COALESCE(json_agg("t"), '[]'::json)
+	FROM (` + roleSelect + `
+		JOIN "user_roles" "ur" ON "ur"."role_id" = "r"."id"
+		WHERE "ur"."user_id" = $1
+		ORDER BY "r"."id"
+	) AS "t";`
+
+	return r.findRoles(query, userId)
+}
+
+func (r *rolesRepository) AssignRole(req *roles.UserRoleReq) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	INSERT INTO "user_roles" ("user_id", "role_id")
+	VALUES ($1, $2)
+	ON CONFLICT ("user_id", "role_id") DO NOTHING;`
+
+	if _, err := r.db.ExecContext(ctx, query, req.UserId, req.RoleId); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "user_roles_user_id_fkey"):
+			return fmt.Errorf("user not found")
+		case strings.Contains(err.Error(), "user_roles_role_id_fkey"):
+			return fmt.Errorf("role not found")
+		default:
+			return fmt.Errorf("assign role failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// RevokeRole keeps at least one holder of the admin role, the admin rows are locked so two
+// concurrent revocations cannot both pass the check
+func (r *rolesRepository) RevokeRole(req *roles.UserRoleReq) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if req.RoleId == roles.AdminRoleId {
+		admins := make([]string, 0)
+		if err := tx.SelectContext(ctx, &admins, `
+		SELECT "user_id"
+		FROM "user_roles"
+		WHERE "role_id" = $1
+		FOR UPDATE;`, req.RoleId); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("get admins failed: %v", err)
+		}
+		if len(admins) == 1 && admins[0] == req.UserId {
+			tx.Rollback()
+			return fmt.Errorf("cannot revoke the last admin")
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `
+	DELETE FROM "user_roles"
+	WHERE "user_id" = $1
+	AND "role_id" = $2;`, req.UserId, req.RoleId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("revoke role failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("user role not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit role failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package rolesUsecases
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/middlewares"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/roles"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/roles/rolesRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftcache"
+)
+
+// managePermission is kept on the admin role so roles can never be locked out of management
+const managePermission = "roles:manage"
+
+type IRolesUsecase interface {
+	FindRoles() ([]*roles.Role, error)
+	FindPermissions() ([]*roles.Permission, error)
+	InsertRole(req *roles.RoleReq) (*roles.Role, error)
+	UpdateRolePermissions(req *roles.PermissionsReq) (*roles.Role, error)
+	DeleteRole(roleId int) error
+	FindUserRoles(userId string) ([]*roles.Role, error)
+	AssignRole(req *roles.UserRoleReq) ([]*roles.Role, error)
+	RevokeRole(req *roles.UserRoleReq) ([]*roles.Role, error)
+}
+
+type rolesUsecase struct {
+	cfg             config.IConfig
+	rolesRepository rolesRepositories.IRolesRepository
+	cache           nftcache.INftCache
+}
+
+func RolesUsecase(cfg config.IConfig, rolesRepository rolesRepositories.IRolesRepository, cache nftcache.INftCache) IRolesUsecase {
+	return &rolesUsecase{
+		cfg:             cfg,
+		rolesRepository: rolesRepository,
+		cache:           cache,
+	}
+}
+
+// validatePermissions drops duplicates and rejects names that are not seeded permissions
+func (u *rolesUsecase) validatePermissions(names []string) ([]string, error) {
+	permissions, err := u.rolesRepository.FindPermissions()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool)
+	for _, permission := range permissions {
+		known[permission.Name] = true
+	}
+
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if !known[name] {
+			return nil, fmt.Errorf("unknown permission %q", name)
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result, nil
+}
+
+func (u *rolesUsecase) FindRoles() ([]*roles.Role, error) {
+	result, err := u.rolesRepository.FindRoles()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *rolesUsecase) FindPermissions() ([]*roles.Permission, error) {
+	result, err := u.rolesRepository.FindPermissions()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *rolesUsecase) InsertRole(req *roles.RoleReq) (*roles.Role, error) {
+	req.Title = strings.ToLower(strings.TrimSpace(req.Title))
+	if req.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+
+	permissions, err := u.validatePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	req.Permissions = permissions
+
+	role, err := u.rolesRepository.InsertRole(req)
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (u *rolesUsecase) UpdateRolePermissions(req *roles.PermissionsReq) (*roles.Role, error) {
+	permissions, err := u.validatePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	req.Permissions = permissions
+
+	if req.RoleId == roles.AdminRoleId {
+		keeps := false
+		for _, permission := range permissions {
+			if permission == managePermission {
+				keeps = true
+			}
+		}
+		if !keeps {
+			return nil, fmt.Errorf("admin role must keep %s", managePermission)
+		}
+	}
+
+	role, err := u.rolesRepository.UpdateRolePermissions(req)
+	if err != nil {
+		return nil, err
+	}
+	// any user may hold the role, so every cached permission check is stale
+	u.cache.DeletePrefix(middlewares.PermissionsCachePrefix())
+	return role, nil
+}
+
+func (u *rolesUsecase) DeleteRole(roleId int) error {
+	if roleId == roles.CustomerRoleId || roleId == roles.AdminRoleId {
+		return fmt.Errorf("built-in roles cannot be deleted")
+	}
+	if _, err := u.rolesRepository.FindOneRole(roleId); err != nil {
+		return err
+	}
+	if err := u.rolesRepository.DeleteRole(roleId); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *rolesUsecase) FindUserRoles(userId string) ([]*roles.Role, error) {
+	result, err := u.rolesRepository.FindUserRoles(userId)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *rolesUsecase) AssignRole(req *roles.UserRoleReq) ([]*roles.Role, error) {
+	if err := u.rolesRepository.AssignRole(req); err != nil {
+		return nil, err
+	}
+	u.cache.DeletePrefix(middlewares.UserPermissionsCachePrefix(req.UserId))
+	return u.FindUserRoles(req.UserId)
+}
+
+func (u *rolesUsecase) RevokeRole(req *roles.UserRoleReq) ([]*roles.Role, error) {
+	if err := u.rolesRepository.RevokeRole(req); err != nil {
+		return nil, err
+	}
+	u.cache.DeletePrefix(middlewares.UserPermissionsCachePrefix(req.UserId))
+	return u.FindUserRoles(req.UserId)
+}
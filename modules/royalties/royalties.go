@@ -0,0 +1,18 @@
+package royalties
+
+type RoyaltySummary struct {
+	RecipientId string          `json:"recipient_id"`
+	TotalAmount float64         `json:"total_amount"`
+	PayoutCount int             `json:"payout_count"`
+	Tokens      []*TokenRoyalty `json:"tokens"`
+}
+
+// TokenRoyalty is the royalty a creator earned from re-sales of one nft
+type TokenRoyalty struct {
+	TokenId     int64   `json:"token_id"`
+	Title       string  `json:"title"`
+	RoyaltyBps  int     `json:"royalty_bps"`
+	TotalAmount float64 `json:"total_amount"`
+	PayoutCount int     `json:"payout_count"`
+	LastPaidAt  string  `json:"last_paid_at"`
+}
@@ -0,0 +1,43 @@
+package royaltiesHandlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/entities"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/royalties/royaltiesUsecases"
+)
+
+type royaltiesHandlersErrCode string
+
+const (
+	findMyRoyaltiesErr royaltiesHandlersErrCode = "royalties-001"
+)
+
+type IRoyaltiesHandler interface {
+	FindMyRoyalties(c *fiber.Ctx) error
+}
+
+type royaltiesHandler struct {
+	cfg              config.IConfig
+	royaltiesUsecase royaltiesUsecases.IRoyaltiesUsecase
+}
+
+func RoyaltiesHandler(cfg config.IConfig, royaltiesUsecase royaltiesUsecases.IRoyaltiesUsecase) IRoyaltiesHandler {
+	return &royaltiesHandler{
+		cfg:              cfg,
+		royaltiesUsecase: royaltiesUsecase,
+	}
+}
+
+func (h *royaltiesHandler) FindMyRoyalties(c *fiber.Ctx) error {
+	summary, err := h.royaltiesUsecase.FindRoyaltySummary(c.Locals("userId").(string))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findMyRoyaltiesErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, summary).Res()
+}
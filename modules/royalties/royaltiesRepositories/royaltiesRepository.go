@@ -0,0 +1,68 @@
+package royaltiesRepositories
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/royalties"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/sales"
+)
+
+type IRoyaltiesRepository interface {
+	FindRoyaltySummary(recipientId string) (*royalties.RoyaltySummary, error)
+}
+
+type royaltiesRepository struct {
+	db *sqlx.DB
+}
+
+func RoyaltiesRepository(db *sqlx.DB) IRoyaltiesRepository {
+	return &royaltiesRepository{
+		db: db,
+	}
+}
+
+func (r *royaltiesRepository) FindRoyaltySummary(recipientId string) (*royalties.RoyaltySummary, error) {
+	query := `
+	SELECT
+		json_build_object(
+			'recipient_id', $1::varchar,
+			'total_amount', COALESCE(SUM("p"."amount"), 0),
+			'payout_count', COUNT("p"."id"),
+			'tokens', (
+				SELECT
+					COALESCE(json_agg("t" ORDER BY "t"."total_amount" DESC), '[]'::json)
+				FROM (
+					SELECT
+						"n"."token_id",
+						"n"."title",
+						"n"."royalty_bps",
+						SUM("tp"."amount") AS "total_amount",
+						COUNT("tp"."id") AS "payout_count",
+						MAX("tp"."created_at") AS "last_paid_at"
+					FROM "payouts" "tp"
+					JOIN "sales" "s" ON "s"."id" = "tp"."sale_id"
+					JOIN "nfts" "n" ON "n"."id" = "s"."nft_id"
+					WHERE "tp"."recipient_id" = $1
+					AND "tp"."kind" = $2
+					GROUP BY "n"."id"
+				) AS "t"
+			)
+		)
+	FROM "payouts" "p"
+	WHERE "p"."recipient_id" = $1
+	AND "p"."kind" = $2;`
+
+	data := make([]byte, 0)
+	if err := r.db.Get(&data, query, recipientId, sales.RoyaltyPayout); err != nil {
+		return nil, fmt.Errorf("get royalties failed: %v", err)
+	}
+
+	summary := new(royalties.RoyaltySummary)
+	if err := json.Unmarshal(data, summary); err != nil {
+		return nil, fmt.Errorf("unmarshal royalties failed: %v", err)
+	}
+	return summary, nil
+}
@@ -0,0 +1,31 @@
+package royaltiesUsecases
+
+import (
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/royalties"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/royalties/royaltiesRepositories"
+)
+
+type IRoyaltiesUsecase interface {
+	FindRoyaltySummary(recipientId string) (*royalties.RoyaltySummary, error)
+}
+
+type royaltiesUsecase struct {
+	cfg                 config.IConfig
+	royaltiesRepository royaltiesRepositories.IRoyaltiesRepository
+}
+
+func RoyaltiesUsecase(cfg config.IConfig, royaltiesRepository royaltiesRepositories.IRoyaltiesRepository) IRoyaltiesUsecase {
+	return &royaltiesUsecase{
+		cfg:                 cfg,
+		royaltiesRepository: royaltiesRepository,
+	}
+}
+
+func (u *royaltiesUsecase) FindRoyaltySummary(recipientId string) (*royalties.RoyaltySummary, error) {
+	summary, err := u.royaltiesRepository.FindRoyaltySummary(recipientId)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
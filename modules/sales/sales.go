@@ -0,0 +1,45 @@
+package sales
+
+import "github.com/muhammadfarhankt/nft-marketplace/modules/wallets"
+
+type SaleSource string
+
+const (
+	ListingSale SaleSource = "listing"
+	AuctionSale SaleSource = "auction"
+	OfferSale   SaleSource = "offer"
+	VoucherSale SaleSource = "voucher"
+)
+
+type PayoutKind string
+
+const (
+	SellerPayout  PayoutKind = "seller"
+	RoyaltyPayout PayoutKind = "royalty"
+	PlatformFee   PayoutKind = "platform_fee"
+)
+
+type Sale struct {
+	Id        string     `json:"id" db:"id"`
+	NftId     string     `json:"nft_id" db:"nft_id"`
+	SellerId  string     `json:"seller_id" db:"seller_id"`
+	BuyerId   string     `json:"buyer_id" db:"buyer_id"`
+	Price     float64    `json:"price" db:"price"`
+	Source    SaleSource `json:"source" db:"source"`
+	SourceId  string     `json:"source_id" db:"source_id"`
+	Payouts   []*Payout  `json:"payouts" db:"-"`
+	CreatedAt string     `json:"created_at" db:"created_at"`
+	// PlatformFeeBps is the fee charged on this sale, set by the usecase from the app config
+	PlatformFeeBps int `json:"-" db:"-"`
+	// PaidFrom is the buyer account the price is taken from, held funds for bids and offers
+	PaidFrom wallets.AccountKind `json:"-" db:"-"`
+}
+
+type Payout struct {
+	Id     string `json:"id" db:"id"`
+	SaleId string `json:"sale_id" db:"sale_id"`
+	// RecipientId is empty for the platform fee
+	RecipientId string     `json:"recipient_id,omitempty" db:"recipient_id"`
+	Kind        PayoutKind `json:"kind" db:"kind"`
+	Amount      float64    `json:"amount" db:"amount"`
+}
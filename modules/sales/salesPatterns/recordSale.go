@@ -0,0 +1,157 @@
+package salesPatterns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/sales"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets/walletsPatterns"
+)
+
+// ErrSellerNotOwner is returned by RecordSale when the nft changed hands since the sale was agreed
+var ErrSellerNotOwner = errors.New("seller no longer owns the nft")
+
+// RecordSale transfers the nft to the buyer, records the sale with its payouts and pays them out of the
+// buyer's wallet inside the caller's transaction, the caller is expected to hold a row lock on the nft
+// and to roll back on error
+func RecordSale(ctx context.Context, tx *sqlx.Tx, req *sales.Sale) error {
+	nft := new(struct {
+		CreatorId  string `db:"author_id"`
+		RoyaltyBps int    `db:"royalty_bps"`
+	})
+	if err := tx.GetContext(ctx, nft, `
+	SELECT "author_id", "royalty_bps"
+	FROM "nfts"
+	WHERE "id" = $1;`, req.NftId); err != nil {
+		return fmt.Errorf("get nft failed: %v", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+	UPDATE "nfts" SET
+		"owner_id" = $1
+	WHERE "id" = $2
+	AND "owner_id" = $3;`,
+		req.BuyerId,
+		req.NftId,
+		req.SellerId,
+	)
+	if err != nil {
+		return fmt.Errorf("transfer nft failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrSellerNotOwner
+	}
+
+	if err := tx.QueryRowxContext(ctx, `
+	INSERT INTO "sales" (
+		"nft_id",
+		"seller_id",
+		"buyer_id",
+		"price",
+		"source",
+		"source_id"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING "id", "created_at";`,
+		req.NftId,
+		req.SellerId,
+		req.BuyerId,
+		req.Price,
+		req.Source,
+		req.SourceId,
+	).Scan(&req.Id, &req.CreatedAt); err != nil {
+		return fmt.Errorf("insert sale failed: %v", err)
+	}
+
+	req.Payouts = splitPayouts(req, nft.CreatorId, nft.RoyaltyBps)
+
+	for _, payout := range req.Payouts {
+		var recipientId any
+		if payout.RecipientId != "" {
+			recipientId = payout.RecipientId
+		}
+		if err := tx.QueryRowxContext(ctx, `
+		INSERT INTO "payouts" (
+			"sale_id",
+			"recipient_id",
+			"kind",
+			"amount"
+		)
+		VALUES ($1, $2, $3, $4)
+		RETURNING "id";`,
+			req.Id,
+			recipientId,
+			payout.Kind,
+			payout.Amount,
+		).Scan(&payout.Id); err != nil {
+			return fmt.Errorf("insert payout failed: %v", err)
+		}
+	}
+
+	if req.PaidFrom == "" {
+		req.PaidFrom = wallets.Available
+	}
+	legs := make([]*wallets.Leg, 0, len(req.Payouts))
+	for _, payout := range req.Payouts {
+		to := wallets.Account{UserId: payout.RecipientId, Kind: wallets.Available}
+		if payout.Kind == sales.PlatformFee {
+			to = wallets.Account{Kind: wallets.Platform}
+		}
+		legs = append(legs, &wallets.Leg{
+			From:   wallets.Account{UserId: req.BuyerId, Kind: req.PaidFrom},
+			To:     to,
+			Amount: payout.Amount,
+		})
+	}
+	if _, err := walletsPatterns.Post(ctx, tx, &wallets.TransactionReq{
+		Kind:      wallets.Sale,
+		Reference: req.Id,
+		Legs:      legs,
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// splitPayouts divides the sale price in cents so the payouts always add up to the price,
+// the seller receives whatever is left after the platform fee and the royalty
+func splitPayouts(req *sales.Sale, creatorId string, royaltyBps int) []*sales.Payout {
+	// a primary sale by the creator pays no royalty, it would only go back to the seller
+	if creatorId == req.SellerId {
+		royaltyBps = 0
+	}
+	cents := int64(math.Round(req.Price * 100))
+	feeCents := cents * int64(req.PlatformFeeBps) / 10000
+	royaltyCents := cents * int64(royaltyBps) / 10000
+	sellerCents := cents - feeCents - royaltyCents
+
+	payouts := []*sales.Payout{
+		{
+			SaleId:      req.Id,
+			RecipientId: req.SellerId,
+			Kind:        sales.SellerPayout,
+			Amount:      float64(sellerCents) / 100,
+		},
+	}
+	if royaltyCents > 0 {
+		payouts = append(payouts, &sales.Payout{
+			SaleId:      req.Id,
+			RecipientId: creatorId,
+			Kind:        sales.RoyaltyPayout,
+			Amount:      float64(royaltyCents) / 100,
+		})
+	}
+	if feeCents > 0 {
+		payouts = append(payouts, &sales.Payout{
+			SaleId: req.Id,
+			Kind:   sales.PlatformFee,
+			Amount: float64(feeCents) / 100,
+		})
+	}
+	return payouts
+}
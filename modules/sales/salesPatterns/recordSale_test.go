@@ -0,0 +1,81 @@
+package salesPatterns
+
+import (
+	"testing"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/sales"
+)
+
+func TestSplitPayouts(t *testing.T) {
+	tests := []struct {
+		name           string
+		price          float64
+		platformFeeBps int
+		sellerId       string
+		creatorId      string
+		royaltyBps     int
+		want           map[sales.PayoutKind]float64
+	}{
+		{"secondary sale", 100, 250, "U000002", "U000001", 1000, map[sales.PayoutKind]float64{
+			sales.SellerPayout: 87.5, sales.RoyaltyPayout: 10, sales.PlatformFee: 2.5,
+		}},
+		{"primary sale pays no royalty", 100, 250, "U000001", "U000001", 1000, map[sales.PayoutKind]float64{
+			sales.SellerPayout: 97.5, sales.PlatformFee: 2.5,
+		}},
+		{"no fee and no royalty", 12.34, 0, "U000002", "U000001", 0, map[sales.PayoutKind]float64{
+			sales.SellerPayout: 12.34,
+		}},
+		{"fractions of a cent go to the seller", 0.99, 250, "U000002", "U000001", 333, map[sales.PayoutKind]float64{
+			sales.SellerPayout: 0.94, sales.RoyaltyPayout: 0.03, sales.PlatformFee: 0.02,
+		}},
+		{"one cent", 0.01, 250, "U000002", "U000001", 1000, map[sales.PayoutKind]float64{
+			sales.SellerPayout: 0.01,
+		}},
+		{"odd price", 33.33, 175, "U000002", "U000001", 750, map[sales.PayoutKind]float64{
+			sales.SellerPayout: 30.26, sales.RoyaltyPayout: 2.49, sales.PlatformFee: 0.58,
+		}},
+	}
+	for _, tt := range tests {
+		req := &sales.Sale{
+			Id:             "sale",
+			SellerId:       tt.sellerId,
+			Price:          tt.price,
+			PlatformFeeBps: tt.platformFeeBps,
+		}
+		payouts := splitPayouts(req, tt.creatorId, tt.royaltyBps)
+
+		got := make(map[sales.PayoutKind]float64)
+		var cents int64
+		for _, payout := range payouts {
+			got[payout.Kind] = payout.Amount
+			cents += int64(payout.Amount*100 + 0.5)
+
+			switch payout.Kind {
+			case sales.SellerPayout:
+				if payout.RecipientId != tt.sellerId {
+					t.Errorf("%s: seller payout to %s, want %s", tt.name, payout.RecipientId, tt.sellerId)
+				}
+			case sales.RoyaltyPayout:
+				if payout.RecipientId != tt.creatorId {
+					t.Errorf("%s: royalty payout to %s, want %s", tt.name, payout.RecipientId, tt.creatorId)
+				}
+			case sales.PlatformFee:
+				if payout.RecipientId != "" {
+					t.Errorf("%s: platform fee to %s, want no recipient", tt.name, payout.RecipientId)
+				}
+			}
+		}
+		if want := int64(tt.price*100 + 0.5); cents != want {
+			t.Errorf("%s: payouts add up to %d cents, want %d", tt.name, cents, want)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: payouts = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for kind, amount := range tt.want {
+			if got[kind] != amount {
+				t.Errorf("%s: %s payout = %v, want %v", tt.name, kind, got[kind], amount)
+			}
+		}
+	}
+}
@@ -1,24 +1,73 @@
 package servers
 
 import (
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/muhammadfarhankt/nft-marketplace/modules/appinfo/appinfoHandlers"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/appinfo/appinfoRepositories"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/appinfo/appinfoUsecases"
 
+	"github.com/muhammadfarhankt/nft-marketplace/modules/auctions/auctionsHandlers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/auctions/auctionsRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/auctions/auctionsUsecases"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/collections/collectionsHandlers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/collections/collectionsRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/collections/collectionsUsecases"
+
 	filesUsecases "github.com/muhammadfarhankt/nft-marketplace/modules/files/fileUsecases"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/files/filesHandlers"
 
+	"github.com/muhammadfarhankt/nft-marketplace/modules/indexer/indexerHandlers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/indexer/indexerRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/indexer/indexerUsecases"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/listings/listingsHandlers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/listings/listingsRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/listings/listingsUsecases"
+
 	middlewareHandlers "github.com/muhammadfarhankt/nft-marketplace/modules/middlewares/middlewaresHandlers"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/middlewares/middlewaresRepositories"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/middlewares/middlewaresUsecases"
 
 	"github.com/muhammadfarhankt/nft-marketplace/modules/monitor/monitorHandlers"
 
+	"github.com/muhammadfarhankt/nft-marketplace/modules/nfts/nftsHandlers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/nfts/nftsRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/nfts/nftsUsecases"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/offers/offersHandlers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/offers/offersRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/offers/offersUsecases"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/orders/ordersHandlers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/orders/ordersRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/orders/ordersUsecases"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/roles/rolesHandlers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/roles/rolesRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/roles/rolesUsecases"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/royalties/royaltiesHandlers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/royalties/royaltiesRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/royalties/royaltiesUsecases"
+
 	"github.com/muhammadfarhankt/nft-marketplace/modules/users/usersHandlers"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/users/usersRepositories"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/users/usersUsecases"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/vouchers/vouchersHandlers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/vouchers/vouchersRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/vouchers/vouchersUsecases"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets/walletsHandlers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets/walletsRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets/walletsUsecases"
+
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftindexer"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftmailer"
 )
 
 type IModuleFactory interface {
@@ -26,6 +75,17 @@ type IModuleFactory interface {
 	UserModule()
 	AppinfoModule()
 	FilesModule()
+	CollectionsModule()
+	NftsModule()
+	ListingsModule()
+	AuctionsModule()
+	OffersModule()
+	RoyaltiesModule()
+	WalletsModule()
+	IndexerModule()
+	VouchersModule()
+	OrdersModule()
+	RolesModule()
 }
 
 type moduleFactory struct {
@@ -44,31 +104,76 @@ func InitModule(r fiber.Router, s *server, mid middlewareHandlers.NMiddlewaresHa
 
 func InitMiddlewares(s *server) middlewareHandlers.NMiddlewaresHandler {
 	repository := middlewaresRepositories.MiddlewaresRepository(s.db)
-	usecase := middlewaresUsecases.MiddlewaresUsecase(repository)
+	usecase := middlewaresUsecases.MiddlewaresUsecase(repository, s.cache)
+
+	s.scheduler.Every("cache:sweep", time.Minute, func() error {
+		s.cache.Sweep()
+		return nil
+	})
+
 	return middlewareHandlers.MiddlewaresHandler(s.cfg, usecase)
 }
 
+// admin chains the guards of a route behind an admin permission, every admin route needs a stepped up session
+func (m *moduleFactory) admin(permission string, handlers ...fiber.Handler) []fiber.Handler {
+	return append([]fiber.Handler{m.mid.JwtAuth(), m.mid.RequirePermission(permission), m.mid.RequireStepUp()}, handlers...)
+}
+
 func (m *moduleFactory) MonitorModule() {
-	handler := monitorHandlers.MonitorHandler(m.s.cfg)
+	handler := monitorHandlers.MonitorHandler(m.s.cfg, m.s.cache)
 
 	m.r.Get("/", handler.HealthCheck)
+	m.r.Get("/cache", m.admin("users:admin", handler.CacheStats)...)
 }
 
 func (m *moduleFactory) UserModule() {
 	repository := usersRepositories.UsersRepository(m.s.db)
-	usecase := usersUsecases.UsersUsecase(m.s.cfg, repository)
+	usecase := usersUsecases.UsersUsecase(m.s.cfg, repository, m.s.cache, nftmailer.NewMailer(m.s.cfg.Mail()))
 	handler := usersHandlers.UsersHandler(m.s.cfg, usecase)
 
 	router := m.r.Group("/users")
 	router.Post("/signup", m.mid.ApiKeyAuth(), handler.SignUpCustomer)
 	router.Post("/signin", m.mid.ApiKeyAuth(), handler.SignIn)
 	router.Post("/refresh", m.mid.ApiKeyAuth(), handler.RefreshPassport)
-	router.Post("/signout", m.mid.ApiKeyAuth(), handler.SignOut)
+	router.Post("/signout", m.mid.JwtAuth(), handler.SignOut)
+
+	// second factor, signin answers 202 with a challenge token when it is enabled
+	router.Post("/signin/mfa", m.mid.ApiKeyAuth(), handler.SignInMfa)
+	router.Post("/mfa/enroll", m.mid.JwtAuth(), handler.EnrollMfa)
+	router.Post("/mfa/activate", m.mid.JwtAuth(), handler.ActivateMfa)
+	router.Post("/mfa/disable", m.mid.JwtAuth(), handler.DisableMfa)
+	router.Post("/mfa/step-up", m.mid.JwtAuth(), handler.StepUp)
+
+	// opened from the emailed link, the signed token is the credential
+	router.Get("/verify-email", handler.VerifyEmail)
+	router.Post("/verify-email/resend", m.mid.JwtAuth(), handler.ResendVerification)
+
+	router.Post("/password/forgot", m.mid.ApiKeyAuth(), handler.ForgotPassword)
+	router.Post("/password/reset", m.mid.ApiKeyAuth(), handler.ResetPassword)
+	router.Post("/password/change", m.mid.JwtAuth(), handler.ChangePassword)
+
+	router.Get("/siwe/nonce", m.mid.ApiKeyAuth(), handler.SiweNonce)
+	router.Post("/siwe/signin", m.mid.ApiKeyAuth(), handler.SiweSignIn)
+
+	router.Post("/addresses/challenge", m.mid.JwtAuth(), handler.IssueAddressChallenge)
+	router.Post("/addresses/verify", m.mid.JwtAuth(), handler.VerifyAddress)
+
+	router.Get("/sessions", m.mid.JwtAuth(), handler.FindSessions)
+	router.Delete("/sessions", m.mid.JwtAuth(), handler.RevokeOtherSessions)
+	router.Delete("/sessions/:session_id", m.mid.JwtAuth(), handler.RevokeSession)
 
 	router.Get("/:user_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.GetUserProfile)
 
-	router.Get("/admin/generate-token", m.mid.JwtAuth(), m.mid.Authorize(2), handler.GenerateAdminToken)
-	router.Post("/signup-admin", m.mid.JwtAuth(), m.mid.Authorize(2), handler.SignUpAdmin)
+	// well-known paths live at the root, outside the versioned api
+	m.s.app.Get("/.well-known/jwks.json", handler.Jwks)
+
+	router.Get("/admin/generate-token", m.admin("users:admin", handler.GenerateAdminToken)...)
+	router.Get("/admin/tokens", m.admin("users:admin", handler.FindAdminTokens)...)
+	router.Post("/signup-admin", m.admin("users:admin", m.mid.AdminTokenAuth(), handler.SignUpAdmin)...)
+	router.Get("/admin/login-throttles", m.admin("users:admin", handler.FindLoginThrottles)...)
+	router.Post("/admin/unlock", m.admin("users:admin", handler.UnlockLogin)...)
+
+	m.s.scheduler.Every("users:login-throttles", time.Hour, usecase.CleanupLoginThrottles)
 }
 
 func (m *moduleFactory) AppinfoModule() {
@@ -78,11 +183,11 @@ func (m *moduleFactory) AppinfoModule() {
 
 	router := m.r.Group("/appinfo")
 
-	router.Get("/apikey", m.mid.JwtAuth(), m.mid.Authorize(2), handler.GenerateApiKey)
+	router.Get("/apikey", m.admin("appinfo:write", handler.GenerateApiKey)...)
 
 	router.Get("/categories", m.mid.ApiKeyAuth(), handler.FindCategory)
-	router.Post("/categories", m.mid.JwtAuth(), m.mid.Authorize(2), handler.InsertCategory)
-	router.Delete("/delete-category/:category_id", m.mid.JwtAuth(), m.mid.Authorize(2), handler.DeleteCategory)
+	router.Post("/categories", m.admin("appinfo:write", handler.InsertCategory)...)
+	router.Delete("/delete-category/:category_id", m.admin("appinfo:write", handler.DeleteCategory)...)
 }
 
 func (m *moduleFactory) FilesModule() {
@@ -94,7 +199,188 @@ func (m *moduleFactory) FilesModule() {
 	//_ = handler
 	//_ = router
 
-	router.Post("/upload", m.mid.JwtAuth(), m.mid.Authorize(2), handler.UploadToGCP)
+	router.Post("/upload", m.admin("files:write", handler.UploadToGCP)...)
+
+	router.Patch("/delete", m.admin("files:write", handler.DeleteFromGCP)...)
+}
+
+func (m *moduleFactory) CollectionsModule() {
+	repository := collectionsRepositories.CollectionsRepository(m.s.db)
+	usecase := collectionsUsecases.CollectionsUsecase(m.s.cfg, repository)
+	handler := collectionsHandlers.CollectionsHandler(m.s.cfg, usecase)
+
+	router := m.r.Group("/collections")
+
+	router.Get("/", m.mid.ApiKeyAuth(), handler.FindCollections)
+	router.Get("/:collection_id", m.mid.ApiKeyAuth(), handler.FindOneCollection)
+
+	router.Post("/", m.mid.JwtAuth(), m.mid.RequirePermission("collections:write"), handler.InsertCollection)
+	router.Patch("/:collection_id", m.mid.JwtAuth(), m.mid.RequirePermission("collections:write"), handler.UpdateCollection)
+	router.Patch("/:collection_id/archive", m.mid.JwtAuth(), m.mid.RequirePermission("collections:write"), handler.ArchiveCollection)
+}
+
+func (m *moduleFactory) NftsModule() {
+	repository := nftsRepositories.NftsRepository(m.s.db)
+	usecase := nftsUsecases.NftsUsecase(m.s.cfg, repository)
+	handler := nftsHandlers.NftsHandler(m.s.cfg, usecase)
+
+	router := m.r.Group("/nfts")
+
+	router.Post("/mint", m.mid.JwtAuth(), m.mid.RequirePermission("nfts:write"), handler.MintNFT)
+
+	router.Get("/owners/:user_id", m.mid.ApiKeyAuth(), handler.FindNftsByOwner)
+	router.Get("/collections/:collection_id", m.mid.ApiKeyAuth(), handler.FindNftsByCollection)
+	router.Get("/:token_id", m.mid.ApiKeyAuth(), handler.FindOneNft)
+
+	// tokenURI is fetched by wallets and marketplaces without credentials
+	router.Get("/:token_id/metadata", handler.GetTokenMetadata)
+}
+
+func (m *moduleFactory) ListingsModule() {
+	repository := listingsRepositories.ListingsRepository(m.s.db)
+	usecase := listingsUsecases.ListingsUsecase(m.s.cfg, repository)
+	handler := listingsHandlers.ListingsHandler(m.s.cfg, usecase)
+
+	router := m.r.Group("/listings")
+
+	router.Get("/", m.mid.ApiKeyAuth(), handler.FindListings)
+	router.Get("/:listing_id", m.mid.ApiKeyAuth(), handler.FindOneListing)
+
+	router.Post("/", m.mid.JwtAuth(), m.mid.RequirePermission("listings:write"), m.mid.RequireVerified(), handler.InsertListing)
+	router.Post("/:listing_id/purchase", m.mid.JwtAuth(), m.mid.RequirePermission("listings:write"), m.mid.RequireVerified(), handler.PurchaseListing)
+	router.Patch("/:listing_id/cancel", m.mid.JwtAuth(), m.mid.RequirePermission("listings:write"), handler.CancelListing)
+}
+
+func (m *moduleFactory) AuctionsModule() {
+	repository := auctionsRepositories.AuctionsRepository(m.s.db)
+	usecase := auctionsUsecases.AuctionsUsecase(m.s.cfg, repository)
+	handler := auctionsHandlers.AuctionsHandler(m.s.cfg, usecase)
+
+	m.s.scheduler.Every("auctions:settle", time.Second*15, usecase.SettleEndedAuctions)
+
+	router := m.r.Group("/auctions")
+
+	router.Get("/", m.mid.ApiKeyAuth(), handler.FindAuctions)
+	router.Get("/:auction_id", m.mid.ApiKeyAuth(), handler.FindOneAuction)
+	router.Get("/:auction_id/bids", m.mid.ApiKeyAuth(), handler.FindBids)
+
+	router.Post("/", m.mid.JwtAuth(), m.mid.RequirePermission("auctions:write"), m.mid.RequireVerified(), handler.InsertAuction)
+	router.Post("/:auction_id/bids", m.mid.JwtAuth(), m.mid.RequirePermission("auctions:write"), m.mid.RequireVerified(), handler.PlaceBid)
+	router.Patch("/:auction_id/cancel", m.mid.JwtAuth(), m.mid.RequirePermission("auctions:write"), handler.CancelAuction)
+}
+
+func (m *moduleFactory) OffersModule() {
+	repository := offersRepositories.OffersRepository(m.s.db)
+	usecase := offersUsecases.OffersUsecase(m.s.cfg, repository)
+	handler := offersHandlers.OffersHandler(m.s.cfg, usecase)
+
+	m.s.scheduler.Every("offers:expire", time.Minute, usecase.ExpireOffers)
+
+	router := m.r.Group("/offers", m.mid.JwtAuth(), m.mid.RequirePermission("offers:write"))
+
+	router.Get("/made", handler.FindOffersMade)
+	router.Get("/received", handler.FindOffersReceived)
+	router.Get("/:offer_id", handler.FindOneOffer)
+
+	router.Post("/", m.mid.RequireVerified(), handler.InsertOffer)
+	router.Patch("/:offer_id/accept", handler.AcceptOffer)
+	router.Patch("/:offer_id/reject", handler.RejectOffer)
+	router.Patch("/:offer_id/cancel", handler.CancelOffer)
+}
+
+func (m *moduleFactory) RoyaltiesModule() {
+	repository := royaltiesRepositories.RoyaltiesRepository(m.s.db)
+	usecase := royaltiesUsecases.RoyaltiesUsecase(m.s.cfg, repository)
+	handler := royaltiesHandlers.RoyaltiesHandler(m.s.cfg, usecase)
+
+	router := m.r.Group("/royalties")
+
+	router.Get("/me", m.mid.JwtAuth(), handler.FindMyRoyalties)
+}
+
+func (m *moduleFactory) WalletsModule() {
+	repository := walletsRepositories.WalletsRepository(m.s.db)
+	usecase := walletsUsecases.WalletsUsecase(m.s.cfg, repository)
+	handler := walletsHandlers.WalletsHandler(m.s.cfg, usecase)
+
+	router := m.r.Group("/wallets")
+
+	router.Get("/me", m.mid.JwtAuth(), handler.FindBalance)
+	router.Get("/me/entries", m.mid.JwtAuth(), handler.FindEntries)
+
+	router.Post("/withdraw", m.mid.JwtAuth(), m.mid.RequirePermission("wallets:withdraw"), m.mid.RequireStepUp(), handler.Withdraw)
+	router.Post("/deposit", m.admin("wallets:deposit", handler.Deposit)...)
+}
+
+func (m *moduleFactory) IndexerModule() {
+	var source nftindexer.ChainSource
+	switch m.s.cfg.Indexer().Source() {
+	case "file":
+		source = nftindexer.NewFileSource(m.s.cfg.Indexer().FilePath())
+	case "rpc":
+		source = nftindexer.NewRpcSource(m.s.cfg.Indexer().RpcUrl())
+	default:
+		return
+	}
+
+	repository := indexerRepositories.IndexerRepository(m.s.db)
+	usecase := indexerUsecases.IndexerUsecase(m.s.cfg, repository, source)
+	handler := indexerHandlers.IndexerHandler(m.s.cfg, usecase)
+
+	m.s.scheduler.Every("indexer:sync", m.s.cfg.Indexer().Interval(), usecase.Sync)
+
+	router := m.r.Group("/indexer")
+
+	router.Get("/status", m.admin("indexer:read", handler.FindStatus)...)
+}
+
+func (m *moduleFactory) VouchersModule() {
+	repository := vouchersRepositories.VouchersRepository(m.s.db)
+	usecase := vouchersUsecases.VouchersUsecase(m.s.cfg, repository)
+	handler := vouchersHandlers.VouchersHandler(m.s.cfg, usecase)
+
+	router := m.r.Group("/vouchers")
+
+	router.Get("/", m.mid.ApiKeyAuth(), handler.FindVouchers)
+	router.Get("/:voucher_id", m.mid.ApiKeyAuth(), handler.FindOneVoucher)
+
+	router.Post("/", m.mid.JwtAuth(), m.mid.RequirePermission("vouchers:write"), m.mid.RequireVerified(), handler.InsertVoucher)
+	router.Patch("/:voucher_id/sign", m.mid.JwtAuth(), m.mid.RequirePermission("vouchers:write"), m.mid.RequireVerified(), handler.SignVoucher)
+	router.Patch("/:voucher_id/cancel", m.mid.JwtAuth(), m.mid.RequirePermission("vouchers:write"), handler.CancelVoucher)
+	router.Post("/:voucher_id/redeem", m.mid.JwtAuth(), m.mid.RequirePermission("vouchers:write"), m.mid.RequireVerified(), handler.RedeemVoucher)
+}
+
+func (m *moduleFactory) OrdersModule() {
+	repository := ordersRepositories.OrdersRepository(m.s.db)
+	usecase := ordersUsecases.OrdersUsecase(m.s.cfg, repository)
+	handler := ordersHandlers.OrdersHandler(m.s.cfg, usecase)
+
+	router := m.r.Group("/orders")
+
+	router.Get("/tokens/:contract/:identifier", m.mid.ApiKeyAuth(), handler.FindTokenOrders)
+	router.Get("/counters/:address", m.mid.ApiKeyAuth(), handler.FindCounter)
+	router.Get("/:order_id", m.mid.ApiKeyAuth(), handler.FindOneOrder)
+
+	router.Post("/typed-data", m.mid.JwtAuth(), m.mid.RequirePermission("orders:write"), handler.BuildTypedData)
+	router.Post("/", m.mid.JwtAuth(), m.mid.RequirePermission("orders:write"), m.mid.RequireVerified(), handler.InsertOrder)
+	router.Patch("/:order_id/cancel", m.mid.JwtAuth(), m.mid.RequirePermission("orders:write"), handler.CancelOrder)
+	router.Post("/counters/:address/increment", m.mid.JwtAuth(), m.mid.RequirePermission("orders:write"), handler.IncrementCounter)
+}
+
+func (m *moduleFactory) RolesModule() {
+	repository := rolesRepositories.RolesRepository(m.s.db)
+	usecase := rolesUsecases.RolesUsecase(m.s.cfg, repository, m.s.cache)
+	handler := rolesHandlers.RolesHandler(m.s.cfg, usecase)
+
+	router := m.r.Group("/roles", m.admin("roles:manage")...)
+
+	router.Get("/", handler.FindRoles)
+	router.Get("/permissions", handler.FindPermissions)
+	router.Get("/users/:user_id", handler.FindUserRoles)
 
-	router.Patch("/delete", m.mid.JwtAuth(), m.mid.Authorize(2), handler.DeleteFromGCP)
+	router.Post("/", handler.InsertRole)
+	router.Put("/:role_id/permissions", handler.UpdateRolePermissions)
+	router.Delete("/:role_id", handler.DeleteRole)
+	router.Post("/users/:user_id", handler.AssignRole)
+	router.Delete("/users/:user_id/:role_id", handler.RevokeRole)
 }
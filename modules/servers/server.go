@@ -5,26 +5,36 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/jmoiron/sqlx"
 	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftcache"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftscheduler"
 )
 
+// authCacheTTL bounds how long a revoked token or permission can outlive a missed invalidation
+const authCacheTTL = time.Second * 30
+
 type IServer interface {
 	Start()
 }
 
 type server struct {
-	app *fiber.App
-	db  *sqlx.DB
-	cfg config.IConfig
+	app       *fiber.App
+	db        *sqlx.DB
+	cfg       config.IConfig
+	scheduler nftscheduler.INftScheduler
+	cache     nftcache.INftCache
 }
 
 func NewServer(cfg config.IConfig, db *sqlx.DB) IServer {
 	return &server{
-		cfg: cfg,
-		db:  db,
+		cfg:       cfg,
+		db:        db,
+		scheduler: nftscheduler.NewScheduler(),
+		cache:     nftcache.NewCache(authCacheTTL),
 		app: fiber.New(fiber.Config{
 			AppName:      cfg.App().Name(),
 			BodyLimit:    cfg.App().BodyLimit(),
@@ -32,6 +42,8 @@ func NewServer(cfg config.IConfig, db *sqlx.DB) IServer {
 			WriteTimeout: cfg.App().WriteTimeout(),
 			JSONEncoder:  json.Marshal,
 			JSONDecoder:  json.Unmarshal,
+			// c.IP() stays the peer of the connection, the client ip behind trusted proxies is
+			// resolved by utils.ClientIp where it keys throttles and sessions
 		}),
 	}
 }
@@ -53,15 +65,30 @@ func (s *server) Start() {
 	modules.UserModule()
 	modules.AppinfoModule()
 	modules.FilesModule()
+	modules.CollectionsModule()
+	modules.NftsModule()
+	modules.ListingsModule()
+	modules.AuctionsModule()
+	modules.OffersModule()
+	modules.RoyaltiesModule()
+	modules.WalletsModule()
+	modules.IndexerModule()
+	modules.VouchersModule()
+	modules.OrdersModule()
+	modules.RolesModule()
 
 	s.app.Use(middlewares.RouterCheck())
 
+	// background jobs registered by the modules
+	s.scheduler.Start()
+
 	// graceful shutdown
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
 	go func() {
 		_ = <-c
 		log.Println("server is shutting down")
+		s.scheduler.Stop()
 		_ = s.app.Shutdown()
 	}()
 
@@ -1,29 +1,197 @@
 package users
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"regexp"
+	"strings"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+// user ids come from users_id_seq, eg U000001
+var userIdPattern = regexp.MustCompile(`^U[0-9]{6}$`)
+
+func IsUserId(userId string) bool {
+	return userIdPattern.MatchString(userId)
+}
+
 type User struct {
 	Id       string `db:"id" json:"id"`
 	Username string `db:"username" json:"username"`
 	Email    string `db:"email" json:"email"`
 	// Password string `db:"password" json:"password"`
-	RoleId int `db:"role_id" json:"role_id"`
+	RoleId    int            `db:"role_id" json:"role_id"`
+	Verified  bool           `db:"verified" json:"verified"`
+	Addresses []*UserAddress `db:"-" json:"addresses,omitempty"`
+}
+
+type UserAddress struct {
+	Address    string `db:"address" json:"address"`
+	VerifiedAt string `db:"verified_at" json:"verified_at"`
 }
 
+type AddressChallengeReq struct {
+	UserId  string `json:"-"`
+	Address string `json:"address" form:"address"`
+}
+
+type AddressChallenge struct {
+	Id        string     `db:"id" json:"id"`
+	UserId    string     `db:"user_id" json:"-"`
+	Address   string     `db:"address" json:"address"`
+	Nonce     string     `db:"nonce" json:"nonce"`
+	Message   string     `db:"message" json:"message"`
+	ExpiresAt time.Time  `db:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time `db:"used_at" json:"-"`
+}
+
+type SiweNonce struct {
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type SiweSignInReq struct {
+	Message   string        `json:"message" form:"message"`
+	Signature string        `json:"signature" form:"signature"`
+	Client    SessionClient `json:"-" form:"-"`
+}
+
+type AddressVerifyReq struct {
+	UserId      string `json:"-"`
+	ChallengeId string `json:"challenge_id" form:"challenge_id"`
+	Signature   string `json:"signature" form:"signature"`
+}
+
+// ErrRefreshReused is returned for a refresh token that was already rotated, the session it belongs to is revoked
+var ErrRefreshReused = errors.New("refresh token reused")
+
 type UserToken struct {
 	Id           string `db:"id" json:"id"`
 	AccessToken  string `db:"access_token" json:"access_token"`
 	RefreshToken string `db:"refresh_token" json:"refresh_token"`
+	AccessJti    string `db:"access_jti" json:"-"`
+	RefreshJti   string `db:"refresh_jti" json:"-"`
+}
+
+// AdminToken is the audit row of a single use admin token
+type AdminToken struct {
+	Jti        string     `db:"jti" json:"jti"`
+	MintedBy   string     `db:"minted_by" json:"minted_by"`
+	ExpiresAt  time.Time  `db:"expires_at" json:"expires_at"`
+	ConsumedBy *string    `db:"consumed_by" json:"consumed_by"`
+	ConsumedAt *time.Time `db:"consumed_at" json:"consumed_at"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
+
+// SessionClient is where a session was opened from, taken from the sign in request
+type SessionClient struct {
+	Ip        string `db:"ip"`
+	UserAgent string `db:"user_agent"`
+}
+
+type Session struct {
+	Id         string    `db:"id" json:"id"`
+	Ip         string    `db:"ip" json:"ip"`
+	UserAgent  string    `db:"user_agent" json:"user_agent"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	LastUsedAt time.Time `db:"last_used_at" json:"last_used_at"`
+	AccessJti  string    `db:"access_jti" json:"-"`
+	Current    bool      `db:"-" json:"current"`
 }
 
 type UserPassport struct {
-	User  *User      `json:"user"`
-	Token *UserToken `json:"token"`
+	User         *User         `json:"user"`
+	Token        *UserToken    `json:"token"`
+	MfaChallenge *MfaChallenge `json:"mfa_challenge,omitempty"`
+}
+
+// MfaChallenge is handed out instead of tokens when the account has two-factor authentication
+type MfaChallenge struct {
+	Token     string    `json:"challenge_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type UserMfa struct {
+	UserId    string     `db:"user_id"`
+	Secret    string     `db:"secret"`
+	EnabledAt *time.Time `db:"enabled_at"`
+	LastStep  int64      `db:"last_step"`
+}
+
+var (
+	// ErrMfaNotEnabled is returned for a user without a second factor or with an enrollment still pending
+	ErrMfaNotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrInvalidMfaCode is returned for a wrong totp or recovery code, it counts as a failed sign in
+	ErrInvalidMfaCode = errors.New("invalid code")
+)
+
+type MfaEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningUri string `json:"provisioning_uri"`
+}
+
+type MfaCodeReq struct {
+	UserId    string        `json:"-"`
+	AccessJti string        `json:"-"`
+	Code      string        `json:"code" form:"code"`
+	Client    SessionClient `json:"-" form:"-"`
+}
+
+type MfaSignInReq struct {
+	ChallengeToken string        `json:"challenge_token" form:"challenge_token"`
+	Code           string        `json:"code" form:"code"`
+	Client         SessionClient `json:"-" form:"-"`
+}
+
+type MfaRecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// login throttle scopes, a failed sign in counts against both
+const (
+	ThrottleUsername = "username"
+	ThrottleIp       = "ip"
+)
+
+type LoginThrottle struct {
+	Scope        string     `db:"scope" json:"scope"`
+	Key          string     `db:"key" json:"key"`
+	Failures     int        `db:"failures" json:"failures"`
+	LockedUntil  *time.Time `db:"locked_until" json:"locked_until"`
+	LastFailedAt time.Time  `db:"last_failed_at" json:"last_failed_at"`
+}
+
+type UnlockLoginReq struct {
+	Username string `json:"username" form:"username"`
+	Ip       string `json:"ip" form:"ip"`
+}
+
+// LockoutError is returned while a username or ip is locked out of sign in
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("too many sign in attempts, try again in %v", e.RetryAfter)
+}
+
+// LockoutDuration starts at base once failures reach threshold and doubles with every further failure, up to max
+func LockoutDuration(failures, threshold int, base, max time.Duration) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+	lockout := base
+	for i := threshold; i < failures && lockout < max; i++ {
+		lockout *= 2
+	}
+	if lockout > max {
+		return max
+	}
+	return lockout
 }
 
 type UserRegisterReq struct {
@@ -32,10 +200,32 @@ type UserRegisterReq struct {
 	Password string `db:"password" json:"password" form:"password"`
 }
 
+type ForgotPasswordReq struct {
+	Email string `json:"email" form:"email"`
+}
+
+type ResetPasswordReq struct {
+	Token    string `json:"token" form:"token"`
+	Password string `json:"password" form:"password"`
+}
+
+type ChangePasswordReq struct {
+	UserId      string `json:"-"`
+	OldPassword string `json:"old_password" form:"old_password"`
+	NewPassword string `json:"new_password" form:"new_password"`
+}
+
+type PasswordReset struct {
+	UserId    string    `db:"user_id"`
+	TokenHash string    `db:"token_hash"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
 type UserCredential struct {
 	//Email   string `db:"email" json:"email" form:"email"`
-	Username string `db:"username" json:"username" form:"username"`
-	Password string `db:"password" json:"password" form:"password"`
+	Username string        `db:"username" json:"username" form:"username"`
+	Password string        `db:"password" json:"password" form:"password"`
+	Client   SessionClient `db:"-" json:"-" form:"-"`
 }
 
 type UserCredentialCheck struct {
@@ -44,6 +234,7 @@ type UserCredentialCheck struct {
 	Email    string `db:"email"`
 	Password string `db:"password"`
 	RoleId   int    `db:"role_id"`
+	Verified bool   `db:"verified"`
 }
 
 type UserClaims struct {
@@ -74,6 +265,17 @@ func (obj *UserRegisterReq) BcryptHashing() error {
 	return nil
 }
 
+// ValidatePassword is checked for passwords chosen through reset and change
+func ValidatePassword(password string) error {
+	if len(password) < 8 {
+		return fmt.Errorf("password must be at least 8 characters")
+	}
+	if len(password) > 72 {
+		return fmt.Errorf("password must be at most 72 characters")
+	}
+	return nil
+}
+
 func (obj *UserRegisterReq) IsEmail() bool {
 	match, err := regexp.MatchString(`^[\w-\.]+@([\w-]+\.)+[\w-]{2,4}$`, obj.Email)
 	if err != nil {
@@ -83,3 +285,37 @@ func (obj *UserRegisterReq) IsEmail() bool {
 	//fmt.Println("error : ", err)
 	return match
 }
+
+func NewNonce() (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce failed: %v", err)
+	}
+	return hex.EncodeToString(nonce), nil
+}
+
+// NewRecoveryCode returns a one time code in the xxxxx-xxxxx form shown to the user
+func NewRecoveryCode() (string, error) {
+	code := make([]byte, 5)
+	if _, err := rand.Read(code); err != nil {
+		return "", fmt.Errorf("generate recovery code failed: %v", err)
+	}
+	encoded := hex.EncodeToString(code)
+	return encoded[:5] + "-" + encoded[5:], nil
+}
+
+// NormalizeRecoveryCode lets a recovery code be typed without the dash or in upper case
+func NormalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// BuildMessage sets the text the wallet signs with personal_sign, the nonce makes every signature single use
+func (obj *AddressChallenge) BuildMessage(appName string, issuedAt time.Time) {
+	obj.Message = fmt.Sprintf(
+		"%s wants you to link the address %s to your account.\n\nNonce: %s\nIssued At: %s",
+		appName,
+		obj.Address,
+		obj.Nonce,
+		issuedAt.UTC().Format(time.RFC3339),
+	)
+}
@@ -1,16 +1,19 @@
 package usersHandlers
 
 import (
-	"fmt"
+	"errors"
+	"strconv"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 
 	"github.com/muhammadfarhankt/nft-marketplace/config"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/entities"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/users"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/users/usersUsecases"
 	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftauth"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/utils"
 )
 
 type usersHandlersErrCode string
@@ -23,6 +26,28 @@ const (
 	signUpAdminErr        usersHandlersErrCode = "users-error-005"
 	generateAdminTokenErr usersHandlersErrCode = "users-error-006"
 	getUserProfileErr     usersHandlersErrCode = "users-error-007"
+	addressChallengeErr   usersHandlersErrCode = "users-error-008"
+	verifyAddressErr      usersHandlersErrCode = "users-error-009"
+	siweNonceErr          usersHandlersErrCode = "users-error-010"
+	siweSignInErr         usersHandlersErrCode = "users-error-011"
+	refreshReusedErr      usersHandlersErrCode = "users-error-012"
+	findSessionsErr       usersHandlersErrCode = "users-error-013"
+	revokeSessionErr      usersHandlersErrCode = "users-error-014"
+	revokeSessionsErr     usersHandlersErrCode = "users-error-015"
+	findAdminTokensErr    usersHandlersErrCode = "users-error-016"
+	verifyEmailErr        usersHandlersErrCode = "users-error-017"
+	resendVerificationErr usersHandlersErrCode = "users-error-018"
+	forgotPasswordErr     usersHandlersErrCode = "users-error-019"
+	resetPasswordErr      usersHandlersErrCode = "users-error-020"
+	changePasswordErr     usersHandlersErrCode = "users-error-021"
+	signInMfaErr          usersHandlersErrCode = "users-error-022"
+	enrollMfaErr          usersHandlersErrCode = "users-error-023"
+	activateMfaErr        usersHandlersErrCode = "users-error-024"
+	disableMfaErr         usersHandlersErrCode = "users-error-025"
+	stepUpErr             usersHandlersErrCode = "users-error-026"
+	signInLockedErr       usersHandlersErrCode = "users-error-027"
+	findLoginThrottlesErr usersHandlersErrCode = "users-error-028"
+	unlockLoginErr        usersHandlersErrCode = "users-error-029"
 )
 
 type IUsersHandler interface {
@@ -33,6 +58,27 @@ type IUsersHandler interface {
 	SignUpAdmin(c *fiber.Ctx) error
 	GenerateAdminToken(c *fiber.Ctx) error
 	GetUserProfile(c *fiber.Ctx) error
+	SiweNonce(c *fiber.Ctx) error
+	SiweSignIn(c *fiber.Ctx) error
+	IssueAddressChallenge(c *fiber.Ctx) error
+	VerifyAddress(c *fiber.Ctx) error
+	FindSessions(c *fiber.Ctx) error
+	RevokeSession(c *fiber.Ctx) error
+	RevokeOtherSessions(c *fiber.Ctx) error
+	Jwks(c *fiber.Ctx) error
+	FindAdminTokens(c *fiber.Ctx) error
+	VerifyEmail(c *fiber.Ctx) error
+	ResendVerification(c *fiber.Ctx) error
+	ForgotPassword(c *fiber.Ctx) error
+	ResetPassword(c *fiber.Ctx) error
+	ChangePassword(c *fiber.Ctx) error
+	SignInMfa(c *fiber.Ctx) error
+	EnrollMfa(c *fiber.Ctx) error
+	ActivateMfa(c *fiber.Ctx) error
+	DisableMfa(c *fiber.Ctx) error
+	StepUp(c *fiber.Ctx) error
+	FindLoginThrottles(c *fiber.Ctx) error
+	UnlockLogin(c *fiber.Ctx) error
 }
 
 type usersHandler struct {
@@ -40,6 +86,47 @@ type usersHandler struct {
 	userUsecase usersUsecases.IUsersUsecase
 }
 
+func (h *usersHandler) sessionClient(c *fiber.Ctx) users.SessionClient {
+	return users.SessionClient{
+		Ip:        utils.ClientIp(c.Context().RemoteIP().String(), c.IPs(), h.cfg.App().TrustedProxies()),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	}
+}
+
+// passportStatus tells a finished sign in from one still waiting for its second factor
+func passportStatus(passport *users.UserPassport) int {
+	if passport.MfaChallenge != nil {
+		return fiber.StatusAccepted
+	}
+	return fiber.StatusOK
+}
+
+// mfaErrStatus maps the errors shared by every endpoint that checks a second factor
+func mfaErrStatus(err error) int {
+	switch err.Error() {
+	case "invalid code",
+		"code already used",
+		"invalid mfa challenge",
+		"mfa challenge expired":
+		return fiber.ErrUnauthorized.Code
+	case "two-factor authentication is not enabled",
+		"two-factor authentication is already enabled":
+		return fiber.ErrConflict.Code
+	default:
+		return fiber.ErrInternalServerError.Code
+	}
+}
+
+// signInLocked answers a locked out sign in with 429 and the seconds left in Retry-After
+func signInLocked(c *fiber.Ctx, lockout *users.LockoutError) error {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(lockout.RetryAfter.Seconds())))
+	return entities.NewResponse(c).Error(
+		fiber.ErrTooManyRequests.Code,
+		string(signInLockedErr),
+		lockout.Error(),
+	).Res()
+}
+
 func UsersHandler(cfg config.IConfig, usersUsecase usersUsecases.IUsersUsecase) IUsersHandler {
 	return &usersHandler{
 		cfg:         cfg,
@@ -106,15 +193,21 @@ func (h *usersHandler) SignIn(c *fiber.Ctx) error {
 		).Res()
 	}
 
+	req.Client = h.sessionClient(c)
+
 	passport, err := h.userUsecase.GetPassport(req)
 	if err != nil {
+		var lockout *users.LockoutError
+		if errors.As(err, &lockout) {
+			return signInLocked(c, lockout)
+		}
 		return entities.NewResponse(c).Error(
 			fiber.ErrBadRequest.Code,
 			string(signInErr),
 			err.Error(),
 		).Res()
 	}
-	return entities.NewResponse(c).Success(fiber.StatusOK, passport).Res()
+	return entities.NewResponse(c).Success(passportStatus(passport), passport).Res()
 }
 
 func (u *usersHandler) SignUpAdmin(c *fiber.Ctx) error {
@@ -138,9 +231,19 @@ func (u *usersHandler) SignUpAdmin(c *fiber.Ctx) error {
 	}
 
 	// Insertion
-	result, err := u.userUsecase.InsertAdmin(req)
+	result, err := u.userUsecase.InsertAdmin(
+		req,
+		c.Locals("adminTokenJti").(string),
+		c.Locals("userId").(string),
+	)
 	if err != nil {
 		switch err.Error() {
+		case "admin token was already used or never issued":
+			return entities.NewResponse(c).Error(
+				fiber.ErrUnauthorized.Code,
+				string(signUpAdminErr),
+				err.Error(),
+			).Res()
 		case "username already exists":
 			return entities.NewResponse(c).Error(
 				fiber.ErrBadRequest.Code,
@@ -167,11 +270,7 @@ func (u *usersHandler) SignUpAdmin(c *fiber.Ctx) error {
 }
 
 func (u *usersHandler) GenerateAdminToken(c *fiber.Ctx) error {
-	adminToken, err := nftauth.NewAuth(
-		nftauth.Admin,
-		u.cfg.Jwt(),
-		nil,
-	)
+	adminToken, err := u.userUsecase.GenerateAdminToken(c.Locals("userId").(string))
 	if err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
@@ -184,7 +283,7 @@ func (u *usersHandler) GenerateAdminToken(c *fiber.Ctx) error {
 		&struct {
 			Token string `json:"token"`
 		}{
-			Token: adminToken.SignToken(),
+			Token: adminToken,
 		},
 	).Res()
 }
@@ -201,6 +300,13 @@ func (h *usersHandler) RefreshPassport(c *fiber.Ctx) error {
 
 	passport, err := h.userUsecase.RefreshPassport(req)
 	if err != nil {
+		if errors.Is(err, users.ErrRefreshReused) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrUnauthorized.Code,
+				string(refreshReusedErr),
+				"refresh token reused, the session has been revoked",
+			).Res()
+		}
 		return entities.NewResponse(c).Error(
 			fiber.ErrBadRequest.Code,
 			string(refreshErr),
@@ -220,13 +326,21 @@ func (h *usersHandler) SignOut(c *fiber.Ctx) error {
 			err.Error(),
 		).Res()
 	}
-	fmt.Println("Users Handler req : ", req)
-	if err := h.userUsecase.DeleteOauth(req.OauthId); err != nil {
-		return entities.NewResponse(c).Error(
-			fiber.ErrBadRequest.Code,
-			string(logoutErr),
-			err.Error(),
-		).Res()
+	if err := h.userUsecase.DeleteOauth(c.Locals("userId").(string), req.OauthId); err != nil {
+		switch err.Error() {
+		case "session not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(logoutErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(logoutErr),
+				err.Error(),
+			).Res()
+		}
 	}
 
 	return entities.NewResponse(c).Success(fiber.StatusOK, "logout sucess").Res()
@@ -254,3 +368,521 @@ func (h *usersHandler) GetUserProfile(c *fiber.Ctx) error {
 	}
 	return entities.NewResponse(c).Success(fiber.StatusOK, profile).Res()
 }
+
+func (h *usersHandler) IssueAddressChallenge(c *fiber.Ctx) error {
+	req := new(users.AddressChallengeReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(addressChallengeErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = c.Locals("userId").(string)
+
+	challenge, err := h.userUsecase.IssueAddressChallenge(req)
+	if err != nil {
+		switch err.Error() {
+		case "invalid address",
+			"invalid address checksum":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(addressChallengeErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(addressChallengeErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, challenge).Res()
+}
+
+func (h *usersHandler) VerifyAddress(c *fiber.Ctx) error {
+	req := new(users.AddressVerifyReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(verifyAddressErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = c.Locals("userId").(string)
+
+	addresses, err := h.userUsecase.VerifyAddress(req)
+	if err != nil {
+		switch err.Error() {
+		case "challenge not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(verifyAddressErr),
+				err.Error(),
+			).Res()
+		case "challenge is no longer valid",
+			"address is already linked":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(verifyAddressErr),
+				err.Error(),
+			).Res()
+		default:
+			if strings.HasPrefix(err.Error(), "invalid signature") {
+				return entities.NewResponse(c).Error(
+					fiber.ErrUnauthorized.Code,
+					string(verifyAddressErr),
+					err.Error(),
+				).Res()
+			}
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(verifyAddressErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, addresses).Res()
+}
+
+func (h *usersHandler) SiweNonce(c *fiber.Ctx) error {
+	nonce, err := h.userUsecase.IssueSiweNonce()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(siweNonceErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, nonce).Res()
+}
+
+func (h *usersHandler) SiweSignIn(c *fiber.Ctx) error {
+	req := new(users.SiweSignInReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(siweSignInErr),
+			err.Error(),
+		).Res()
+	}
+
+	req.Client = h.sessionClient(c)
+
+	passport, err := h.userUsecase.SiweSignIn(req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid siwe message"),
+			strings.HasPrefix(err.Error(), "unsupported siwe"),
+			err.Error() == "siwe address must be checksummed":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(siweSignInErr),
+				err.Error(),
+			).Res()
+		case strings.HasPrefix(err.Error(), "invalid signature"),
+			err.Error() == "invalid siwe nonce",
+			err.Error() == "siwe domain mismatch",
+			err.Error() == "siwe chain id mismatch",
+			err.Error() == "siwe message is issued in the future",
+			err.Error() == "siwe message has expired",
+			err.Error() == "siwe message is not yet valid":
+			return entities.NewResponse(c).Error(
+				fiber.ErrUnauthorized.Code,
+				string(siweSignInErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(siweSignInErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(passportStatus(passport), passport).Res()
+}
+
+func (h *usersHandler) FindSessions(c *fiber.Ctx) error {
+	sessions, err := h.userUsecase.FindSessions(
+		c.Locals("userId").(string),
+		c.Locals("accessJti").(string),
+	)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findSessionsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, sessions).Res()
+}
+
+func (h *usersHandler) RevokeSession(c *fiber.Ctx) error {
+	sessionId := strings.Trim(c.Params("session_id"), " ")
+	if _, err := uuid.Parse(sessionId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(revokeSessionErr),
+			"invalid session id",
+		).Res()
+	}
+
+	if err := h.userUsecase.DeleteOauth(c.Locals("userId").(string), sessionId); err != nil {
+		switch err.Error() {
+		case "session not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(revokeSessionErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(revokeSessionErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, "session revoked successfully").Res()
+}
+
+func (h *usersHandler) RevokeOtherSessions(c *fiber.Ctx) error {
+	revoked, err := h.userUsecase.DeleteOtherOauth(
+		c.Locals("userId").(string),
+		c.Locals("accessJti").(string),
+	)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(revokeSessionsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(
+		fiber.StatusOK,
+		&struct {
+			Revoked int64 `json:"revoked"`
+		}{
+			Revoked: revoked,
+		},
+	).Res()
+}
+
+// Jwks publishes the public keys so other services can verify access tokens on their own
+func (h *usersHandler) Jwks(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return entities.NewResponse(c).Success(fiber.StatusOK, nftauth.Jwks(h.cfg.Jwt())).Res()
+}
+
+func (h *usersHandler) FindAdminTokens(c *fiber.Ctx) error {
+	tokens, err := h.userUsecase.FindAdminTokens()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findAdminTokensErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, tokens).Res()
+}
+
+func (h *usersHandler) VerifyEmail(c *fiber.Ctx) error {
+	if err := h.userUsecase.VerifyEmail(c.Query("token")); err != nil {
+		switch err.Error() {
+		case "invalid verification link",
+			"verification link expired":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(verifyEmailErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(verifyEmailErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, "email verified successfully").Res()
+}
+
+func (h *usersHandler) ResendVerification(c *fiber.Ctx) error {
+	if err := h.userUsecase.ResendVerification(c.Locals("userId").(string)); err != nil {
+		switch err.Error() {
+		case "account is already verified":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(resendVerificationErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(resendVerificationErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, "verification email sent").Res()
+}
+
+func (h *usersHandler) ForgotPassword(c *fiber.Ctx) error {
+	req := new(users.ForgotPasswordReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(forgotPasswordErr),
+			err.Error(),
+		).Res()
+	}
+
+	h.userUsecase.ForgotPassword(req)
+	return entities.NewResponse(c).Success(fiber.StatusOK, "a reset link is sent if the email belongs to an account").Res()
+}
+
+func (h *usersHandler) ResetPassword(c *fiber.Ctx) error {
+	req := new(users.ResetPasswordReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(resetPasswordErr),
+			err.Error(),
+		).Res()
+	}
+
+	if err := h.userUsecase.ResetPassword(req); err != nil {
+		switch {
+		case err.Error() == "invalid or expired reset token",
+			strings.HasPrefix(err.Error(), "password must"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(resetPasswordErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(resetPasswordErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, "password reset successfully").Res()
+}
+
+func (h *usersHandler) ChangePassword(c *fiber.Ctx) error {
+	req := new(users.ChangePasswordReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(changePasswordErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = c.Locals("userId").(string)
+
+	if err := h.userUsecase.ChangePassword(req); err != nil {
+		switch {
+		case err.Error() == "invalid password":
+			return entities.NewResponse(c).Error(
+				fiber.ErrUnauthorized.Code,
+				string(changePasswordErr),
+				err.Error(),
+			).Res()
+		case strings.HasPrefix(err.Error(), "password must"):
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(changePasswordErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(changePasswordErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, "password changed successfully, sign in again").Res()
+}
+
+func (h *usersHandler) SignInMfa(c *fiber.Ctx) error {
+	req := new(users.MfaSignInReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(signInMfaErr),
+			err.Error(),
+		).Res()
+	}
+
+	req.Client = h.sessionClient(c)
+
+	passport, err := h.userUsecase.SignInMfa(req)
+	if err != nil {
+		var lockout *users.LockoutError
+		if errors.As(err, &lockout) {
+			return signInLocked(c, lockout)
+		}
+		return entities.NewResponse(c).Error(
+			mfaErrStatus(err),
+			string(signInMfaErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, passport).Res()
+}
+
+func (h *usersHandler) EnrollMfa(c *fiber.Ctx) error {
+	enrollment, err := h.userUsecase.EnrollMfa(c.Locals("userId").(string))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			mfaErrStatus(err),
+			string(enrollMfaErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, enrollment).Res()
+}
+
+func (h *usersHandler) mfaCodeReq(c *fiber.Ctx) (*users.MfaCodeReq, error) {
+	req := new(users.MfaCodeReq)
+	if err := c.BodyParser(req); err != nil {
+		return nil, err
+	}
+	req.UserId = c.Locals("userId").(string)
+	req.AccessJti = c.Locals("accessJti").(string)
+	req.Client = h.sessionClient(c)
+	return req, nil
+}
+
+func (h *usersHandler) ActivateMfa(c *fiber.Ctx) error {
+	req, err := h.mfaCodeReq(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(activateMfaErr),
+			err.Error(),
+		).Res()
+	}
+
+	codes, err := h.userUsecase.ActivateMfa(req)
+	if err != nil {
+		var lockout *users.LockoutError
+		if errors.As(err, &lockout) {
+			return signInLocked(c, lockout)
+		}
+		return entities.NewResponse(c).Error(
+			mfaErrStatus(err),
+			string(activateMfaErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, codes).Res()
+}
+
+func (h *usersHandler) DisableMfa(c *fiber.Ctx) error {
+	req, err := h.mfaCodeReq(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(disableMfaErr),
+			err.Error(),
+		).Res()
+	}
+
+	if err := h.userUsecase.DisableMfa(req); err != nil {
+		var lockout *users.LockoutError
+		if errors.As(err, &lockout) {
+			return signInLocked(c, lockout)
+		}
+		return entities.NewResponse(c).Error(
+			mfaErrStatus(err),
+			string(disableMfaErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, "two-factor authentication disabled").Res()
+}
+
+func (h *usersHandler) StepUp(c *fiber.Ctx) error {
+	req, err := h.mfaCodeReq(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(stepUpErr),
+			err.Error(),
+		).Res()
+	}
+
+	if err := h.userUsecase.StepUp(req); err != nil {
+		var lockout *users.LockoutError
+		if errors.As(err, &lockout) {
+			return signInLocked(c, lockout)
+		}
+		return entities.NewResponse(c).Error(
+			mfaErrStatus(err),
+			string(stepUpErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, "session verified").Res()
+}
+
+func (h *usersHandler) FindLoginThrottles(c *fiber.Ctx) error {
+	throttles, err := h.userUsecase.FindLoginThrottles()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findLoginThrottlesErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, throttles).Res()
+}
+
+func (h *usersHandler) UnlockLogin(c *fiber.Ctx) error {
+	req := new(users.UnlockLoginReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(unlockLoginErr),
+			err.Error(),
+		).Res()
+	}
+
+	unlocked, err := h.userUsecase.UnlockLogin(req)
+	if err != nil {
+		switch err.Error() {
+		case "username or ip is required":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(unlockLoginErr),
+				err.Error(),
+			).Res()
+		case "login throttle not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(unlockLoginErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(unlockLoginErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(
+		fiber.StatusOK,
+		&struct {
+			Unlocked int64 `json:"unlocked"`
+		}{
+			Unlocked: unlocked,
+		},
+	).Res()
+}
@@ -2,11 +2,11 @@ package usersPatterns
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"time"
 
-	"github.com/jmoiron/sqlx"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/users"
 )
 
@@ -16,10 +16,16 @@ type IInsertUser interface {
 	Result() (*users.UserPassport, error)
 }
 
+// queryer is a *sqlx.DB or a *sqlx.Tx, an admin is inserted inside the transaction that spends its admin token
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	Get(dest any, query string, args ...any) error
+}
+
 type userReq struct {
 	id  string
 	req *users.UserRegisterReq
-	db  *sqlx.DB
+	db  queryer
 }
 
 type customer struct {
@@ -30,14 +36,14 @@ type admin struct {
 	*userReq
 }
 
-func InserUser(db *sqlx.DB, req *users.UserRegisterReq, isAdmin bool) IInsertUser {
+func InserUser(db queryer, req *users.UserRegisterReq, isAdmin bool) IInsertUser {
 	if isAdmin {
 		return newAdmin(db, req)
 	}
 	return newCustomer(db, req)
 }
 
-func newCustomer(db *sqlx.DB, req *users.UserRegisterReq) IInsertUser {
+func newCustomer(db queryer, req *users.UserRegisterReq) IInsertUser {
 	return &customer{
 		userReq: &userReq{
 			req: req,
@@ -46,7 +52,7 @@ func newCustomer(db *sqlx.DB, req *users.UserRegisterReq) IInsertUser {
 	}
 }
 
-func newAdmin(db *sqlx.DB, req *users.UserRegisterReq) IInsertUser {
+func newAdmin(db queryer, req *users.UserRegisterReq) IInsertUser {
 	return &admin{
 		userReq: &userReq{
 			req: req,
@@ -59,16 +65,23 @@ func (u *userReq) Customer() (IInsertUser, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
+	// the sign up role is also the first entry of user_roles, which grants the permissions
 	query := `
-	INSERT INTO "users" 
-	(	"email", 
-		"password", 
-		"username",
-		"role_id"
+	WITH "u" AS (
+		INSERT INTO "users" 
+		(	"email", 
+			"password", 
+			"username",
+			"role_id"
+		)
+		VALUES 
+		($1, $2, $3, 1)
+		RETURNING "id", "role_id"
+	), "ur" AS (
+		INSERT INTO "user_roles" ("user_id", "role_id")
+		SELECT "id", "role_id" FROM "u"
 	)
-	VALUES 
-	($1, $2, $3, 1)
-	RETURNING "id";`
+	SELECT "id" FROM "u";`
 
 	if err := u.db.QueryRowContext(
 		ctx,
@@ -95,16 +108,24 @@ func (u *userReq) Admin() (IInsertUser, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
+	// admins are created by another admin, their email is not verified by link
 	query := `
-	INSERT INTO "users" 
-	(	"email", 
-		"password", 
-		"username",
-		"role_id"
+	WITH "u" AS (
+		INSERT INTO "users" 
+		(	"email", 
+			"password", 
+			"username",
+			"role_id",
+			"email_verified_at"
+		)
+		VALUES 
+		($1, $2, $3, 2, now())
+		RETURNING "id", "role_id"
+	), "ur" AS (
+		INSERT INTO "user_roles" ("user_id", "role_id")
+		SELECT "id", "role_id" FROM "u"
 	)
-	VALUES 
-	($1, $2, $3, 2)
-	RETURNING "id";`
+	SELECT "id" FROM "u";`
 
 	if err := u.db.QueryRowContext(
 		ctx,
@@ -136,8 +157,9 @@ func (u *userReq) Result() (*users.UserPassport, error) {
 		SELECT
 			"us"."id",
 			"us"."username",
-			"us"."email",
-			"us"."role_id"
+			COALESCE("us"."email", '') AS "email",
+			"us"."role_id",
+			("us"."email" IS NULL OR "us"."email_verified_at" IS NOT NULL) AS "verified"
 		FROM "users" "us"
 		WHERE "us"."id" = $1
 	) AS "t"
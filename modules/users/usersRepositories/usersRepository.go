@@ -2,22 +2,58 @@ package usersRepositories
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/users"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/users/usersPatterns"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftauth"
 )
 
 type IUsersRepository interface {
 	InsertUser(req *users.UserRegisterReq, isAdmin bool) (*users.UserPassport, error)
+	InsertAdmin(req *users.UserRegisterReq, adminTokenJti, consumedBy string) (*users.UserPassport, error)
 	FindOneUserByUsername(username string) (*users.UserCredentialCheck, error)
 	GetProfile(userId string) (*users.User, error)
-	InsertOAuth(req *users.UserPassport) error
+	InsertOAuth(req *users.UserPassport, client *users.SessionClient) error
 	FindOneOAuth(refreshToken string) (*users.Oauth, error)
-	UpdateOneOAuth(req *users.UserToken) error
-	DeleteOauth(oauthId string) error
+	FindRotatedOAuth(refreshToken string) (*users.Oauth, error)
+	RotateOAuth(req *users.UserToken, refreshToken string) error
+	FindSessions(userId string) ([]*users.Session, error)
+	DeleteOauth(userId, oauthId string) error
+	DeleteOtherOauth(userId, accessJti string) (int64, error)
+	FindUserAddresses(userId string) ([]*users.UserAddress, error)
+	InsertAddressChallenge(req *users.AddressChallenge) error
+	FindAddressChallenge(userId, challengeId string) (*users.AddressChallenge, error)
+	InsertUserAddress(challenge *users.AddressChallenge) error
+	InsertSiweNonce(req *users.SiweNonce) error
+	UseSiweNonce(nonce string) error
+	FindOrInsertUserByAddress(address string) (*users.UserCredentialCheck, error)
+	InsertAdminToken(req *users.AdminToken) error
+	FindAdminTokens() ([]*users.AdminToken, error)
+	VerifyEmail(userId, email string) error
+	FindOneUserByEmail(email string) (*users.UserCredentialCheck, error)
+	FindOneUserById(userId string) (*users.UserCredentialCheck, error)
+	InsertPasswordReset(req *users.PasswordReset) error
+	ResetPassword(tokenHash, password string) (string, error)
+	UpdatePassword(userId, password string) error
+	FindMfa(userId string) (*users.UserMfa, error)
+	UpsertMfa(userId, secret string) error
+	EnableMfa(userId, accessJti string, step int64, recoveryCodeHashes []string) error
+	DeleteMfa(userId string) error
+	UseTotpStep(userId string, step int64) error
+	UseRecoveryCode(userId, codeHash string) error
+	MarkSessionMfa(userId, accessJti string) error
+	FindLoginLockout(username, ip string) (time.Duration, error)
+	RecordLoginFailure(scope, key string, window time.Duration) (int, error)
+	LockLogin(scope, key string, lockout time.Duration) error
+	DeleteLoginThrottle(scope, key string) (int64, error)
+	FindLoginThrottles() ([]*users.LoginThrottle, error)
+	DeleteStaleLoginThrottles(window time.Duration) (int64, error)
 }
 
 type usersRepository struct {
@@ -52,15 +88,62 @@ func (u *usersRepository) InsertUser(req *users.UserRegisterReq, isAdmin bool) (
 	return user, nil
 }
 
+// InsertAdmin spends the admin token and creates the admin in one transaction,
+// a sign up that fails leaves the token unused
+func (r *usersRepository) InsertAdmin(req *users.UserRegisterReq, adminTokenJti, consumedBy string) (*users.UserPassport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+	UPDATE "admin_tokens"
+	SET "consumed_by" = $2,
+		"consumed_at" = now()
+	WHERE "jti" = $1
+	AND "consumed_at" IS NULL
+	AND "expires_at" > now();`
+
+	result, err := tx.ExecContext(ctx, query, adminTokenJti, consumedBy)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("consume admin token failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows != 1 {
+		tx.Rollback()
+		return nil, fmt.Errorf("admin token was already used or never issued")
+	}
+
+	inserted, err := usersPatterns.InserUser(tx, req, true).Admin()
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	user, err := inserted.Result()
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
 func (r *usersRepository) FindOneUserByUsername(username string) (*users.UserCredentialCheck, error) {
 	query := `
-		SELECT "id", "username", "email", "password", "role_id"
+		SELECT "id", "username", COALESCE("email", '') AS "email", COALESCE("password", '') AS "password", "role_id",
+			("email" IS NULL OR "email_verified_at" IS NOT NULL) AS "verified"
 		FROM "users"
 		WHERE username = $1;
 	`
 	user := new(users.UserCredentialCheck)
 	if err := r.db.Get(user, query, username); err != nil {
-		return nil, fmt.Errorf("user not found w/ username %v \n error: %v", username, err)
+		return nil, fmt.Errorf("user not found w/ username %v \n error: %w", username, err)
 	}
 
 	return user, nil
@@ -69,17 +152,27 @@ func (r *usersRepository) FindOneUserByUsername(username string) (*users.UserCre
 	//return usersPatterns.FindOneUserByUsername(r.db, username)
 }
 
-func (r *usersRepository) InsertOAuth(req *users.UserPassport) error {
+func (r *usersRepository) InsertOAuth(req *users.UserPassport, client *users.SessionClient) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 	query := `
 		INSERT INTO "oauth"
-		("user_id", "access_token", "refresh_token")
+		("user_id", "access_token_hash", "access_jti", "refresh_token_hash", "refresh_jti", "ip", "user_agent")
 		VALUES
-		($1, $2, $3)
+		($1, $2, $3, $4, $5, $6, $7)
 		RETURNING "id";
 	`
-	err := r.db.QueryRowContext(ctx, query, req.User.Id, req.Token.AccessToken, req.Token.RefreshToken).Scan(&req.Token.Id)
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		req.User.Id,
+		nftauth.HashToken(req.Token.AccessToken),
+		req.Token.AccessJti,
+		nftauth.HashToken(req.Token.RefreshToken),
+		req.Token.RefreshJti,
+		client.Ip,
+		client.UserAgent,
+	).Scan(&req.Token.Id)
 	if err != nil {
 		return fmt.Errorf("inserting oauth user token failed: %v", err)
 	}
@@ -90,25 +183,87 @@ func (r *usersRepository) FindOneOAuth(refreshToken string) (*users.Oauth, error
 	query := `
 		SELECT "id", "user_id"
 		FROM "oauth"
-		WHERE "refresh_token" = $1;
+		WHERE "refresh_token_hash" = $1;
 	`
 	oauth := new(users.Oauth)
-	if err := r.db.Get(oauth, query, refreshToken); err != nil {
+	if err := r.db.Get(oauth, query, nftauth.HashToken(refreshToken)); err != nil {
 		return nil, fmt.Errorf("oauth not found. Error : %v", err)
 	}
 	return oauth, nil
 }
 
-func (r *usersRepository) UpdateOneOAuth(req *users.UserToken) error {
+// FindRotatedOAuth finds the session a refresh token was rotated out of
+func (r *usersRepository) FindRotatedOAuth(refreshToken string) (*users.Oauth, error) {
 	query := `
-		UPDATE "oauth"
-		SET "access_token" = :access_token,
-			"refresh_token" = :refresh_token
-		WHERE "id" = :id
-	;`
-	if _, err := r.db.NamedExecContext(context.Background(), query, req); err != nil {
+		SELECT "o"."id", "o"."user_id"
+		FROM "oauth_refresh_history" "h"
+		JOIN "oauth" "o" ON "o"."id" = "h"."oauth_id"
+		WHERE "h"."token_hash" = $1;
+	`
+	oauth := new(users.Oauth)
+	if err := r.db.Get(oauth, query, nftauth.HashToken(refreshToken)); err != nil {
+		return nil, fmt.Errorf("rotated oauth not found. Error : %v", err)
+	}
+	return oauth, nil
+}
+
+// RotateOAuth swaps the session tokens only while refreshToken is still the current one,
+// losing a race against another refresh of the same token counts as reuse
+func (r *usersRepository) RotateOAuth(req *users.UserToken, refreshToken string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	oldHash := nftauth.HashToken(refreshToken)
+	var oldJti string
+	if err := tx.GetContext(ctx, &oldJti, `
+		UPDATE "oauth" "o"
+		SET "access_token_hash" = $1,
+			"access_jti" = $2,
+			"refresh_token_hash" = $3,
+			"refresh_jti" = $4,
+			"last_used_at" = now()
+		FROM (
+			SELECT "refresh_jti"
+			FROM "oauth"
+			WHERE "id" = $5
+			AND "refresh_token_hash" = $6
+			FOR UPDATE
+		) "prev"
+		WHERE "o"."id" = $5
+		RETURNING "prev"."refresh_jti";`,
+		nftauth.HashToken(req.AccessToken),
+		req.AccessJti,
+		nftauth.HashToken(req.RefreshToken),
+		req.RefreshJti,
+		req.Id,
+		oldHash,
+	); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return users.ErrRefreshReused
+		}
 		return fmt.Errorf("updating oauth fialed : %v", err)
 	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "oauth_refresh_history" ("token_hash", "oauth_id", "refresh_jti")
+		VALUES ($1, $2, $3);`,
+		oldHash,
+		req.Id,
+		oldJti,
+	); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("insert refresh history failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit oauth failed: %v", err)
+	}
 	return nil
 }
 
@@ -116,9 +271,10 @@ func (r *usersRepository) GetProfile(userId string) (*users.User, error) {
 	query := `
 	SELECT
 		"id",
-		"email",
+		COALESCE("email", '') AS "email",
 		"username",
-		"role_id"
+		"role_id",
+		("email" IS NULL OR "email_verified_at" IS NOT NULL) AS "verified"
 	FROM "users"
 	WHERE "id" = $1;`
 
@@ -129,14 +285,792 @@ func (r *usersRepository) GetProfile(userId string) (*users.User, error) {
 	return profile, nil
 }
 
-func (r *usersRepository) DeleteOauth(oauthId string) error {
+func (r *usersRepository) FindSessions(userId string) ([]*users.Session, error) {
+	query := `
+	SELECT
+		"id",
+		"ip",
+		"user_agent",
+		"created_at",
+		"last_used_at",
+		"access_jti"
+	FROM "oauth"
+	WHERE "user_id" = $1
+	ORDER BY "last_used_at" DESC;`
+
+	sessions := make([]*users.Session, 0)
+	if err := r.db.Select(&sessions, query, userId); err != nil {
+		return nil, fmt.Errorf("get sessions failed: %v", err)
+	}
+	return sessions, nil
+}
+
+// DeleteOauth only removes a session owned by the user
+func (r *usersRepository) DeleteOauth(userId, oauthId string) error {
 	query := `
 		DELETE FROM "oauth"
-		WHERE "id" = $1;
+		WHERE "id" = $1
+		AND "user_id" = $2;
 	`
-	fmt.Println("oauthId : ", oauthId)
-	if _, err := r.db.ExecContext(context.Background(), query, oauthId); err != nil {
+	result, err := r.db.ExecContext(context.Background(), query, oauthId, userId)
+	if err != nil {
 		return fmt.Errorf("deleting oauth failed : %v", err)
 	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("session not found")
+	}
+	return nil
+}
+
+// DeleteOtherOauth removes every session of the user except the one holding accessJti
+func (r *usersRepository) DeleteOtherOauth(userId, accessJti string) (int64, error) {
+	query := `
+		DELETE FROM "oauth"
+		WHERE "user_id" = $1
+		AND "access_jti" <> $2;
+	`
+	result, err := r.db.ExecContext(context.Background(), query, userId, accessJti)
+	if err != nil {
+		return 0, fmt.Errorf("deleting oauth failed : %v", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows, nil
+}
+
+func (r *usersRepository) FindUserAddresses(userId string) ([]*users.UserAddress, error) {
+	query := `
+	SELECT
+		"address",
+		"verified_at"
+	FROM "user_addresses"
+	WHERE "user_id" = $1
+	ORDER BY "verified_at" ASC;`
+
+	addresses := make([]*users.UserAddress, 0)
+	if err := r.db.Select(&addresses, query, userId); err != nil {
+		return nil, fmt.Errorf("get user addresses failed: %v", err)
+	}
+	return addresses, nil
+}
+
+func (r *usersRepository) InsertAddressChallenge(req *users.AddressChallenge) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	INSERT INTO "address_challenges" (
+		"user_id",
+		"address",
+		"nonce",
+		"message",
+		"expires_at"
+	)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING "id";`
+
+	if err := r.db.QueryRowContext(
+		ctx,
+		query,
+		req.UserId,
+		req.Address,
+		req.Nonce,
+		req.Message,
+		req.ExpiresAt,
+	).Scan(&req.Id); err != nil {
+		return fmt.Errorf("insert address challenge failed: %v", err)
+	}
+	return nil
+}
+
+func (r *usersRepository) FindAddressChallenge(userId, challengeId string) (*users.AddressChallenge, error) {
+	query := `
+	SELECT
+		"id",
+		"user_id",
+		"address",
+		"nonce",
+		"message",
+		"expires_at",
+		"used_at"
+	FROM "address_challenges"
+	WHERE "id" = $1
+	AND "user_id" = $2;`
+
+	challenge := new(users.AddressChallenge)
+	if err := r.db.Get(challenge, query, challengeId, userId); err != nil {
+		return nil, fmt.Errorf("get address challenge failed: %v", err)
+	}
+	return challenge, nil
+}
+
+// InsertUserAddress consumes the challenge and links its address in one transaction,
+// so a signature can never be replayed to link an address twice
+func (r *usersRepository) InsertUserAddress(challenge *users.AddressChallenge) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+	UPDATE "address_challenges" SET
+		"used_at" = now()
+	WHERE "id" = $1
+	AND "used_at" IS NULL
+	AND "expires_at" > now();`, challenge.Id)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("use address challenge failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("challenge is no longer valid")
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	INSERT INTO "user_addresses" (
+		"user_id",
+		"address"
+	)
+	VALUES ($1, $2);`,
+		challenge.UserId,
+		challenge.Address,
+	); err != nil {
+		tx.Rollback()
+		if strings.Contains(err.Error(), "user_addresses_address_key") {
+			return fmt.Errorf("address is already linked")
+		}
+		return fmt.Errorf("insert user address failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit user address failed: %v", err)
+	}
+	return nil
+}
+
+func (r *usersRepository) InsertSiweNonce(req *users.SiweNonce) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	INSERT INTO "siwe_nonces" (
+		"nonce",
+		"expires_at"
+	)
+	VALUES ($1, $2);`
+
+	if _, err := r.db.ExecContext(ctx, query, req.Nonce, req.ExpiresAt); err != nil {
+		return fmt.Errorf("insert siwe nonce failed: %v", err)
+	}
+	return nil
+}
+
+// UseSiweNonce marks a server issued nonce as spent, a nonce can sign in only once
+func (r *usersRepository) UseSiweNonce(nonce string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	UPDATE "siwe_nonces" SET
+		"used_at" = now()
+	WHERE "nonce" = $1
+	AND "used_at" IS NULL
+	AND "expires_at" > now();`
+
+	result, err := r.db.ExecContext(ctx, query, nonce)
+	if err != nil {
+		return fmt.Errorf("use siwe nonce failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("invalid siwe nonce")
+	}
 	return nil
 }
+
+// FindOrInsertUserByAddress returns the user the address is linked to, signing in with an
+// unknown address creates a customer named after it
+func (r *usersRepository) FindOrInsertUserByAddress(address string) (*users.UserCredentialCheck, error) {
+	user, err := r.findUserByAddress(address)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var userId string
+	if err := tx.QueryRowContext(ctx, `
+	WITH "u" AS (
+		INSERT INTO "users" (
+			"username",
+			"role_id"
+		)
+		VALUES ($1, 1)
+		RETURNING "id", "role_id"
+	), "ur" AS (
+		INSERT INTO "user_roles" ("user_id", "role_id")
+		SELECT "id", "role_id" FROM "u"
+	)
+	SELECT "id" FROM "u";`, "eth_"+strings.ToLower(strings.TrimPrefix(address, "0x"))).Scan(&userId); err != nil {
+		tx.Rollback()
+		// a concurrent first sign-in with the same address won the race
+		if strings.Contains(err.Error(), "users_username_key") {
+			return r.findUserByAddress(address)
+		}
+		return nil, fmt.Errorf("insert user failed: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	INSERT INTO "user_addresses" (
+		"user_id",
+		"address"
+	)
+	VALUES ($1, $2);`, userId, address); err != nil {
+		tx.Rollback()
+		if strings.Contains(err.Error(), "user_addresses_address_key") {
+			return r.findUserByAddress(address)
+		}
+		return nil, fmt.Errorf("insert user address failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit user failed: %v", err)
+	}
+	return r.findUserByAddress(address)
+}
+
+func (r *usersRepository) findUserByAddress(address string) (*users.UserCredentialCheck, error) {
+	query := `
+	SELECT
+		"u"."id",
+		"u"."username",
+		COALESCE("u"."email", '') AS "email",
+		"u"."role_id",
+		("u"."email" IS NULL OR "u"."email_verified_at" IS NOT NULL) AS "verified"
+	FROM "user_addresses" "a"
+	JOIN "users" "u" ON "u"."id" = "a"."user_id"
+	WHERE "a"."address" = $1;`
+
+	user := new(users.UserCredentialCheck)
+	if err := r.db.Get(user, query, address); err != nil {
+		return nil, fmt.Errorf("get user failed: %w", err)
+	}
+	return user, nil
+}
+
+func (r *usersRepository) InsertAdminToken(req *users.AdminToken) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	INSERT INTO "admin_tokens" (
+		"jti",
+		"minted_by",
+		"expires_at"
+	)
+	VALUES ($1, $2, $3)
+	RETURNING "created_at";`
+
+	if err := r.db.QueryRowContext(
+		ctx,
+		query,
+		req.Jti,
+		req.MintedBy,
+		req.ExpiresAt,
+	).Scan(&req.CreatedAt); err != nil {
+		return fmt.Errorf("insert admin token failed: %v", err)
+	}
+	return nil
+}
+
+func (r *usersRepository) FindAdminTokens() ([]*users.AdminToken, error) {
+	query := `
+	SELECT
+		"jti",
+		"minted_by",
+		"expires_at",
+		"consumed_by",
+		"consumed_at",
+		"created_at"
+	FROM "admin_tokens"
+	ORDER BY "created_at" DESC
+	LIMIT 100;`
+
+	tokens := make([]*users.AdminToken, 0)
+	if err := r.db.Select(&tokens, query); err != nil {
+		return nil, fmt.Errorf("get admin tokens failed: %v", err)
+	}
+	return tokens, nil
+}
+
+// VerifyEmail keeps the first verification time when a link is opened twice
+func (r *usersRepository) VerifyEmail(userId, email string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	UPDATE "users"
+	SET "email_verified_at" = COALESCE("email_verified_at", now())
+	WHERE "id" = $1
+	AND "email" = $2;`
+
+	result, err := r.db.ExecContext(ctx, query, userId, email)
+	if err != nil {
+		return fmt.Errorf("verify email failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("invalid verification link")
+	}
+	return nil
+}
+
+func (r *usersRepository) findCredential(where string, arg any) (*users.UserCredentialCheck, error) {
+	query := `
+		SELECT "id", "username", COALESCE("email", '') AS "email", COALESCE("password", '') AS "password", "role_id",
+			("email" IS NULL OR "email_verified_at" IS NOT NULL) AS "verified"
+		FROM "users"
+		WHERE ` + where + ` = $1;
+	`
+	user := new(users.UserCredentialCheck)
+	if err := r.db.Get(user, query, arg); err != nil {
+		return nil, fmt.Errorf("get user failed: %w", err)
+	}
+	return user, nil
+}
+
+func (r *usersRepository) FindOneUserByEmail(email string) (*users.UserCredentialCheck, error) {
+	return r.findCredential(`"email"`, email)
+}
+
+func (r *usersRepository) FindOneUserById(userId string) (*users.UserCredentialCheck, error) {
+	return r.findCredential(`"id"`, userId)
+}
+
+func (r *usersRepository) InsertPasswordReset(req *users.PasswordReset) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	INSERT INTO "password_resets" (
+		"user_id",
+		"token_hash",
+		"expires_at"
+	)
+	VALUES ($1, $2, $3);`
+
+	if _, err := r.db.ExecContext(ctx, query, req.UserId, req.TokenHash, req.ExpiresAt); err != nil {
+		return fmt.Errorf("insert password reset failed: %v", err)
+	}
+	return nil
+}
+
+// ResetPassword spends the reset token, voids the other outstanding ones and ends every session of the user
+func (r *usersRepository) ResetPassword(tokenHash, password string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var userId string
+	if err := tx.GetContext(ctx, &userId, `
+	UPDATE "password_resets"
+	SET "used_at" = now()
+	WHERE "token_hash" = $1
+	AND "used_at" IS NULL
+	AND "expires_at" > now()
+	RETURNING "user_id";`, tokenHash); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("invalid or expired reset token")
+		}
+		return "", fmt.Errorf("use password reset failed: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE "password_resets"
+	SET "used_at" = now()
+	WHERE "user_id" = $1
+	AND "used_at" IS NULL;`, userId); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("void password resets failed: %v", err)
+	}
+
+	if err := updatePassword(ctx, tx, userId, password); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("commit password reset failed: %v", err)
+	}
+	return userId, nil
+}
+
+func (r *usersRepository) UpdatePassword(userId, password string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := updatePassword(ctx, tx, userId, password); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit password failed: %v", err)
+	}
+	return nil
+}
+
+// updatePassword stores the new hash and deletes every oauth session opened with the old password
+func updatePassword(ctx context.Context, tx *sqlx.Tx, userId, password string) error {
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE "users"
+	SET "password" = $2
+	WHERE "id" = $1;`, userId, password); err != nil {
+		return fmt.Errorf("update password failed: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	DELETE FROM "oauth"
+	WHERE "user_id" = $1;`, userId); err != nil {
+		return fmt.Errorf("revoke sessions failed: %v", err)
+	}
+	return nil
+}
+
+func (r *usersRepository) FindMfa(userId string) (*users.UserMfa, error) {
+	query := `
+	SELECT
+		"user_id",
+		"secret",
+		"enabled_at",
+		"last_step"
+	FROM "user_mfa"
+	WHERE "user_id" = $1;`
+
+	mfa := new(users.UserMfa)
+	if err := r.db.Get(mfa, query, userId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, users.ErrMfaNotEnabled
+		}
+		return nil, fmt.Errorf("get mfa failed: %v", err)
+	}
+	return mfa, nil
+}
+
+// UpsertMfa starts over a pending enrollment with a new secret, an enabled one is left alone
+func (r *usersRepository) UpsertMfa(userId, secret string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	INSERT INTO "user_mfa" ("user_id", "secret")
+	VALUES ($1, $2)
+	ON CONFLICT ("user_id") DO UPDATE
+	SET "secret" = EXCLUDED."secret",
+		"last_step" = 0
+	WHERE "user_mfa"."enabled_at" IS NULL;`
+
+	result, err := r.db.ExecContext(ctx, query, userId, secret)
+	if err != nil {
+		return fmt.Errorf("insert mfa failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("two-factor authentication is already enabled")
+	}
+	return nil
+}
+
+// EnableMfa turns on a pending enrollment, replaces the recovery codes and marks the session that
+// activated it in one transaction, so the codes are never stored without being handed out
+func (r *usersRepository) EnableMfa(userId, accessJti string, step int64, recoveryCodeHashes []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+	UPDATE "user_mfa"
+	SET "enabled_at" = now(),
+		"last_step" = $2
+	WHERE "user_id" = $1
+	AND "enabled_at" IS NULL
+	AND "last_step" < $2;`, userId, step)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("enable mfa failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("two-factor authentication is already enabled")
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	DELETE FROM "mfa_recovery_codes"
+	WHERE "user_id" = $1;`, userId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("delete recovery codes failed: %v", err)
+	}
+
+	for _, codeHash := range recoveryCodeHashes {
+		if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "mfa_recovery_codes" ("user_id", "code_hash")
+		VALUES ($1, $2);`, userId, codeHash); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("insert recovery code failed: %v", err)
+		}
+	}
+
+	result, err = tx.ExecContext(ctx, `
+	UPDATE "oauth"
+	SET "mfa_verified_at" = now()
+	WHERE "user_id" = $1
+	AND "access_jti" = $2;`, userId, accessJti)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update session mfa failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("session not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit mfa failed: %v", err)
+	}
+	return nil
+}
+
+func (r *usersRepository) DeleteMfa(userId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	DELETE FROM "mfa_recovery_codes"
+	WHERE "user_id" = $1;`, userId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("delete recovery codes failed: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	DELETE FROM "user_mfa"
+	WHERE "user_id" = $1;`, userId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("delete mfa failed: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE "oauth"
+	SET "mfa_verified_at" = NULL
+	WHERE "user_id" = $1;`, userId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("reset session mfa failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit mfa failed: %v", err)
+	}
+	return nil
+}
+
+// UseTotpStep moves the last accepted step forward, a code can never be accepted twice
+func (r *usersRepository) UseTotpStep(userId string, step int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	UPDATE "user_mfa"
+	SET "last_step" = $2
+	WHERE "user_id" = $1
+	AND "last_step" < $2;`
+
+	result, err := r.db.ExecContext(ctx, query, userId, step)
+	if err != nil {
+		return fmt.Errorf("use totp code failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("code already used")
+	}
+	return nil
+}
+
+func (r *usersRepository) UseRecoveryCode(userId, codeHash string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	UPDATE "mfa_recovery_codes"
+	SET "used_at" = now()
+	WHERE "user_id" = $1
+	AND "code_hash" = $2
+	AND "used_at" IS NULL;`
+
+	result, err := r.db.ExecContext(ctx, query, userId, codeHash)
+	if err != nil {
+		return fmt.Errorf("use recovery code failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return users.ErrInvalidMfaCode
+	}
+	return nil
+}
+
+// MarkSessionMfa records a second factor on the session, RequireStepUp reads it back
+func (r *usersRepository) MarkSessionMfa(userId, accessJti string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	UPDATE "oauth"
+	SET "mfa_verified_at" = now()
+	WHERE "user_id" = $1
+	AND "access_jti" = $2;`
+
+	result, err := r.db.ExecContext(ctx, query, userId, accessJti)
+	if err != nil {
+		return fmt.Errorf("update session mfa failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("session not found")
+	}
+	return nil
+}
+
+// FindLoginLockout returns how long the longer of the username and ip lockouts still runs, zero when neither is locked
+func (r *usersRepository) FindLoginLockout(username, ip string) (time.Duration, error) {
+	query := `
+	SELECT
+		COALESCE(CEIL(EXTRACT(EPOCH FROM MAX("locked_until") - now())), 0)::int
+	FROM "login_throttles"
+	WHERE (
+		("scope" = $1 AND "key" = $2)
+		OR ("scope" = $3 AND "key" = $4)
+	)
+	AND "locked_until" > now();`
+
+	var seconds int
+	if err := r.db.Get(&seconds, query, users.ThrottleUsername, username, users.ThrottleIp, ip); err != nil {
+		return 0, fmt.Errorf("get login lockout failed: %v", err)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// RecordLoginFailure counts a failed sign in, the count starts over once the last failure is older than window
+func (r *usersRepository) RecordLoginFailure(scope, key string, window time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	INSERT INTO "login_throttles" ("scope", "key", "failures")
+	VALUES ($1, $2, 1)
+	ON CONFLICT ("scope", "key") DO UPDATE
+	SET "failures" = CASE
+			WHEN "login_throttles"."last_failed_at" < now() - make_interval(secs => $3) THEN 1
+			ELSE "login_throttles"."failures" + 1
+		END,
+		"last_failed_at" = now()
+	RETURNING "failures";`
+
+	var failures int
+	if err := r.db.GetContext(ctx, &failures, query, scope, key, window.Seconds()); err != nil {
+		return 0, fmt.Errorf("record login failure failed: %v", err)
+	}
+	return failures, nil
+}
+
+func (r *usersRepository) LockLogin(scope, key string, lockout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	UPDATE "login_throttles"
+	SET "locked_until" = now() + make_interval(secs => $3)
+	WHERE "scope" = $1
+	AND "key" = $2;`
+
+	if _, err := r.db.ExecContext(ctx, query, scope, key, lockout.Seconds()); err != nil {
+		return fmt.Errorf("lock login failed: %v", err)
+	}
+	return nil
+}
+
+func (r *usersRepository) DeleteLoginThrottle(scope, key string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	DELETE FROM "login_throttles"
+	WHERE "scope" = $1
+	AND "key" = $2;`
+
+	result, err := r.db.ExecContext(ctx, query, scope, key)
+	if err != nil {
+		return 0, fmt.Errorf("delete login throttle failed: %v", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows, nil
+}
+
+func (r *usersRepository) FindLoginThrottles() ([]*users.LoginThrottle, error) {
+	query := `
+	SELECT
+		"scope",
+		"key",
+		"failures",
+		"locked_until",
+		"last_failed_at"
+	FROM "login_throttles"
+	ORDER BY "locked_until" DESC NULLS LAST, "last_failed_at" DESC
+	LIMIT 100;`
+
+	throttles := make([]*users.LoginThrottle, 0)
+	if err := r.db.Select(&throttles, query); err != nil {
+		return nil, fmt.Errorf("get login throttles failed: %v", err)
+	}
+	return throttles, nil
+}
+
+// DeleteStaleLoginThrottles drops counters that have gone quiet and are no longer locked
+func (r *usersRepository) DeleteStaleLoginThrottles(window time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	DELETE FROM "login_throttles"
+	WHERE "last_failed_at" < now() - make_interval(secs => $1)
+	AND ("locked_until" IS NULL OR "locked_until" < now());`
+
+	result, err := r.db.ExecContext(ctx, query, window.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("delete login throttles failed: %v", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows, nil
+}
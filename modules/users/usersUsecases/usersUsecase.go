@@ -1,33 +1,87 @@
 package usersUsecases
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/middlewares"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/users"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/users/usersRepositories"
 	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftauth"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftcache"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nfteth"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nftmailer"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nfttotp"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type IUsersUsecase interface {
 	InsertCustomer(req *users.UserRegisterReq) (*users.UserPassport, error)
-	InsertAdmin(req *users.UserRegisterReq) (*users.UserPassport, error)
+	InsertAdmin(req *users.UserRegisterReq, adminTokenJti, consumedBy string) (*users.UserPassport, error)
 	GetPassport(req *users.UserCredential) (*users.UserPassport, error)
 	RefreshPassport(req *users.UserRefreshCredential) (*users.UserPassport, error)
-	DeleteOauth(oauthId string) error
+	DeleteOauth(userId, oauthId string) error
+	FindSessions(userId, accessJti string) ([]*users.Session, error)
+	DeleteOtherOauth(userId, accessJti string) (int64, error)
+	VerifyEmail(token string) error
+	ResendVerification(userId string) error
+	ForgotPassword(req *users.ForgotPasswordReq)
+	ResetPassword(req *users.ResetPasswordReq) error
+	ChangePassword(req *users.ChangePasswordReq) error
+	GenerateAdminToken(userId string) (string, error)
+	FindAdminTokens() ([]*users.AdminToken, error)
 	GetUserProfile(userId string) (*users.User, error)
+	IssueSiweNonce() (*users.SiweNonce, error)
+	SiweSignIn(req *users.SiweSignInReq) (*users.UserPassport, error)
+	IssueAddressChallenge(req *users.AddressChallengeReq) (*users.AddressChallenge, error)
+	VerifyAddress(req *users.AddressVerifyReq) ([]*users.UserAddress, error)
+	SignInMfa(req *users.MfaSignInReq) (*users.UserPassport, error)
+	EnrollMfa(userId string) (*users.MfaEnrollment, error)
+	ActivateMfa(req *users.MfaCodeReq) (*users.MfaRecoveryCodes, error)
+	DisableMfa(req *users.MfaCodeReq) error
+	StepUp(req *users.MfaCodeReq) error
+	FindLoginThrottles() ([]*users.LoginThrottle, error)
+	UnlockLogin(req *users.UnlockLoginReq) (int64, error)
+	CleanupLoginThrottles() error
 }
 
+const (
+	addressChallengeExpires  = time.Minute * 10
+	siweNonceExpires         = time.Minute * 10
+	emailVerificationExpires = time.Hour * 24
+	passwordResetExpires     = time.Minute * 30
+	mfaChallengeExpires      = time.Minute * 5
+	mfaRecoveryCodes         = 10
+)
+
+// sign in throttling, one ip is allowed more failures than one username since many users can share it
+const (
+	usernameLockoutThreshold = 5
+	ipLockoutThreshold       = 20
+	loginLockoutBase         = time.Second * 30
+	loginLockoutMax          = time.Hour
+	loginFailureWindow       = time.Hour * 24
+)
+
 type usersUsecase struct {
 	cfg             config.IConfig
 	usersRepository usersRepositories.IUsersRepository
+	cache           nftcache.INftCache
+	mailer          nftmailer.INftMailer
 }
 
-func UsersUsecase(cfg config.IConfig, usersRepository usersRepositories.IUsersRepository) IUsersUsecase {
+func UsersUsecase(cfg config.IConfig, usersRepository usersRepositories.IUsersRepository, cache nftcache.INftCache, mailer nftmailer.INftMailer) IUsersUsecase {
 	return &usersUsecase{
 		cfg:             cfg,
 		usersRepository: usersRepository,
+		cache:           cache,
+		mailer:          mailer,
 	}
 }
 
@@ -41,16 +95,56 @@ func (u *usersUsecase) InsertCustomer(req *users.UserRegisterReq) (*users.UserPa
 	if err != nil {
 		return nil, err
 	}
+
+	// the account exists either way, a lost email can be sent again
+	if err := u.sendVerification(result.User); err != nil {
+		log.Printf("send verification email to user %s failed: %v", result.User.Id, err)
+	}
 	return result, err
 }
 
-func (u *usersUsecase) InsertAdmin(req *users.UserRegisterReq) (*users.UserPassport, error) {
+func (u *usersUsecase) sendVerification(user *users.User) error {
+	token := nftauth.NewEmailToken(u.cfg.Jwt(), user.Id, user.Email, emailVerificationExpires)
+	link := fmt.Sprintf("https://%s/v1/users/verify-email?token=%s", u.cfg.App().Domain(), url.QueryEscape(token))
+
+	return u.mailer.Send(&nftmailer.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("Verify your %s account", u.cfg.App().Name()),
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nOpen the link below to verify your email address, it expires in %v.\n\n%s\n",
+			user.Username,
+			emailVerificationExpires,
+			link,
+		),
+	})
+}
+
+func (u *usersUsecase) VerifyEmail(token string) error {
+	claims, err := nftauth.ParseEmailToken(u.cfg.Jwt(), token)
+	if err != nil {
+		return err
+	}
+	return u.usersRepository.VerifyEmail(claims.UserId, claims.Email)
+}
+
+func (u *usersUsecase) ResendVerification(userId string) error {
+	profile, err := u.usersRepository.GetProfile(userId)
+	if err != nil {
+		return err
+	}
+	if profile.Verified {
+		return fmt.Errorf("account is already verified")
+	}
+	return u.sendVerification(profile)
+}
+
+func (u *usersUsecase) InsertAdmin(req *users.UserRegisterReq, adminTokenJti, consumedBy string) (*users.UserPassport, error) {
 	//hashing password
 	if err := req.BcryptHashing(); err != nil {
 		return nil, err
 	}
-	//inserting user
-	result, err := u.usersRepository.InsertUser(req, true)
+	//inserting user, the admin token is spent in the same transaction
+	result, err := u.usersRepository.InsertAdmin(req, adminTokenJti, consumedBy)
 	if err != nil {
 		return nil, err
 	}
@@ -58,17 +152,99 @@ func (u *usersUsecase) InsertAdmin(req *users.UserRegisterReq) (*users.UserPassp
 }
 
 func (u *usersUsecase) GetPassport(req *users.UserCredential) (*users.UserPassport, error) {
+	if err := u.checkLoginLockout(req.Username, req.Client.Ip); err != nil {
+		return nil, err
+	}
+
 	// finding user
 	user, err := u.usersRepository.FindOneUserByUsername(req.Username)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			u.recordLoginFailure(req.Username, req.Client.Ip)
+		}
 		return nil, err
 	}
 
 	//compare password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		u.recordLoginFailure(req.Username, req.Client.Ip)
 		return nil, fmt.Errorf("invalid password")
 	}
+	u.clearLoginFailures(user.Username)
+
+	return u.passportOrChallenge(user, &req.Client)
+}
+
+// checkLoginLockout refuses a sign in while the username or the client ip is locked out,
+// before the password is looked at so a locked account cannot be probed
+func (u *usersUsecase) checkLoginLockout(username, ip string) error {
+	lockout, err := u.usersRepository.FindLoginLockout(username, ip)
+	if err != nil {
+		return err
+	}
+	if lockout > 0 {
+		return &users.LockoutError{RetryAfter: lockout}
+	}
+	return nil
+}
+
+// recordLoginFailure only logs its own errors, the caller still answers with the sign in failure
+func (u *usersUsecase) recordLoginFailure(username, ip string) {
+	for _, throttle := range []struct {
+		scope     string
+		key       string
+		threshold int
+	}{
+		{users.ThrottleUsername, username, usernameLockoutThreshold},
+		{users.ThrottleIp, ip, ipLockoutThreshold},
+	} {
+		if throttle.key == "" {
+			continue
+		}
+		failures, err := u.usersRepository.RecordLoginFailure(throttle.scope, throttle.key, loginFailureWindow)
+		if err != nil {
+			log.Printf("record login failure of %s %s failed: %v", throttle.scope, throttle.key, err)
+			continue
+		}
+		lockout := users.LockoutDuration(failures, throttle.threshold, loginLockoutBase, loginLockoutMax)
+		if lockout == 0 {
+			continue
+		}
+		if err := u.usersRepository.LockLogin(throttle.scope, throttle.key, lockout); err != nil {
+			log.Printf("lock login of %s %s failed: %v", throttle.scope, throttle.key, err)
+		}
+	}
+}
 
+// clearLoginFailures forgets the failures of a username after a correct password, the ip keeps its count
+func (u *usersUsecase) clearLoginFailures(username string) {
+	if _, err := u.usersRepository.DeleteLoginThrottle(users.ThrottleUsername, username); err != nil {
+		log.Printf("clear login failures of %s failed: %v", username, err)
+	}
+}
+
+// passportOrChallenge holds back the tokens of an account with two-factor authentication,
+// the challenge token is exchanged for them by SignInMfa
+func (u *usersUsecase) passportOrChallenge(user *users.UserCredentialCheck, client *users.SessionClient) (*users.UserPassport, error) {
+	mfa, err := u.usersRepository.FindMfa(user.Id)
+	if err != nil && !errors.Is(err, users.ErrMfaNotEnabled) {
+		return nil, err
+	}
+	if mfa == nil || mfa.EnabledAt == nil {
+		return u.issuePassport(user, client)
+	}
+
+	token, expiresAt := nftauth.NewMfaToken(u.cfg.Jwt(), user.Id, mfaChallengeExpires)
+	return &users.UserPassport{
+		MfaChallenge: &users.MfaChallenge{
+			Token:     token,
+			ExpiresAt: expiresAt,
+		},
+	}, nil
+}
+
+// issuePassport signs a new access/refresh pair for an authenticated user and stores it as an oauth session
+func (u *usersUsecase) issuePassport(user *users.UserCredentialCheck, client *users.SessionClient) (*users.UserPassport, error) {
 	accessToken, _ := nftauth.NewAuth(nftauth.Access, u.cfg.Jwt(), &users.UserClaims{
 		Id:     user.Id,
 		RoleId: user.RoleId,
@@ -86,30 +262,41 @@ func (u *usersUsecase) GetPassport(req *users.UserCredential) (*users.UserPasspo
 			Email:    user.Email,
 			Username: user.Username,
 			RoleId:   user.RoleId,
+			Verified: user.Verified,
 		},
 		Token: &users.UserToken{
 			AccessToken:  accessToken.SignToken(),
 			RefreshToken: refreshToken.SignToken(),
+			AccessJti:    accessToken.Jti(),
+			RefreshJti:   refreshToken.Jti(),
 		},
 	}
 
-	if err := u.usersRepository.InsertOAuth(passport); err != nil {
+	if err := u.usersRepository.InsertOAuth(passport, client); err != nil {
 		return nil, err
 	}
 
 	return passport, nil
 }
 
+// RefreshPassport rotates the refresh token of the session, presenting a token that was already
+// rotated revokes the whole session since either the holder or a thief is replaying it
 func (u *usersUsecase) RefreshPassport(req *users.UserRefreshCredential) (*users.UserPassport, error) {
 	// parse token
 	claims, err := nftauth.ParseToken(u.cfg.Jwt(), req.RefreshToken)
 	if err != nil {
 		return nil, err
 	}
+	if claims.Subject != "refresh-token" {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
 
 	//check Oauth
 	oauth, err := u.usersRepository.FindOneOAuth(req.RefreshToken)
 	if err != nil {
+		if rotated, rotatedErr := u.usersRepository.FindRotatedOAuth(req.RefreshToken); rotatedErr == nil {
+			return nil, u.revokeFamily(rotated)
+		}
 		return nil, err
 	}
 
@@ -144,26 +331,491 @@ func (u *usersUsecase) RefreshPassport(req *users.UserRefreshCredential) (*users
 		Token: &users.UserToken{
 			Id:           oauth.Id,
 			AccessToken:  accessToken.SignToken(),
-			RefreshToken: refreshToken,
+			RefreshToken: refreshToken.SignToken(),
+			AccessJti:    accessToken.Jti(),
+			RefreshJti:   refreshToken.Jti(),
 		},
 	}
-	if err := u.usersRepository.UpdateOneOAuth(passport.Token); err != nil {
+	if err := u.usersRepository.RotateOAuth(passport.Token, req.RefreshToken); err != nil {
+		if errors.Is(err, users.ErrRefreshReused) {
+			return nil, u.revokeFamily(oauth)
+		}
 		return nil, err
 	}
+	// the replaced access token must stop passing JwtAuth right away
+	u.cache.DeletePrefix(middlewares.UserTokensCachePrefix(oauth.UserId))
 	return passport, nil
 }
 
-func (u *usersUsecase) DeleteOauth(oauthId string) error {
-	if err := u.usersRepository.DeleteOauth(oauthId); err != nil {
+// revokeFamily ends the session a reused refresh token belongs to
+func (u *usersUsecase) revokeFamily(oauth *users.Oauth) error {
+	if err := u.usersRepository.DeleteOauth(oauth.UserId, oauth.Id); err != nil {
+		return err
+	}
+	u.cache.DeletePrefix(middlewares.UserTokensCachePrefix(oauth.UserId))
+	return users.ErrRefreshReused
+}
+
+func (u *usersUsecase) DeleteOauth(userId, oauthId string) error {
+	if err := u.usersRepository.DeleteOauth(userId, oauthId); err != nil {
 		return err
 	}
+	u.cache.DeletePrefix(middlewares.UserTokensCachePrefix(userId))
 	return nil
 }
 
+// FindSessions flags the session the request is authenticated with
+func (u *usersUsecase) FindSessions(userId, accessJti string) ([]*users.Session, error) {
+	sessions, err := u.usersRepository.FindSessions(userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		session.Current = session.AccessJti == accessJti
+	}
+	return sessions, nil
+}
+
+func (u *usersUsecase) DeleteOtherOauth(userId, accessJti string) (int64, error) {
+	revoked, err := u.usersRepository.DeleteOtherOauth(userId, accessJti)
+	if err != nil {
+		return 0, err
+	}
+	u.cache.DeletePrefix(middlewares.UserTokensCachePrefix(userId))
+	return revoked, nil
+}
+
 func (u *usersUsecase) GetUserProfile(userId string) (*users.User, error) {
 	profile, err := u.usersRepository.GetProfile(userId)
 	if err != nil {
 		return nil, err
 	}
+
+	addresses, err := u.usersRepository.FindUserAddresses(userId)
+	if err != nil {
+		return nil, err
+	}
+	profile.Addresses = addresses
 	return profile, nil
 }
+
+func (u *usersUsecase) IssueAddressChallenge(req *users.AddressChallengeReq) (*users.AddressChallenge, error) {
+	address, err := nfteth.NormalizeAddress(req.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := users.NewNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	challenge := &users.AddressChallenge{
+		UserId:    req.UserId,
+		Address:   address,
+		Nonce:     nonce,
+		ExpiresAt: now.Add(addressChallengeExpires),
+	}
+	challenge.BuildMessage(u.cfg.App().Name(), now)
+
+	if err := u.usersRepository.InsertAddressChallenge(challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+func (u *usersUsecase) VerifyAddress(req *users.AddressVerifyReq) ([]*users.UserAddress, error) {
+	challenge, err := u.usersRepository.FindAddressChallenge(req.UserId, req.ChallengeId)
+	if err != nil {
+		return nil, fmt.Errorf("challenge not found")
+	}
+	if challenge.UsedAt != nil || !challenge.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("challenge is no longer valid")
+	}
+
+	if err := nfteth.VerifyPersonalSign(challenge.Address, challenge.Message, req.Signature); err != nil {
+		return nil, fmt.Errorf("invalid signature: %v", err)
+	}
+
+	if err := u.usersRepository.InsertUserAddress(challenge); err != nil {
+		return nil, err
+	}
+	return u.usersRepository.FindUserAddresses(req.UserId)
+}
+
+func (u *usersUsecase) IssueSiweNonce() (*users.SiweNonce, error) {
+	nonce, err := users.NewNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &users.SiweNonce{
+		Nonce:     nonce,
+		ExpiresAt: time.Now().Add(siweNonceExpires),
+	}
+	if err := u.usersRepository.InsertSiweNonce(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// SiweSignIn authenticates an EIP-4361 message signed by the wallet, the nonce is spent
+// only after the signature checks out so a forged message cannot burn it
+func (u *usersUsecase) SiweSignIn(req *users.SiweSignInReq) (*users.UserPassport, error) {
+	msg, err := nfteth.ParseSiweMessage(req.Message)
+	if err != nil {
+		return nil, err
+	}
+	if err := msg.Validate(u.cfg.App().Domain(), u.cfg.App().ChainId(), time.Now()); err != nil {
+		return nil, err
+	}
+	if err := nfteth.VerifyPersonalSign(msg.Address, req.Message, req.Signature); err != nil {
+		return nil, fmt.Errorf("invalid signature: %v", err)
+	}
+
+	if err := u.usersRepository.UseSiweNonce(msg.Nonce); err != nil {
+		return nil, err
+	}
+
+	user, err := u.usersRepository.FindOrInsertUserByAddress(msg.Address)
+	if err != nil {
+		return nil, err
+	}
+	return u.passportOrChallenge(user, &req.Client)
+}
+
+// GenerateAdminToken records the jti before handing the token out, AdminTokenAuth only spends recorded tokens
+func (u *usersUsecase) GenerateAdminToken(userId string) (string, error) {
+	adminToken, err := nftauth.NewAuth(
+		nftauth.Admin,
+		u.cfg.Jwt(),
+		nil,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	if err := u.usersRepository.InsertAdminToken(&users.AdminToken{
+		Jti:       adminToken.Jti(),
+		MintedBy:  userId,
+		ExpiresAt: adminToken.ExpiresAt(),
+	}); err != nil {
+		return "", err
+	}
+	return adminToken.SignToken(), nil
+}
+
+func (u *usersUsecase) FindAdminTokens() ([]*users.AdminToken, error) {
+	tokens, err := u.usersRepository.FindAdminTokens()
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// ForgotPassword answers the same whether or not the email is known, so it cannot be used to probe accounts.
+// The lookup and the email run in the background, neither their time nor their errors reach the caller
+func (u *usersUsecase) ForgotPassword(req *users.ForgotPasswordReq) {
+	email := strings.TrimSpace(req.Email)
+	go func() {
+		if err := u.sendPasswordReset(email); err != nil {
+			log.Printf("send password reset failed: %v", err)
+		}
+	}()
+}
+
+func (u *usersUsecase) sendPasswordReset(email string) error {
+	user, err := u.usersRepository.FindOneUserByEmail(email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := users.NewNonce()
+	if err != nil {
+		return err
+	}
+	if err := u.usersRepository.InsertPasswordReset(&users.PasswordReset{
+		UserId:    user.Id,
+		TokenHash: nftauth.HashToken(token),
+		ExpiresAt: time.Now().Add(passwordResetExpires),
+	}); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("https://%s/reset-password?token=%s", u.cfg.App().Domain(), token)
+	return u.mailer.Send(&nftmailer.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("Reset your %s password", u.cfg.App().Name()),
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nOpen the link below to choose a new password, it expires in %v and works once.\n\n%s\n\nIf you did not ask for this you can ignore this email.\n",
+			user.Username,
+			passwordResetExpires,
+			link,
+		),
+	})
+}
+
+func (u *usersUsecase) ResetPassword(req *users.ResetPasswordReq) error {
+	if err := users.ValidatePassword(req.Password); err != nil {
+		return err
+	}
+
+	hashed := &users.UserRegisterReq{Password: req.Password}
+	if err := hashed.BcryptHashing(); err != nil {
+		return err
+	}
+
+	userId, err := u.usersRepository.ResetPassword(nftauth.HashToken(req.Token), hashed.Password)
+	if err != nil {
+		return err
+	}
+	u.cache.DeletePrefix(middlewares.UserTokensCachePrefix(userId))
+	return nil
+}
+
+func (u *usersUsecase) ChangePassword(req *users.ChangePasswordReq) error {
+	user, err := u.usersRepository.FindOneUserById(req.UserId)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.OldPassword)); err != nil {
+		return fmt.Errorf("invalid password")
+	}
+	if err := users.ValidatePassword(req.NewPassword); err != nil {
+		return err
+	}
+
+	hashed := &users.UserRegisterReq{Password: req.NewPassword}
+	if err := hashed.BcryptHashing(); err != nil {
+		return err
+	}
+
+	if err := u.usersRepository.UpdatePassword(req.UserId, hashed.Password); err != nil {
+		return err
+	}
+	u.cache.DeletePrefix(middlewares.UserTokensCachePrefix(req.UserId))
+	return nil
+}
+
+// enabledMfa returns the second factor of the user, a pending enrollment does not count
+func (u *usersUsecase) enabledMfa(userId string) (*users.UserMfa, error) {
+	mfa, err := u.usersRepository.FindMfa(userId)
+	if err != nil {
+		return nil, err
+	}
+	if mfa.EnabledAt == nil {
+		return nil, users.ErrMfaNotEnabled
+	}
+	return mfa, nil
+}
+
+// verifyMfa accepts a totp code or an unused recovery code
+func (u *usersUsecase) verifyMfa(mfa *users.UserMfa, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == nfttotp.Digits {
+		step, ok := nfttotp.Validate(mfa.Secret, code, time.Now())
+		if !ok {
+			return users.ErrInvalidMfaCode
+		}
+		return u.usersRepository.UseTotpStep(mfa.UserId, step)
+	}
+	return u.usersRepository.UseRecoveryCode(mfa.UserId, nftauth.HashToken(users.NormalizeRecoveryCode(code)))
+}
+
+// throttledMfa runs verify under the sign in lockout of the user and the client ip,
+// a stolen access token must not be enough to guess the second factor
+func (u *usersUsecase) throttledMfa(userId, ip string, verify func() error) error {
+	user, err := u.usersRepository.FindOneUserById(userId)
+	if err != nil {
+		return err
+	}
+	if err := u.checkLoginLockout(user.Username, ip); err != nil {
+		return err
+	}
+	if err := verify(); err != nil {
+		if errors.Is(err, users.ErrInvalidMfaCode) {
+			u.recordLoginFailure(user.Username, ip)
+		}
+		return err
+	}
+	u.clearLoginFailures(user.Username)
+	return nil
+}
+
+// SignInMfa finishes a sign in that returned a challenge, the session starts out step-up verified
+func (u *usersUsecase) SignInMfa(req *users.MfaSignInReq) (*users.UserPassport, error) {
+	claims, err := nftauth.ParseMfaToken(u.cfg.Jwt(), req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := u.usersRepository.FindOneUserById(claims.UserId)
+	if err != nil {
+		return nil, err
+	}
+	// guessing codes within a challenge counts the same as guessing passwords
+	if err := u.checkLoginLockout(user.Username, req.Client.Ip); err != nil {
+		return nil, err
+	}
+
+	mfa, err := u.enabledMfa(claims.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.verifyMfa(mfa, req.Code); err != nil {
+		if errors.Is(err, users.ErrInvalidMfaCode) {
+			u.recordLoginFailure(user.Username, req.Client.Ip)
+		}
+		return nil, err
+	}
+	u.clearLoginFailures(user.Username)
+
+	passport, err := u.issuePassport(user, &req.Client)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.usersRepository.MarkSessionMfa(user.Id, passport.Token.AccessJti); err != nil {
+		return nil, err
+	}
+	return passport, nil
+}
+
+// EnrollMfa hands out a new secret, it only takes effect once ActivateMfa confirms a code from it
+func (u *usersUsecase) EnrollMfa(userId string) (*users.MfaEnrollment, error) {
+	profile, err := u.usersRepository.GetProfile(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := nfttotp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.usersRepository.UpsertMfa(userId, secret); err != nil {
+		return nil, err
+	}
+
+	account := profile.Email
+	if account == "" {
+		account = profile.Username
+	}
+	return &users.MfaEnrollment{
+		Secret:          secret,
+		ProvisioningUri: nfttotp.ProvisioningURI(u.cfg.App().Name(), account, secret),
+	}, nil
+}
+
+// ActivateMfa returns the recovery codes in plain text, this is the only time they can be read
+func (u *usersUsecase) ActivateMfa(req *users.MfaCodeReq) (*users.MfaRecoveryCodes, error) {
+	mfa, err := u.usersRepository.FindMfa(req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if mfa.EnabledAt != nil {
+		return nil, fmt.Errorf("two-factor authentication is already enabled")
+	}
+
+	var step int64
+	if err := u.throttledMfa(req.UserId, req.Client.Ip, func() error {
+		var ok bool
+		step, ok = nfttotp.Validate(mfa.Secret, strings.TrimSpace(req.Code), time.Now())
+		if !ok {
+			return users.ErrInvalidMfaCode
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, mfaRecoveryCodes)
+	hashes := make([]string, 0, mfaRecoveryCodes)
+	for i := 0; i < mfaRecoveryCodes; i++ {
+		code, err := users.NewRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, nftauth.HashToken(users.NormalizeRecoveryCode(code)))
+	}
+
+	// the session that activates mfa counts as stepped up
+	if err := u.usersRepository.EnableMfa(req.UserId, req.AccessJti, step, hashes); err != nil {
+		return nil, err
+	}
+	return &users.MfaRecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+func (u *usersUsecase) DisableMfa(req *users.MfaCodeReq) error {
+	mfa, err := u.enabledMfa(req.UserId)
+	if err != nil {
+		return err
+	}
+	if err := u.throttledMfa(req.UserId, req.Client.Ip, func() error {
+		return u.verifyMfa(mfa, req.Code)
+	}); err != nil {
+		return err
+	}
+	return u.usersRepository.DeleteMfa(req.UserId)
+}
+
+// StepUp re-verifies the second factor on the current session before a sensitive action
+func (u *usersUsecase) StepUp(req *users.MfaCodeReq) error {
+	mfa, err := u.enabledMfa(req.UserId)
+	if err != nil {
+		return err
+	}
+	if err := u.throttledMfa(req.UserId, req.Client.Ip, func() error {
+		return u.verifyMfa(mfa, req.Code)
+	}); err != nil {
+		return err
+	}
+	return u.usersRepository.MarkSessionMfa(req.UserId, req.AccessJti)
+}
+
+func (u *usersUsecase) FindLoginThrottles() ([]*users.LoginThrottle, error) {
+	return u.usersRepository.FindLoginThrottles()
+}
+
+// UnlockLogin clears the failures and lockout of a username, an ip or both
+func (u *usersUsecase) UnlockLogin(req *users.UnlockLoginReq) (int64, error) {
+	username := strings.TrimSpace(req.Username)
+	ip := strings.TrimSpace(req.Ip)
+	if username == "" && ip == "" {
+		return 0, fmt.Errorf("username or ip is required")
+	}
+
+	var unlocked int64
+	if username != "" {
+		rows, err := u.usersRepository.DeleteLoginThrottle(users.ThrottleUsername, username)
+		if err != nil {
+			return 0, err
+		}
+		unlocked += rows
+	}
+	if ip != "" {
+		rows, err := u.usersRepository.DeleteLoginThrottle(users.ThrottleIp, ip)
+		if err != nil {
+			return 0, err
+		}
+		unlocked += rows
+	}
+	if unlocked == 0 {
+		return 0, fmt.Errorf("login throttle not found")
+	}
+	return unlocked, nil
+}
+
+func (u *usersUsecase) CleanupLoginThrottles() error {
+	rows, err := u.usersRepository.DeleteStaleLoginThrottles(loginFailureWindow)
+	if err != nil {
+		return err
+	}
+	if rows > 0 {
+		log.Printf("%d login throttles removed", rows)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package users
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	const (
+		threshold = 5
+		base      = 30 * time.Second
+		max       = time.Hour
+	)
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, 30 * time.Second},
+		{6, time.Minute},
+		{7, 2 * time.Minute},
+		{11, 32 * time.Minute},
+		{12, time.Hour},
+		{13, time.Hour},
+		{1000, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := LockoutDuration(tt.failures, threshold, base, max); got != tt.want {
+			t.Errorf("LockoutDuration(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
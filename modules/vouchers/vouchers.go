@@ -0,0 +1,112 @@
+package vouchers
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/files"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/nfts"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nfteth"
+)
+
+type VoucherStatus string
+
+const (
+	Unsigned  VoucherStatus = "unsigned"
+	Signed    VoucherStatus = "signed"
+	Redeemed  VoucherStatus = "redeemed"
+	Cancelled VoucherStatus = "cancelled"
+)
+
+const VoucherPrimaryType = "NFTVoucher"
+
+// voucherTypes is what the creator signs, the price is in cents so it stays an integer on chain
+var voucherTypes = map[string][]*nfteth.TypedDataField{
+	VoucherPrimaryType: {
+		{Name: "tokenId", Type: "uint256"},
+		{Name: "price", Type: "uint256"},
+		{Name: "uri", Type: "string"},
+	},
+}
+
+type Voucher struct {
+	Id           string            `json:"id" db:"id"`
+	TokenId      int64             `json:"token_id" db:"token_id"`
+	CreatorId    string            `json:"creator_id" db:"creator_id"`
+	CollectionId string            `json:"collection_id" db:"collection_id"`
+	Title        string            `json:"title" db:"title"`
+	Description  string            `json:"description" db:"description"`
+	Metadata     *nfts.NftMetadata `json:"metadata" db:"metadata"`
+	Media        []*files.FileRes  `json:"media" db:"media"`
+	RoyaltyBps   int               `json:"royalty_bps" db:"royalty_bps"`
+	Price        float64           `json:"price" db:"price"`
+	Uri          string            `json:"uri" db:"uri"`
+	Signer       string            `json:"signer" db:"signer"`
+	Signature    string            `json:"signature" db:"signature"`
+	Status       VoucherStatus     `json:"status" db:"status"`
+	BuyerId      string            `json:"buyer_id" db:"buyer_id"`
+	NftId        string            `json:"nft_id" db:"nft_id"`
+	RedeemedAt   string            `json:"redeemed_at" db:"redeemed_at"`
+	CreatedAt    string            `json:"created_at" db:"created_at"`
+	UpdatedAt    string            `json:"updated_at" db:"update_at"`
+	// Domain is stored with the signature, nil until the voucher is signed
+	Domain *VoucherDomain `json:"domain" db:"domain"`
+	// TypedData is the eth_signTypedData_v4 payload, set by the usecase
+	TypedData *nfteth.TypedData `json:"typed_data" db:"-"`
+}
+
+// BuildTypedData binds the voucher's token id, price and uri to the marketplace domain
+func (obj *Voucher) BuildTypedData(domain *nfteth.TypedDataDomain) *nfteth.TypedData {
+	return nfteth.NewTypedData(domain, VoucherPrimaryType, voucherTypes, map[string]any{
+		"tokenId": strconv.FormatInt(obj.TokenId, 10),
+		"price":   strconv.FormatInt(int64(math.Round(obj.Price*100)), 10),
+		"uri":     obj.Uri,
+	})
+}
+
+// VoucherDomain is the EIP-712 domain a voucher was signed under
+type VoucherDomain struct {
+	Name              string `json:"name"`
+	Version           string `json:"version"`
+	ChainId           int64  `json:"chain_id"`
+	VerifyingContract string `json:"verifying_contract"`
+}
+
+func (obj *VoucherDomain) TypedDataDomain() *nfteth.TypedDataDomain {
+	return &nfteth.TypedDataDomain{
+		Name:              obj.Name,
+		Version:           obj.Version,
+		ChainId:           obj.ChainId,
+		VerifyingContract: obj.VerifyingContract,
+	}
+}
+
+type VoucherReq struct {
+	nfts.MintReq
+	TokenId int64   `json:"-"`
+	Price   float64 `json:"price" form:"price"`
+	// Uri defaults to the metadata endpoint of the token
+	Uri string `json:"uri" form:"uri"`
+}
+
+type SignReq struct {
+	VoucherId string                  `json:"-"`
+	CreatorId string                  `json:"-"`
+	Signer    string                  `json:"-"`
+	Domain    *nfteth.TypedDataDomain `json:"-"`
+	Signature string                  `json:"signature" form:"signature"`
+}
+
+type RedeemReq struct {
+	VoucherId      string `json:"-"`
+	BuyerId        string `json:"-"`
+	PlatformFeeBps int    `json:"-"`
+	// Verify checks the signature of the voucher row the redemption locked
+	Verify func(voucher *Voucher) error `json:"-"`
+}
+
+type VoucherFilter struct {
+	CreatorId    string `query:"creator_id"`
+	CollectionId string `query:"collection_id"`
+	Status       string `query:"status"`
+}
@@ -0,0 +1,307 @@
+package vouchersHandlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/entities"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/vouchers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/vouchers/vouchersUsecases"
+)
+
+type vouchersHandlersErrCode string
+
+const (
+	findOneVoucherErr vouchersHandlersErrCode = "vouchers-001"
+	findVouchersErr   vouchersHandlersErrCode = "vouchers-002"
+	insertVoucherErr  vouchersHandlersErrCode = "vouchers-003"
+	signVoucherErr    vouchersHandlersErrCode = "vouchers-004"
+	cancelVoucherErr  vouchersHandlersErrCode = "vouchers-005"
+	redeemVoucherErr  vouchersHandlersErrCode = "vouchers-006"
+)
+
+type IVouchersHandler interface {
+	FindOneVoucher(c *fiber.Ctx) error
+	FindVouchers(c *fiber.Ctx) error
+	InsertVoucher(c *fiber.Ctx) error
+	SignVoucher(c *fiber.Ctx) error
+	CancelVoucher(c *fiber.Ctx) error
+	RedeemVoucher(c *fiber.Ctx) error
+}
+
+type vouchersHandler struct {
+	cfg             config.IConfig
+	vouchersUsecase vouchersUsecases.IVouchersUsecase
+}
+
+func VouchersHandler(cfg config.IConfig, vouchersUsecase vouchersUsecases.IVouchersUsecase) IVouchersHandler {
+	return &vouchersHandler{
+		cfg:             cfg,
+		vouchersUsecase: vouchersUsecase,
+	}
+}
+
+func voucherIdParam(c *fiber.Ctx) (string, bool) {
+	voucherId := strings.Trim(c.Params("voucher_id"), " ")
+	if _, err := uuid.Parse(voucherId); err != nil {
+		return "", false
+	}
+	return voucherId, true
+}
+
+func (h *vouchersHandler) FindOneVoucher(c *fiber.Ctx) error {
+	voucherId, ok := voucherIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findOneVoucherErr),
+			"invalid voucher id",
+		).Res()
+	}
+
+	voucher, err := h.vouchersUsecase.FindOneVoucher(voucherId)
+	if err != nil {
+		switch err.Error() {
+		case "get voucher failed: sql: no rows in result set":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(findOneVoucherErr),
+				"voucher not found",
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findOneVoucherErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, voucher).Res()
+}
+
+func (h *vouchersHandler) FindVouchers(c *fiber.Ctx) error {
+	req := new(vouchers.VoucherFilter)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findVouchersErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.vouchersUsecase.FindVouchers(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findVouchersErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, result).Res()
+}
+
+func (h *vouchersHandler) InsertVoucher(c *fiber.Ctx) error {
+	req := new(vouchers.VoucherReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertVoucherErr),
+			err.Error(),
+		).Res()
+	}
+	req.CreatorId = c.Locals("userId").(string)
+
+	voucher, err := h.vouchersUsecase.InsertVoucher(req)
+	if err != nil {
+		switch err.Error() {
+		case "title is required",
+			"collection id is required",
+			"media is required",
+			"media must be uploaded files",
+			"price must be greater than zero",
+			"collection not found",
+			"collection is archived":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(insertVoucherErr),
+				err.Error(),
+			).Res()
+		case "collection belongs to another user":
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(insertVoucherErr),
+				err.Error(),
+			).Res()
+		default:
+			if strings.HasPrefix(err.Error(), "attribute") ||
+				strings.HasPrefix(err.Error(), "background color") ||
+				strings.HasPrefix(err.Error(), "royalty bps") {
+				return entities.NewResponse(c).Error(
+					fiber.ErrBadRequest.Code,
+					string(insertVoucherErr),
+					err.Error(),
+				).Res()
+			}
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(insertVoucherErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, voucher).Res()
+}
+
+func (h *vouchersHandler) SignVoucher(c *fiber.Ctx) error {
+	voucherId, ok := voucherIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(signVoucherErr),
+			"invalid voucher id",
+		).Res()
+	}
+
+	req := new(vouchers.SignReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(signVoucherErr),
+			err.Error(),
+		).Res()
+	}
+	req.VoucherId = voucherId
+	req.CreatorId = c.Locals("userId").(string)
+
+	voucher, err := h.vouchersUsecase.SignVoucher(req)
+	if err != nil {
+		switch err.Error() {
+		case "get voucher failed: sql: no rows in result set",
+			"voucher not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(signVoucherErr),
+				"voucher not found",
+			).Res()
+		case "voucher is already signed":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(signVoucherErr),
+				err.Error(),
+			).Res()
+		case "signer is not linked to the creator":
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(signVoucherErr),
+				err.Error(),
+			).Res()
+		default:
+			if strings.HasPrefix(err.Error(), "invalid signature") {
+				return entities.NewResponse(c).Error(
+					fiber.ErrBadRequest.Code,
+					string(signVoucherErr),
+					err.Error(),
+				).Res()
+			}
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(signVoucherErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, voucher).Res()
+}
+
+func (h *vouchersHandler) CancelVoucher(c *fiber.Ctx) error {
+	voucherId, ok := voucherIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(cancelVoucherErr),
+			"invalid voucher id",
+		).Res()
+	}
+
+	if err := h.vouchersUsecase.CancelVoucher(c.Locals("userId").(string), voucherId); err != nil {
+		switch err.Error() {
+		case "voucher not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(cancelVoucherErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(cancelVoucherErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, "voucher cancelled successfully").Res()
+}
+
+func (h *vouchersHandler) RedeemVoucher(c *fiber.Ctx) error {
+	voucherId, ok := voucherIdParam(c)
+	if !ok {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(redeemVoucherErr),
+			"invalid voucher id",
+		).Res()
+	}
+
+	sale, err := h.vouchersUsecase.RedeemVoucher(&vouchers.RedeemReq{
+		VoucherId: voucherId,
+		BuyerId:   c.Locals("userId").(string),
+	})
+	if err != nil {
+		switch err.Error() {
+		case "get voucher failed: sql: no rows in result set",
+			"voucher not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(redeemVoucherErr),
+				"voucher not found",
+			).Res()
+		case "voucher is not available",
+			"collection not found",
+			"collection is archived":
+			return entities.NewResponse(c).Error(
+				fiber.ErrConflict.Code,
+				string(redeemVoucherErr),
+				err.Error(),
+			).Res()
+		case "cannot redeem your own voucher":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(redeemVoucherErr),
+				err.Error(),
+			).Res()
+		case "insufficient funds":
+			return entities.NewResponse(c).Error(
+				fiber.ErrPaymentRequired.Code,
+				string(redeemVoucherErr),
+				err.Error(),
+			).Res()
+		default:
+			if strings.HasPrefix(err.Error(), "invalid signature") {
+				return entities.NewResponse(c).Error(
+					fiber.ErrConflict.Code,
+					string(redeemVoucherErr),
+					err.Error(),
+				).Res()
+			}
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(redeemVoucherErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, sale).Res()
+}
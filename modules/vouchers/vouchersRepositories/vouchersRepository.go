@@ -0,0 +1,429 @@
+package vouchersRepositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/collections"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/sales"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/sales/salesPatterns"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/vouchers"
+)
+
+type IVouchersRepository interface {
+	NextTokenId() (int64, error)
+	FindOneVoucher(voucherId string) (*vouchers.Voucher, error)
+	FindVouchers(req *vouchers.VoucherFilter) ([]*vouchers.Voucher, error)
+	IsUserAddress(userId, address string) (bool, error)
+	InsertVoucher(req *vouchers.VoucherReq) (*vouchers.Voucher, error)
+	SignVoucher(req *vouchers.SignReq) error
+	CancelVoucher(creatorId, voucherId string) error
+	RedeemVoucher(req *vouchers.RedeemReq) (*sales.Sale, error)
+}
+
+type vouchersRepository struct {
+	db *sqlx.DB
+}
+
+func VouchersRepository(db *sqlx.DB) IVouchersRepository {
+	return &vouchersRepository{
+		db: db,
+	}
+}
+
+const voucherSelect = `
+	SELECT
+		"v"."id",
+		"v"."token_id",
+		"v"."creator_id",
+		"v"."collection_id",
+		"v"."title",
+		"v"."description",
+		"v"."metadata",
+		"v"."media",
+		"v"."royalty_bps",
+		"v"."price",
+		"v"."uri",
+		"v"."signer",
+		"v"."signature",
+		"v"."status",
+		"v"."buyer_id",
+		"v"."nft_id",
+		"v"."redeemed_at",
+		"v"."created_at",
+		"v"."update_at" AS "updated_at",
+		CASE WHEN "v"."domain_name" IS NULL THEN NULL ELSE json_build_object(
+			'name', "v"."domain_name",
+			'version', "v"."domain_version",
+			'chain_id', "v"."domain_chain_id",
+			'verifying_contract', COALESCE("v"."domain_verifying_contract", '')
+		) END AS "domain"
+	FROM "vouchers" "v"
+`
+
+// NextTokenId reserves a token id from the nfts sequence, so lazy and direct mints never collide
+func (r *vouchersRepository) NextTokenId() (int64, error) {
+	var tokenId int64
+	if err := r.db.Get(&tokenId, `SELECT nextval('nfts_token_id_seq');`); err != nil {
+		return 0, fmt.Errorf("reserve token id failed: %v", err)
+	}
+	return tokenId, nil
+}
+
+func (r *vouchersRepository) FindOneVoucher(voucherId string) (*vouchers.Voucher, error) {
+	query := `
+	SELECT
+		row_to_json("t")
+	FROM (` + voucherSelect + `
+		WHERE "v"."id" = $1
+	) AS "t";`
+
+	data := make([]byte, 0)
+	if err := r.db.Get(&data, query, voucherId); err != nil {
+		return nil, fmt.Errorf("get voucher failed: %v", err)
+	}
+
+	voucher := new(vouchers.Voucher)
+	if err := json.Unmarshal(data, voucher); err != nil {
+		return nil, fmt.Errorf("unmarshal voucher failed: %v", err)
+	}
+	return voucher, nil
+}
+
+func (r *vouchersRepository) FindVouchers(req *vouchers.VoucherFilter) ([]*vouchers.Voucher, error) {
+	conditions := make([]string, 0)
+	filterValues := make([]any, 0)
+
+	if req.CreatorId != "" {
+		filterValues = append(filterValues, req.CreatorId)
+		conditions = append(conditions, fmt.Sprintf(`"v"."creator_id" = $%d`, len(filterValues)))
+	}
+	if req.CollectionId != "" {
+		filterValues = append(filterValues, req.CollectionId)
+		conditions = append(conditions, fmt.Sprintf(`"v"."collection_id" = $%d`, len(filterValues)))
+	}
+	if req.Status != "" {
+		filterValues = append(filterValues, req.Status)
+		conditions = append(conditions, fmt.Sprintf(`"v"."status" = $%d`, len(filterValues)))
+	}
+
+	query := `
+	SELECT
+		COALESCE(json_agg("t"), '[]'::json)
+	FROM (` + voucherSelect
+	if len(conditions) > 0 {
+		query += `
+		WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += `
+		ORDER BY "v"."created_at" DESC
+	) AS "t";`
+
+	data := make([]byte, 0)
+	if err := r.db.Get(&data, query, filterValues...); err != nil {
+		return nil, fmt.Errorf("get vouchers failed: %v", err)
+	}
+
+	result := make([]*vouchers.Voucher, 0)
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal vouchers failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *vouchersRepository) IsUserAddress(userId, address string) (bool, error) {
+	query := `
+	SELECT EXISTS (
+		SELECT 1
+		FROM "user_addresses"
+		WHERE "user_id" = $1
+		AND "address" = $2
+	);`
+
+	var linked bool
+	if err := r.db.Get(&linked, query, userId, address); err != nil {
+		return false, fmt.Errorf("get user address failed: %v", err)
+	}
+	return linked, nil
+}
+
+func (r *vouchersRepository) InsertVoucher(req *vouchers.VoucherReq) (*vouchers.Voucher, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	collection := new(struct {
+		OwnerId string                       `db:"owner_id"`
+		Status  collections.CollectionStatus `db:"status"`
+	})
+	if err := r.db.GetContext(ctx, collection, `
+	SELECT "owner_id", "status"
+	FROM "collections"
+	WHERE "id" = $1
+	AND "deleted_at" IS NULL;`, req.CollectionId); err != nil {
+		return nil, fmt.Errorf("collection not found")
+	}
+	if collection.OwnerId != req.CreatorId {
+		return nil, fmt.Errorf("collection belongs to another user")
+	}
+	if collection.Status != collections.Active {
+		return nil, fmt.Errorf("collection is archived")
+	}
+
+	metadata, err := json.Marshal(req.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("marshal voucher metadata failed: %v", err)
+	}
+	media, err := json.Marshal(req.Media)
+	if err != nil {
+		return nil, fmt.Errorf("marshal voucher media failed: %v", err)
+	}
+
+	var voucherId string
+	if err := r.db.QueryRowxContext(ctx, `
+	INSERT INTO "vouchers" (
+		"token_id",
+		"creator_id",
+		"collection_id",
+		"title",
+		"description",
+		"metadata",
+		"media",
+		"royalty_bps",
+		"price",
+		"uri"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	RETURNING "id";`,
+		req.TokenId,
+		req.CreatorId,
+		req.CollectionId,
+		req.Title,
+		req.Description,
+		string(metadata),
+		string(media),
+		req.RoyaltyBps,
+		req.Price,
+		req.Uri,
+	).Scan(&voucherId); err != nil {
+		return nil, fmt.Errorf("insert voucher failed: %v", err)
+	}
+	return r.FindOneVoucher(voucherId)
+}
+
+func (r *vouchersRepository) SignVoucher(req *vouchers.SignReq) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	UPDATE "vouchers" SET
+		"signer" = $1,
+		"signature" = $2,
+		"status" = $3,
+		"domain_name" = $7,
+		"domain_version" = $8,
+		"domain_chain_id" = $9,
+		"domain_verifying_contract" = NULLIF($10, '')
+	WHERE "id" = $4
+	AND "creator_id" = $5
+	AND "status" = $6;`
+
+	result, err := r.db.ExecContext(ctx, query,
+		req.Signer,
+		req.Signature,
+		vouchers.Signed,
+		req.VoucherId,
+		req.CreatorId,
+		vouchers.Unsigned,
+		req.Domain.Name,
+		req.Domain.Version,
+		req.Domain.ChainId,
+		req.Domain.VerifyingContract,
+	)
+	if err != nil {
+		return fmt.Errorf("sign voucher failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("voucher is already signed")
+	}
+	return nil
+}
+
+func (r *vouchersRepository) CancelVoucher(creatorId, voucherId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	UPDATE "vouchers" SET
+		"status" = $1
+	WHERE "id" = $2
+	AND "creator_id" = $3
+	AND "status" IN ($4, $5);`
+
+	result, err := r.db.ExecContext(ctx, query, vouchers.Cancelled, voucherId, creatorId, vouchers.Unsigned, vouchers.Signed)
+	if err != nil {
+		return fmt.Errorf("cancel voucher failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("voucher not found")
+	}
+	return nil
+}
+
+// RedeemVoucher mints the nft to the creator and sells it to the buyer in one transaction, the
+// voucher row lock makes concurrent buyers queue up and find it already redeemed, and the locked
+// row is what req.Verify checks the signature of
+func (r *vouchersRepository) RedeemVoucher(req *vouchers.RedeemReq) (*sales.Sale, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0)
+	if err := tx.GetContext(ctx, &data, `
+	SELECT
+		row_to_json("t")
+	FROM (`+voucherSelect+`
+		WHERE "v"."id" = $1
+		FOR UPDATE OF "v"
+	) AS "t";`, req.VoucherId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("voucher not found")
+	}
+	voucher := new(vouchers.Voucher)
+	if err := json.Unmarshal(data, voucher); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("unmarshal voucher failed: %v", err)
+	}
+	if voucher.Status != vouchers.Signed {
+		tx.Rollback()
+		return nil, fmt.Errorf("voucher is not available")
+	}
+	if err := req.Verify(voucher); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if voucher.CreatorId == req.BuyerId {
+		tx.Rollback()
+		return nil, fmt.Errorf("cannot redeem your own voucher")
+	}
+
+	var collectionStatus collections.CollectionStatus
+	if err := tx.GetContext(ctx, &collectionStatus, `
+	SELECT "status"
+	FROM "collections"
+	WHERE "id" = $1
+	AND "deleted_at" IS NULL
+	FOR SHARE;`, voucher.CollectionId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("collection not found")
+	}
+	if collectionStatus != collections.Active {
+		tx.Rollback()
+		return nil, fmt.Errorf("collection is archived")
+	}
+
+	media := voucher.Media
+	var imageUrl any
+	if len(media) > 0 {
+		imageUrl = media[0].Url
+	}
+	metadata, err := json.Marshal(voucher.Metadata)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("marshal voucher metadata failed: %v", err)
+	}
+
+	// the creator owns the nft for the instant between the mint and the sale
+	var nftId string
+	if err := tx.QueryRowxContext(ctx, `
+	INSERT INTO "nfts" (
+		"token_id",
+		"title",
+		"description",
+		"image_url",
+		"author_id",
+		"owner_id",
+		"collection_id",
+		"metadata",
+		"royalty_bps"
+	)
+	VALUES ($1, $2, $3, $4, $5, $5, $6, $7, $8)
+	RETURNING "id";`,
+		voucher.TokenId,
+		voucher.Title,
+		voucher.Description,
+		imageUrl,
+		voucher.CreatorId,
+		voucher.CollectionId,
+		string(metadata),
+		voucher.RoyaltyBps,
+	).Scan(&nftId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("insert nft failed: %v", err)
+	}
+
+	if len(media) > 0 {
+		query := `
+		INSERT INTO "images" ("filename", "url", "nft_id")
+		VALUES
+		`
+		valueStack := make([]any, 0)
+		for i, m := range media {
+			valueStack = append(valueStack, m.FileName, m.Url, nftId)
+			query += fmt.Sprintf(`($%d, $%d, $%d)`, i*3+1, i*3+2, i*3+3)
+			if i != len(media)-1 {
+				query += `,`
+			}
+		}
+		query += `;`
+
+		if _, err := tx.ExecContext(ctx, query, valueStack...); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("insert nft media failed: %v", err)
+		}
+	}
+
+	sale := &sales.Sale{
+		NftId:    nftId,
+		SellerId: voucher.CreatorId,
+		BuyerId:  req.BuyerId,
+		Price:    voucher.Price,
+		Source:   sales.VoucherSale,
+		SourceId: voucher.Id,
+
+		PlatformFeeBps: req.PlatformFeeBps,
+	}
+	if err := salesPatterns.RecordSale(ctx, tx, sale); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	UPDATE "vouchers" SET
+		"status" = $1,
+		"buyer_id" = $2,
+		"nft_id" = $3,
+		"redeemed_at" = now()
+	WHERE "id" = $4;`,
+		vouchers.Redeemed,
+		req.BuyerId,
+		nftId,
+		voucher.Id,
+	); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("close voucher failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit redeem failed: %v", err)
+	}
+	return sale, nil
+}
@@ -0,0 +1,166 @@
+package vouchersUsecases
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/sales"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/vouchers"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/vouchers/vouchersRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nfteth"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/utils"
+)
+
+type IVouchersUsecase interface {
+	FindOneVoucher(voucherId string) (*vouchers.Voucher, error)
+	FindVouchers(req *vouchers.VoucherFilter) ([]*vouchers.Voucher, error)
+	InsertVoucher(req *vouchers.VoucherReq) (*vouchers.Voucher, error)
+	SignVoucher(req *vouchers.SignReq) (*vouchers.Voucher, error)
+	CancelVoucher(creatorId, voucherId string) error
+	RedeemVoucher(req *vouchers.RedeemReq) (*sales.Sale, error)
+}
+
+type vouchersUsecase struct {
+	cfg                config.IConfig
+	vouchersRepository vouchersRepositories.IVouchersRepository
+}
+
+func VouchersUsecase(cfg config.IConfig, vouchersRepository vouchersRepositories.IVouchersRepository) IVouchersUsecase {
+	return &vouchersUsecase{
+		cfg:                cfg,
+		vouchersRepository: vouchersRepository,
+	}
+}
+
+// domain binds signatures to this marketplace and chain, and to the nft contract when one is configured
+func (u *vouchersUsecase) domain() *nfteth.TypedDataDomain {
+	domain := &nfteth.TypedDataDomain{
+		Name:    u.cfg.App().Name(),
+		Version: "1",
+		ChainId: u.cfg.App().ChainId(),
+	}
+	if contract, err := nfteth.NormalizeAddress(u.cfg.Indexer().Contract()); err == nil {
+		domain.VerifyingContract = contract
+	}
+	return domain
+}
+
+// typedData rebuilds what the creator signs, a signed voucher keeps the domain it was signed under
+// and only vouchers signed before domains were stored fall back to the current one
+func (u *vouchersUsecase) typedData(voucher *vouchers.Voucher) *nfteth.TypedData {
+	if voucher.Domain != nil {
+		return voucher.BuildTypedData(voucher.Domain.TypedDataDomain())
+	}
+	return voucher.BuildTypedData(u.domain())
+}
+
+func (u *vouchersUsecase) FindOneVoucher(voucherId string) (*vouchers.Voucher, error) {
+	voucher, err := u.vouchersRepository.FindOneVoucher(voucherId)
+	if err != nil {
+		return nil, err
+	}
+	voucher.TypedData = u.typedData(voucher)
+	return voucher, nil
+}
+
+// FindVouchers lists vouchers open for redemption unless another status is asked for
+func (u *vouchersUsecase) FindVouchers(req *vouchers.VoucherFilter) ([]*vouchers.Voucher, error) {
+	if req.Status == "" {
+		req.Status = string(vouchers.Signed)
+	}
+	result, err := u.vouchersRepository.FindVouchers(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, voucher := range result {
+		voucher.TypedData = u.typedData(voucher)
+	}
+	return result, nil
+}
+
+func (u *vouchersUsecase) InsertVoucher(req *vouchers.VoucherReq) (*vouchers.Voucher, error) {
+	if err := req.Validate(u.cfg.App().MaxRoyaltyBps(), u.cfg.App().GCPBucket()); err != nil {
+		return nil, err
+	}
+	req.Price = utils.RoundPrice(req.Price)
+	if req.Price <= 0 {
+		return nil, fmt.Errorf("price must be greater than zero")
+	}
+
+	tokenId, err := u.vouchersRepository.NextTokenId()
+	if err != nil {
+		return nil, err
+	}
+	req.TokenId = tokenId
+
+	req.Uri = strings.TrimSpace(req.Uri)
+	if req.Uri == "" {
+		req.Uri = fmt.Sprintf("https://%s/v1/nfts/%d/metadata", u.cfg.App().Domain(), tokenId)
+	}
+
+	voucher, err := u.vouchersRepository.InsertVoucher(req)
+	if err != nil {
+		return nil, err
+	}
+	voucher.TypedData = u.typedData(voucher)
+	return voucher, nil
+}
+
+// SignVoucher accepts the creator's signature when it comes from one of their linked addresses
+func (u *vouchersUsecase) SignVoucher(req *vouchers.SignReq) (*vouchers.Voucher, error) {
+	voucher, err := u.vouchersRepository.FindOneVoucher(req.VoucherId)
+	if err != nil {
+		return nil, err
+	}
+	if voucher.CreatorId != req.CreatorId {
+		return nil, fmt.Errorf("voucher not found")
+	}
+	if voucher.Status != vouchers.Unsigned {
+		return nil, fmt.Errorf("voucher is already signed")
+	}
+
+	domain := u.domain()
+	signer, err := nfteth.RecoverTypedData(voucher.BuildTypedData(domain), req.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %v", err)
+	}
+	linked, err := u.vouchersRepository.IsUserAddress(req.CreatorId, signer)
+	if err != nil {
+		return nil, err
+	}
+	if !linked {
+		return nil, fmt.Errorf("signer is not linked to the creator")
+	}
+
+	req.Signer = signer
+	req.Domain = domain
+	if err := u.vouchersRepository.SignVoucher(req); err != nil {
+		return nil, err
+	}
+	return u.FindOneVoucher(req.VoucherId)
+}
+
+func (u *vouchersUsecase) CancelVoucher(creatorId, voucherId string) error {
+	if err := u.vouchersRepository.CancelVoucher(creatorId, voucherId); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RedeemVoucher checks the stored signature again before the mint, the row could have been
+// written around the usecase, so it is verified on the row the repository holds locked
+func (u *vouchersUsecase) RedeemVoucher(req *vouchers.RedeemReq) (*sales.Sale, error) {
+	req.Verify = func(voucher *vouchers.Voucher) error {
+		if err := nfteth.VerifyTypedData(voucher.Signer, u.typedData(voucher), voucher.Signature); err != nil {
+			return fmt.Errorf("invalid signature: %v", err)
+		}
+		return nil
+	}
+	req.PlatformFeeBps = u.cfg.App().PlatformFeeBps()
+	sale, err := u.vouchersRepository.RedeemVoucher(req)
+	if err != nil {
+		return nil, err
+	}
+	return sale, nil
+}
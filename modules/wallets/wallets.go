@@ -0,0 +1,68 @@
+package wallets
+
+type AccountKind string
+
+const (
+	Available AccountKind = "available"
+	Held      AccountKind = "held"
+	Platform  AccountKind = "platform"
+	External  AccountKind = "external"
+)
+
+type TransactionKind string
+
+const (
+	Deposit  TransactionKind = "deposit"
+	Withdraw TransactionKind = "withdraw"
+	Hold     TransactionKind = "hold"
+	Release  TransactionKind = "release"
+	Sale     TransactionKind = "sale"
+)
+
+type Direction string
+
+const (
+	Debit  Direction = "debit"
+	Credit Direction = "credit"
+)
+
+type Balance struct {
+	UserId    string  `json:"user_id" db:"user_id"`
+	Available float64 `json:"available" db:"available"`
+	Held      float64 `json:"held" db:"held"`
+}
+
+type LedgerEntry struct {
+	Id            string          `json:"id" db:"id"`
+	TransactionId string          `json:"transaction_id" db:"transaction_id"`
+	Kind          TransactionKind `json:"kind" db:"kind"`
+	Reference     string          `json:"reference" db:"reference"`
+	Account       AccountKind     `json:"account" db:"account"`
+	Direction     Direction       `json:"direction" db:"direction"`
+	Amount        float64         `json:"amount" db:"amount"`
+	CreatedAt     string          `json:"created_at" db:"created_at"`
+}
+
+type AmountReq struct {
+	UserId string  `json:"user_id" db:"user_id" form:"user_id"`
+	Amount float64 `json:"amount" db:"amount" form:"amount"`
+}
+
+// Account points at a user account, or at a system account when UserId is empty
+type Account struct {
+	UserId string
+	Kind   AccountKind
+}
+
+// Leg moves Amount from one account to another
+type Leg struct {
+	From   Account
+	To     Account
+	Amount float64
+}
+
+type TransactionReq struct {
+	Kind      TransactionKind
+	Reference string
+	Legs      []*Leg
+}
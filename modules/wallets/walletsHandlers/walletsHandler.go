@@ -0,0 +1,137 @@
+package walletsHandlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/entities"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets/walletsUsecases"
+)
+
+type walletsHandlersErrCode string
+
+const (
+	findBalanceErr walletsHandlersErrCode = "wallets-001"
+	findEntriesErr walletsHandlersErrCode = "wallets-002"
+	depositErr     walletsHandlersErrCode = "wallets-003"
+	withdrawErr    walletsHandlersErrCode = "wallets-004"
+)
+
+type IWalletsHandler interface {
+	FindBalance(c *fiber.Ctx) error
+	FindEntries(c *fiber.Ctx) error
+	Deposit(c *fiber.Ctx) error
+	Withdraw(c *fiber.Ctx) error
+}
+
+type walletsHandler struct {
+	cfg            config.IConfig
+	walletsUsecase walletsUsecases.IWalletsUsecase
+}
+
+func WalletsHandler(cfg config.IConfig, walletsUsecase walletsUsecases.IWalletsUsecase) IWalletsHandler {
+	return &walletsHandler{
+		cfg:            cfg,
+		walletsUsecase: walletsUsecase,
+	}
+}
+
+func (h *walletsHandler) FindBalance(c *fiber.Ctx) error {
+	balance, err := h.walletsUsecase.FindBalance(c.Locals("userId").(string))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findBalanceErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, balance).Res()
+}
+
+func (h *walletsHandler) FindEntries(c *fiber.Ctx) error {
+	entries, err := h.walletsUsecase.FindEntries(c.Locals("userId").(string))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findEntriesErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Success(fiber.StatusOK, entries).Res()
+}
+
+// Deposit credits a user once an external payment has been confirmed, it is an admin operation
+func (h *walletsHandler) Deposit(c *fiber.Ctx) error {
+	req := new(wallets.AmountReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(depositErr),
+			err.Error(),
+		).Res()
+	}
+
+	balance, err := h.walletsUsecase.Deposit(req)
+	if err != nil {
+		switch err.Error() {
+		case "user id is required",
+			"amount must be greater than zero":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(depositErr),
+				err.Error(),
+			).Res()
+		case "user not found":
+			return entities.NewResponse(c).Error(
+				fiber.ErrNotFound.Code,
+				string(depositErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(depositErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, balance).Res()
+}
+
+func (h *walletsHandler) Withdraw(c *fiber.Ctx) error {
+	req := new(wallets.AmountReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(withdrawErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = c.Locals("userId").(string)
+
+	balance, err := h.walletsUsecase.Withdraw(req)
+	if err != nil {
+		switch err.Error() {
+		case "amount must be greater than zero":
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(withdrawErr),
+				err.Error(),
+			).Res()
+		case "insufficient funds":
+			return entities.NewResponse(c).Error(
+				fiber.ErrPaymentRequired.Code,
+				string(withdrawErr),
+				err.Error(),
+			).Res()
+		default:
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(withdrawErr),
+				err.Error(),
+			).Res()
+		}
+	}
+	return entities.NewResponse(c).Success(fiber.StatusCreated, balance).Res()
+}
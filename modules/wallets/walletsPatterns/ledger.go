@@ -0,0 +1,217 @@
+package walletsPatterns
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets"
+)
+
+// Post writes a balanced ledger transaction inside the caller's transaction, each leg becomes a
+// debit and a credit entry and the account balances move with them; user accounts are locked in id
+// order and system accounts after them so concurrent transfers between the same accounts cannot
+// deadlock, and a debit that would take a balance below zero fails with "insufficient funds"
+func Post(ctx context.Context, tx *sqlx.Tx, req *wallets.TransactionReq) (string, error) {
+	accounts := make([]wallets.Account, 0, len(req.Legs)*2)
+	for _, leg := range req.Legs {
+		accounts = append(accounts, leg.From, leg.To)
+	}
+	accountIds, err := lockAccounts(ctx, tx, accounts)
+	if err != nil {
+		return "", err
+	}
+
+	var transactionId string
+	if err := tx.QueryRowxContext(ctx, `
+	INSERT INTO "ledger_transactions" (
+		"kind",
+		"reference"
+	)
+	VALUES ($1, $2)
+	RETURNING "id";`,
+		req.Kind,
+		req.Reference,
+	).Scan(&transactionId); err != nil {
+		return "", fmt.Errorf("insert ledger transaction failed: %v", err)
+	}
+
+	for _, leg := range req.Legs {
+		if leg.Amount <= 0 {
+			continue
+		}
+
+		result, err := tx.ExecContext(ctx, `
+		UPDATE "wallet_accounts" SET
+			"balance" = "balance" - $1
+		WHERE "id" = $2
+		AND ("kind" = 'external' OR "balance" >= $1);`,
+			leg.Amount,
+			accountIds[leg.From],
+		)
+		if err != nil {
+			return "", fmt.Errorf("debit wallet account failed: %v", err)
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return "", fmt.Errorf("insufficient funds")
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+		UPDATE "wallet_accounts" SET
+			"balance" = "balance" + $1
+		WHERE "id" = $2;`,
+			leg.Amount,
+			accountIds[leg.To],
+		); err != nil {
+			return "", fmt.Errorf("credit wallet account failed: %v", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+		INSERT INTO "ledger_entries" (
+			"transaction_id",
+			"account_id",
+			"direction",
+			"amount"
+		)
+		VALUES ($1, $2, $3, $5), ($1, $4, $6, $5);`,
+			transactionId,
+			accountIds[leg.From],
+			wallets.Debit,
+			accountIds[leg.To],
+			leg.Amount,
+			wallets.Credit,
+		); err != nil {
+			return "", fmt.Errorf("insert ledger entries failed: %v", err)
+		}
+	}
+	return transactionId, nil
+}
+
+// LockUsers locks the available and held accounts of every user up front in id order, callers
+// posting several ledger transactions over different users in one database transaction take
+// their locks here first so the order of the posts cannot cause a deadlock
+func LockUsers(ctx context.Context, tx *sqlx.Tx, userIds ...string) error {
+	accounts := make([]wallets.Account, 0, len(userIds)*2)
+	for _, userId := range userIds {
+		if userId == "" {
+			continue
+		}
+		accounts = append(accounts,
+			wallets.Account{UserId: userId, Kind: wallets.Available},
+			wallets.Account{UserId: userId, Kind: wallets.Held},
+		)
+	}
+	_, err := lockAccounts(ctx, tx, accounts)
+	return err
+}
+
+// lockAccounts resolves the accounts and locks them sorted by id, system accounts go last so a
+// transaction that took its user locks through LockUsers still follows the same order
+func lockAccounts(ctx context.Context, tx *sqlx.Tx, accounts []wallets.Account) (map[wallets.Account]string, error) {
+	accountIds := make(map[wallets.Account]string)
+	for _, account := range accounts {
+		if _, ok := accountIds[account]; ok {
+			continue
+		}
+		accountId, err := findAccountId(ctx, tx, account)
+		if err != nil {
+			return nil, err
+		}
+		accountIds[account] = accountId
+	}
+
+	userIds := make([]string, 0, len(accountIds))
+	systemIds := make([]string, 0)
+	for account, accountId := range accountIds {
+		if account.UserId == "" {
+			systemIds = append(systemIds, accountId)
+			continue
+		}
+		userIds = append(userIds, accountId)
+	}
+	sort.Strings(userIds)
+	sort.Strings(systemIds)
+	for _, accountId := range append(userIds, systemIds...) {
+		if _, err := tx.ExecContext(ctx, `
+		SELECT "id"
+		FROM "wallet_accounts"
+		WHERE "id" = $1
+		FOR UPDATE;`, accountId); err != nil {
+			return nil, fmt.Errorf("lock wallet account failed: %v", err)
+		}
+	}
+	return accountIds, nil
+}
+
+// Hold moves funds from the user's available balance to their held balance
+func Hold(ctx context.Context, tx *sqlx.Tx, userId string, amount float64, reference string) error {
+	_, err := Post(ctx, tx, &wallets.TransactionReq{
+		Kind:      wallets.Hold,
+		Reference: reference,
+		Legs: []*wallets.Leg{
+			{
+				From:   wallets.Account{UserId: userId, Kind: wallets.Available},
+				To:     wallets.Account{UserId: userId, Kind: wallets.Held},
+				Amount: amount,
+			},
+		},
+	})
+	return err
+}
+
+// Release returns held funds to the user's available balance
+func Release(ctx context.Context, tx *sqlx.Tx, userId string, amount float64, reference string) error {
+	_, err := Post(ctx, tx, &wallets.TransactionReq{
+		Kind:      wallets.Release,
+		Reference: reference,
+		Legs: []*wallets.Leg{
+			{
+				From:   wallets.Account{UserId: userId, Kind: wallets.Held},
+				To:     wallets.Account{UserId: userId, Kind: wallets.Available},
+				Amount: amount,
+			},
+		},
+	})
+	return err
+}
+
+// findAccountId opens user accounts on first use, system accounts are created by the migration
+func findAccountId(ctx context.Context, tx *sqlx.Tx, account wallets.Account) (string, error) {
+	var accountId string
+	if account.UserId == "" {
+		if err := tx.GetContext(ctx, &accountId, `
+		SELECT "id"
+		FROM "wallet_accounts"
+		WHERE "user_id" IS NULL
+		AND "kind" = $1;`, account.Kind); err != nil {
+			return "", fmt.Errorf("get %s account failed: %v", account.Kind, err)
+		}
+		return accountId, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	INSERT INTO "wallet_accounts" (
+		"user_id",
+		"kind"
+	)
+	VALUES ($1, $2)
+	ON CONFLICT ("user_id", "kind") DO NOTHING;`,
+		account.UserId,
+		account.Kind,
+	); err != nil {
+		return "", fmt.Errorf("open wallet account failed: %v", err)
+	}
+	if err := tx.GetContext(ctx, &accountId, `
+	SELECT "id"
+	FROM "wallet_accounts"
+	WHERE "user_id" = $1
+	AND "kind" = $2;`,
+		account.UserId,
+		account.Kind,
+	); err != nil {
+		return "", fmt.Errorf("get wallet account failed: %v", err)
+	}
+	return accountId, nil
+}
@@ -0,0 +1,120 @@
+package walletsRepositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets/walletsPatterns"
+)
+
+type IWalletsRepository interface {
+	FindBalance(userId string) (*wallets.Balance, error)
+	FindEntries(userId string) ([]*wallets.LedgerEntry, error)
+	Deposit(req *wallets.AmountReq) (*wallets.Balance, error)
+	Withdraw(req *wallets.AmountReq) (*wallets.Balance, error)
+}
+
+type walletsRepository struct {
+	db *sqlx.DB
+}
+
+func WalletsRepository(db *sqlx.DB) IWalletsRepository {
+	return &walletsRepository{
+		db: db,
+	}
+}
+
+func (r *walletsRepository) FindBalance(userId string) (*wallets.Balance, error) {
+	query := `
+	SELECT
+		$1::varchar AS "user_id",
+		COALESCE(SUM("balance") FILTER (WHERE "kind" = 'available'), 0) AS "available",
+		COALESCE(SUM("balance") FILTER (WHERE "kind" = 'held'), 0) AS "held"
+	FROM "wallet_accounts"
+	WHERE "user_id" = $1;`
+
+	balance := new(wallets.Balance)
+	if err := r.db.Get(balance, query, userId); err != nil {
+		return nil, fmt.Errorf("get balance failed: %v", err)
+	}
+	return balance, nil
+}
+
+func (r *walletsRepository) FindEntries(userId string) ([]*wallets.LedgerEntry, error) {
+	query := `
+	SELECT
+		"e"."id",
+		"e"."transaction_id",
+		"t"."kind",
+		"t"."reference",
+		"a"."kind" AS "account",
+		"e"."direction",
+		"e"."amount",
+		"e"."created_at"
+	FROM "ledger_entries" "e"
+	JOIN "ledger_transactions" "t" ON "t"."id" = "e"."transaction_id"
+	JOIN "wallet_accounts" "a" ON "a"."id" = "e"."account_id"
+	WHERE "a"."user_id" = $1
+	ORDER BY "e"."created_at" DESC
+	LIMIT 100;`
+
+	result := make([]*wallets.LedgerEntry, 0)
+	if err := r.db.Select(&result, query, userId); err != nil {
+		return nil, fmt.Errorf("get ledger entries failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *walletsRepository) Deposit(req *wallets.AmountReq) (*wallets.Balance, error) {
+	return r.transfer(&wallets.TransactionReq{
+		Kind: wallets.Deposit,
+		Legs: []*wallets.Leg{
+			{
+				From:   wallets.Account{Kind: wallets.External},
+				To:     wallets.Account{UserId: req.UserId, Kind: wallets.Available},
+				Amount: req.Amount,
+			},
+		},
+	}, req.UserId)
+}
+
+func (r *walletsRepository) Withdraw(req *wallets.AmountReq) (*wallets.Balance, error) {
+	return r.transfer(&wallets.TransactionReq{
+		Kind: wallets.Withdraw,
+		Legs: []*wallets.Leg{
+			{
+				From:   wallets.Account{UserId: req.UserId, Kind: wallets.Available},
+				To:     wallets.Account{Kind: wallets.External},
+				Amount: req.Amount,
+			},
+		},
+	}, req.UserId)
+}
+
+func (r *walletsRepository) transfer(req *wallets.TransactionReq, userId string) (*wallets.Balance, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := walletsPatterns.Post(ctx, tx, req); err != nil {
+		tx.Rollback()
+		if strings.Contains(err.Error(), "wallet_accounts_user_id_fkey") {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit %s failed: %v", req.Kind, err)
+	}
+	return r.FindBalance(userId)
+}
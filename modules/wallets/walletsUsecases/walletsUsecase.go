@@ -0,0 +1,78 @@
+package walletsUsecases
+
+import (
+	"fmt"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets"
+	"github.com/muhammadfarhankt/nft-marketplace/modules/wallets/walletsRepositories"
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/utils"
+)
+
+type IWalletsUsecase interface {
+	FindBalance(userId string) (*wallets.Balance, error)
+	FindEntries(userId string) ([]*wallets.LedgerEntry, error)
+	Deposit(req *wallets.AmountReq) (*wallets.Balance, error)
+	Withdraw(req *wallets.AmountReq) (*wallets.Balance, error)
+}
+
+type walletsUsecase struct {
+	cfg               config.IConfig
+	walletsRepository walletsRepositories.IWalletsRepository
+}
+
+func WalletsUsecase(cfg config.IConfig, walletsRepository walletsRepositories.IWalletsRepository) IWalletsUsecase {
+	return &walletsUsecase{
+		cfg:               cfg,
+		walletsRepository: walletsRepository,
+	}
+}
+
+func validateAmount(req *wallets.AmountReq) error {
+	if req.UserId == "" {
+		return fmt.Errorf("user id is required")
+	}
+	req.Amount = utils.RoundPrice(req.Amount)
+	if req.Amount <= 0 {
+		return fmt.Errorf("amount must be greater than zero")
+	}
+	return nil
+}
+
+func (u *walletsUsecase) FindBalance(userId string) (*wallets.Balance, error) {
+	balance, err := u.walletsRepository.FindBalance(userId)
+	if err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+func (u *walletsUsecase) FindEntries(userId string) ([]*wallets.LedgerEntry, error) {
+	entries, err := u.walletsRepository.FindEntries(userId)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (u *walletsUsecase) Deposit(req *wallets.AmountReq) (*wallets.Balance, error) {
+	if err := validateAmount(req); err != nil {
+		return nil, err
+	}
+	balance, err := u.walletsRepository.Deposit(req)
+	if err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+func (u *walletsUsecase) Withdraw(req *wallets.AmountReq) (*wallets.Balance, error) {
+	if err := validateAmount(req); err != nil {
+		return nil, err
+	}
+	balance, err := u.walletsRepository.Withdraw(req)
+	if err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS update_collections_updated_at ON "collections";
+
+DROP TABLE IF EXISTS "collections" CASCADE;
+
+DROP SEQUENCE IF EXISTS collections_id_seq;
+
+COMMIT;
@@ -0,0 +1,27 @@
+BEGIN;
+
+CREATE SEQUENCE collections_id_seq START WITH 1 INCREMENT BY 1;
+
+CREATE TABLE "collections" (
+  "id" varchar(7) PRIMARY KEY DEFAULT CONCAT('C', LPAD(nextval('collections_id_seq')::text, 6, '0')),
+  "owner_id" varchar(7) NOT NULL,
+  "category_id" int NOT NULL,
+  "title" varchar(255) NOT NULL,
+  "description" text NOT NULL DEFAULT '',
+  "cover_filename" varchar,
+  "cover_url" varchar,
+  "status" varchar(20) NOT NULL DEFAULT 'active',
+  "created_at" timestamp NOT NULL DEFAULT now(),
+  "update_at" timestamp NOT NULL DEFAULT now(),
+  "deleted_at" timestamp
+);
+
+CREATE INDEX ON "collections" ("owner_id");
+CREATE INDEX ON "collections" ("category_id");
+
+ALTER TABLE "collections" ADD FOREIGN KEY ("owner_id") REFERENCES "users" ("id");
+ALTER TABLE "collections" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id");
+
+CREATE TRIGGER update_collections_updated_at BEFORE UPDATE ON "collections" FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "images_nft_id_idx";
+DROP INDEX IF EXISTS "nfts_owner_id_idx";
+
+ALTER TABLE "nfts" DROP COLUMN IF EXISTS "metadata";
+ALTER TABLE "nfts" DROP COLUMN IF EXISTS "collection_id";
+ALTER TABLE "nfts" DROP COLUMN IF EXISTS "token_id";
+
+UPDATE "nfts" SET "price" = 0 WHERE "price" IS NULL;
+UPDATE "nfts" SET "image_url" = '' WHERE "image_url" IS NULL;
+ALTER TABLE "nfts" ALTER COLUMN "price" SET NOT NULL;
+ALTER TABLE "nfts" ALTER COLUMN "image_url" SET NOT NULL;
+
+DROP SEQUENCE IF EXISTS nfts_token_id_seq;
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE SEQUENCE nfts_token_id_seq START WITH 1 INCREMENT BY 1;
+
+ALTER TABLE "nfts" ADD COLUMN "token_id" bigint UNIQUE NOT NULL DEFAULT nextval('nfts_token_id_seq');
+ALTER TABLE "nfts" ADD COLUMN "collection_id" varchar(7);
+ALTER TABLE "nfts" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
+
+-- minted items carry their media in "images" and are priced once listed
+ALTER TABLE "nfts" ALTER COLUMN "price" DROP NOT NULL;
+ALTER TABLE "nfts" ALTER COLUMN "image_url" DROP NOT NULL;
+
+CREATE INDEX ON "nfts" ("owner_id");
+CREATE INDEX ON "nfts" ("collection_id");
+CREATE INDEX ON "images" ("nft_id");
+
+ALTER TABLE "nfts" ADD FOREIGN KEY ("collection_id") REFERENCES "collections" ("id");
+
+COMMIT;
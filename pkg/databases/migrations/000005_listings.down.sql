@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS update_listings_updated_at ON "listings";
+
+DROP TABLE IF EXISTS "sales" CASCADE;
+DROP TABLE IF EXISTS "listings" CASCADE;
+
+COMMIT;
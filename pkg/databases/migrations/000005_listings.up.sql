@@ -0,0 +1,38 @@
+BEGIN;
+
+CREATE TABLE "listings" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "nft_id" varchar(7) NOT NULL,
+  "seller_id" varchar(7) NOT NULL,
+  "price" numeric(10, 2) NOT NULL CHECK ("price" > 0),
+  "status" varchar(20) NOT NULL DEFAULT 'active',
+  "buyer_id" varchar(7),
+  "created_at" timestamp NOT NULL DEFAULT now(),
+  "update_at" timestamp NOT NULL DEFAULT now()
+);
+
+CREATE TABLE "sales" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "nft_id" varchar(7) NOT NULL,
+  "seller_id" varchar(7) NOT NULL,
+  "buyer_id" varchar(7) NOT NULL,
+  "price" numeric(10, 2) NOT NULL,
+  "source" varchar(20) NOT NULL,
+  "source_id" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT now()
+);
+
+-- an nft can only have one open listing at a time
+CREATE UNIQUE INDEX "listings_active_nft_id_key" ON "listings" ("nft_id") WHERE "status" = 'active';
+CREATE INDEX ON "sales" ("nft_id");
+
+ALTER TABLE "listings" ADD FOREIGN KEY ("nft_id") REFERENCES "nfts" ("id");
+ALTER TABLE "listings" ADD FOREIGN KEY ("seller_id") REFERENCES "users" ("id");
+ALTER TABLE "listings" ADD FOREIGN KEY ("buyer_id") REFERENCES "users" ("id");
+ALTER TABLE "sales" ADD FOREIGN KEY ("nft_id") REFERENCES "nfts" ("id");
+ALTER TABLE "sales" ADD FOREIGN KEY ("seller_id") REFERENCES "users" ("id");
+ALTER TABLE "sales" ADD FOREIGN KEY ("buyer_id") REFERENCES "users" ("id");
+
+CREATE TRIGGER update_listings_updated_at BEFORE UPDATE ON "listings" FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS update_auctions_updated_at ON "auctions";
+
+DROP TABLE IF EXISTS "auction_bids" CASCADE;
+DROP TABLE IF EXISTS "auctions" CASCADE;
+
+COMMIT;
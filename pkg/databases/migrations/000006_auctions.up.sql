@@ -0,0 +1,42 @@
+BEGIN;
+
+CREATE TABLE "auctions" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "nft_id" varchar(7) NOT NULL,
+  "seller_id" varchar(7) NOT NULL,
+  "start_price" numeric(10, 2) NOT NULL CHECK ("start_price" > 0),
+  "reserve_price" numeric(10, 2) NOT NULL DEFAULT 0,
+  "min_increment" numeric(10, 2) NOT NULL CHECK ("min_increment" > 0),
+  "start_time" timestamptz NOT NULL,
+  "end_time" timestamptz NOT NULL,
+  "extension_window" int NOT NULL DEFAULT 300,
+  "extension_duration" int NOT NULL DEFAULT 300,
+  "status" varchar(20) NOT NULL DEFAULT 'active',
+  "highest_bid_id" uuid,
+  "created_at" timestamp NOT NULL DEFAULT now(),
+  "update_at" timestamp NOT NULL DEFAULT now(),
+  CHECK ("end_time" > "start_time")
+);
+
+CREATE TABLE "auction_bids" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "auction_id" uuid NOT NULL,
+  "bidder_id" varchar(7) NOT NULL,
+  "amount" numeric(10, 2) NOT NULL CHECK ("amount" > 0),
+  "created_at" timestamp NOT NULL DEFAULT now()
+);
+
+-- an nft can only be auctioned once at a time
+CREATE UNIQUE INDEX "auctions_active_nft_id_key" ON "auctions" ("nft_id") WHERE "status" = 'active';
+CREATE INDEX ON "auctions" ("status", "end_time");
+CREATE INDEX ON "auction_bids" ("auction_id", "created_at");
+
+ALTER TABLE "auctions" ADD FOREIGN KEY ("nft_id") REFERENCES "nfts" ("id");
+ALTER TABLE "auctions" ADD FOREIGN KEY ("seller_id") REFERENCES "users" ("id");
+ALTER TABLE "auctions" ADD FOREIGN KEY ("highest_bid_id") REFERENCES "auction_bids" ("id");
+ALTER TABLE "auction_bids" ADD FOREIGN KEY ("auction_id") REFERENCES "auctions" ("id");
+ALTER TABLE "auction_bids" ADD FOREIGN KEY ("bidder_id") REFERENCES "users" ("id");
+
+CREATE TRIGGER update_auctions_updated_at BEFORE UPDATE ON "auctions" FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+DELETE FROM "listings" WHERE "type" = 'dutch';
+
+ALTER TABLE "listings" DROP CONSTRAINT IF EXISTS "listings_dutch_check";
+ALTER TABLE "listings"
+  DROP COLUMN IF EXISTS "type",
+  DROP COLUMN IF EXISTS "start_price",
+  DROP COLUMN IF EXISTS "floor_price",
+  DROP COLUMN IF EXISTS "curve",
+  DROP COLUMN IF EXISTS "start_time",
+  DROP COLUMN IF EXISTS "end_time";
+
+COMMIT;
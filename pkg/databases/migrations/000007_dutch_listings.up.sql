@@ -0,0 +1,22 @@
+BEGIN;
+
+ALTER TABLE "listings"
+  ADD COLUMN "type" varchar(20) NOT NULL DEFAULT 'fixed',
+  ADD COLUMN "start_price" numeric(10, 2),
+  ADD COLUMN "floor_price" numeric(10, 2),
+  ADD COLUMN "curve" varchar(20),
+  ADD COLUMN "start_time" timestamptz,
+  ADD COLUMN "end_time" timestamptz;
+
+-- a dutch listing decays from start_price to floor_price between start_time and end_time
+ALTER TABLE "listings" ADD CONSTRAINT "listings_dutch_check" CHECK (
+  "type" = 'fixed' OR (
+    "type" = 'dutch'
+    AND "floor_price" > 0
+    AND "start_price" > "floor_price"
+    AND "curve" IN ('linear', 'exponential')
+    AND "end_time" > "start_time"
+  )
+);
+
+COMMIT;
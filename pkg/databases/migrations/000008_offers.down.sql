@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS update_offers_updated_at ON "offers";
+
+DROP TABLE IF EXISTS "offers" CASCADE;
+
+COMMIT;
@@ -0,0 +1,24 @@
+BEGIN;
+
+CREATE TABLE "offers" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "nft_id" varchar(7) NOT NULL,
+  "buyer_id" varchar(7) NOT NULL,
+  "price" numeric(10, 2) NOT NULL CHECK ("price" > 0),
+  "expires_at" timestamptz NOT NULL,
+  "status" varchar(20) NOT NULL DEFAULT 'pending',
+  "created_at" timestamp NOT NULL DEFAULT now(),
+  "update_at" timestamp NOT NULL DEFAULT now()
+);
+
+-- a buyer keeps at most one pending offer per nft, a new price means cancelling the old offer
+CREATE UNIQUE INDEX "offers_pending_nft_id_buyer_id_key" ON "offers" ("nft_id", "buyer_id") WHERE "status" = 'pending';
+CREATE INDEX ON "offers" ("status", "expires_at");
+CREATE INDEX ON "offers" ("buyer_id");
+
+ALTER TABLE "offers" ADD FOREIGN KEY ("nft_id") REFERENCES "nfts" ("id");
+ALTER TABLE "offers" ADD FOREIGN KEY ("buyer_id") REFERENCES "users" ("id");
+
+CREATE TRIGGER update_offers_updated_at BEFORE UPDATE ON "offers" FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+COMMIT;
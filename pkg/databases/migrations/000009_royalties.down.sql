@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "payouts" CASCADE;
+
+ALTER TABLE "nfts" DROP COLUMN IF EXISTS "royalty_bps";
+
+COMMIT;
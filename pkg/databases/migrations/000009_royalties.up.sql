@@ -0,0 +1,21 @@
+BEGIN;
+
+ALTER TABLE "nfts" ADD COLUMN "royalty_bps" int NOT NULL DEFAULT 0 CHECK ("royalty_bps" >= 0 AND "royalty_bps" <= 10000);
+
+-- every sale is split into payouts, a null recipient is the platform
+CREATE TABLE "payouts" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "sale_id" uuid NOT NULL,
+  "recipient_id" varchar(7),
+  "kind" varchar(20) NOT NULL,
+  "amount" numeric(10, 2) NOT NULL CHECK ("amount" >= 0),
+  "created_at" timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ON "payouts" ("sale_id");
+CREATE INDEX ON "payouts" ("recipient_id", "kind");
+
+ALTER TABLE "payouts" ADD FOREIGN KEY ("sale_id") REFERENCES "sales" ("id");
+ALTER TABLE "payouts" ADD FOREIGN KEY ("recipient_id") REFERENCES "users" ("id");
+
+COMMIT;
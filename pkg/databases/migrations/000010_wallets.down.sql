@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS update_wallet_accounts_updated_at ON "wallet_accounts";
+
+DROP TABLE IF EXISTS "ledger_entries" CASCADE;
+DROP TABLE IF EXISTS "ledger_transactions" CASCADE;
+DROP TABLE IF EXISTS "wallet_accounts" CASCADE;
+
+COMMIT;
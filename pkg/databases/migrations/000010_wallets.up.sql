@@ -0,0 +1,48 @@
+BEGIN;
+
+-- user funds live in an available and a held account, the platform account collects fees
+-- and the external account is the outside world money is deposited from and withdrawn to
+CREATE TABLE "wallet_accounts" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "user_id" varchar(7),
+  "kind" varchar(20) NOT NULL,
+  "balance" numeric(12, 2) NOT NULL DEFAULT 0,
+  "created_at" timestamp NOT NULL DEFAULT now(),
+  "update_at" timestamp NOT NULL DEFAULT now(),
+  CONSTRAINT "wallet_accounts_balance_check" CHECK ("kind" = 'external' OR "balance" >= 0)
+);
+
+CREATE TABLE "ledger_transactions" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "kind" varchar(20) NOT NULL,
+  "reference" varchar NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL DEFAULT now()
+);
+
+-- every movement is a debit on one account and a credit of the same amount on another
+CREATE TABLE "ledger_entries" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "transaction_id" uuid NOT NULL,
+  "account_id" uuid NOT NULL,
+  "direction" varchar(6) NOT NULL CHECK ("direction" IN ('debit', 'credit')),
+  "amount" numeric(12, 2) NOT NULL CHECK ("amount" > 0),
+  "created_at" timestamp NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX "wallet_accounts_user_id_kind_key" ON "wallet_accounts" ("user_id", "kind");
+CREATE UNIQUE INDEX "wallet_accounts_system_kind_key" ON "wallet_accounts" ("kind") WHERE "user_id" IS NULL;
+CREATE INDEX ON "ledger_entries" ("account_id", "created_at");
+CREATE INDEX ON "ledger_entries" ("transaction_id");
+
+ALTER TABLE "wallet_accounts" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+ALTER TABLE "ledger_entries" ADD FOREIGN KEY ("transaction_id") REFERENCES "ledger_transactions" ("id");
+ALTER TABLE "ledger_entries" ADD FOREIGN KEY ("account_id") REFERENCES "wallet_accounts" ("id");
+
+CREATE TRIGGER update_wallet_accounts_updated_at BEFORE UPDATE ON "wallet_accounts" FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+INSERT INTO "wallet_accounts" ("kind")
+VALUES
+  ('platform'),
+  ('external');
+
+COMMIT;
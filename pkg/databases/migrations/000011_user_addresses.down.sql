@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "address_challenges" CASCADE;
+DROP TABLE IF EXISTS "user_addresses" CASCADE;
+
+COMMIT;
@@ -0,0 +1,27 @@
+BEGIN;
+
+-- ethereum addresses are stored in their EIP-55 checksummed form
+CREATE TABLE "user_addresses" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "user_id" varchar(7) NOT NULL,
+  "address" varchar(42) NOT NULL UNIQUE,
+  "verified_at" timestamp NOT NULL DEFAULT now()
+);
+
+CREATE TABLE "address_challenges" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "user_id" varchar(7) NOT NULL,
+  "address" varchar(42) NOT NULL,
+  "nonce" varchar NOT NULL UNIQUE,
+  "message" text NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ON "user_addresses" ("user_id");
+
+ALTER TABLE "user_addresses" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+ALTER TABLE "address_challenges" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "siwe_nonces" CASCADE;
+
+UPDATE "users" SET "email" = "username" || '@siwe.invalid' WHERE "email" IS NULL;
+UPDATE "users" SET "password" = '' WHERE "password" IS NULL;
+
+ALTER TABLE "users" ALTER COLUMN "email" SET NOT NULL;
+ALTER TABLE "users" ALTER COLUMN "password" SET NOT NULL;
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+-- users created through sign-in with ethereum have neither email nor password
+ALTER TABLE "users" ALTER COLUMN "email" DROP NOT NULL;
+ALTER TABLE "users" ALTER COLUMN "password" DROP NOT NULL;
+
+CREATE TABLE "siwe_nonces" (
+  "nonce" varchar PRIMARY KEY,
+  "expires_at" timestamptz NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamp NOT NULL DEFAULT now()
+);
+
+COMMIT;
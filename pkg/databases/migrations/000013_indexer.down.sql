@@ -0,0 +1,12 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS update_indexer_checkpoints_updated_at ON "indexer_checkpoints";
+
+DROP TABLE IF EXISTS "indexer_owner_snapshots" CASCADE;
+DROP TABLE IF EXISTS "indexer_transfers" CASCADE;
+DROP TABLE IF EXISTS "indexer_blocks" CASCADE;
+DROP TABLE IF EXISTS "indexer_checkpoints" CASCADE;
+
+ALTER TABLE "nfts" DROP COLUMN IF EXISTS "owner_address";
+
+COMMIT;
@@ -0,0 +1,57 @@
+BEGIN;
+
+-- the on-chain owner, it is mirrored to owner_id whenever the address is linked to a user
+ALTER TABLE "nfts" ADD COLUMN "owner_address" varchar(42);
+
+-- one checkpoint per indexed contract, the last block whose transfers were applied
+CREATE TABLE "indexer_checkpoints" (
+  "contract" varchar(42) NOT NULL PRIMARY KEY,
+  "block_number" bigint NOT NULL,
+  "block_hash" varchar(66) NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT now(),
+  "update_at" timestamp NOT NULL DEFAULT now()
+);
+
+-- recent block hashes, compared against the chain to find the fork point of a reorg
+CREATE TABLE "indexer_blocks" (
+  "contract" varchar(42) NOT NULL,
+  "number" bigint NOT NULL,
+  "hash" varchar(66) NOT NULL,
+  "parent_hash" varchar(66) NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT now(),
+  PRIMARY KEY ("contract", "number")
+);
+
+CREATE TABLE "indexer_transfers" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "contract" varchar(42) NOT NULL,
+  "block_number" bigint NOT NULL,
+  "block_hash" varchar(66) NOT NULL,
+  "tx_hash" varchar(66) NOT NULL,
+  "log_index" int NOT NULL,
+  "token_id" bigint NOT NULL,
+  "from_address" varchar(42) NOT NULL,
+  "to_address" varchar(42) NOT NULL,
+  -- the local owner the transfer left the token with, a rewind restores it from the last surviving transfer
+  "owner_id" varchar(7),
+  "created_at" timestamp NOT NULL DEFAULT now(),
+  UNIQUE ("contract", "block_hash", "log_index")
+);
+
+-- the owner a token had before the indexer first moved it, a reorg that drops every transfer
+-- of the token puts this owner back
+CREATE TABLE "indexer_owner_snapshots" (
+  "contract" varchar(42) NOT NULL,
+  "token_id" bigint NOT NULL,
+  "owner_id" varchar(7) NOT NULL,
+  "owner_address" varchar(42),
+  "created_at" timestamp NOT NULL DEFAULT now(),
+  PRIMARY KEY ("contract", "token_id")
+);
+
+CREATE INDEX ON "indexer_transfers" ("contract", "token_id", "block_number", "log_index");
+CREATE INDEX ON "indexer_transfers" ("contract", "block_number");
+
+CREATE TRIGGER update_indexer_checkpoints_updated_at BEFORE UPDATE ON "indexer_checkpoints" FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS update_vouchers_updated_at ON "vouchers";
+
+DROP TABLE IF EXISTS "vouchers" CASCADE;
+
+COMMIT;
@@ -0,0 +1,37 @@
+BEGIN;
+
+-- lazy minted nfts, the token id is taken from the nfts sequence when the voucher is drafted
+-- so it can be signed before the nft row exists
+CREATE TABLE "vouchers" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "token_id" bigint NOT NULL UNIQUE,
+  "creator_id" varchar(7) NOT NULL,
+  "collection_id" varchar(7) NOT NULL,
+  "title" varchar(255) NOT NULL,
+  "description" varchar NOT NULL DEFAULT '',
+  "metadata" jsonb NOT NULL DEFAULT '{}',
+  "media" jsonb NOT NULL DEFAULT '[]',
+  "royalty_bps" int NOT NULL DEFAULT 0,
+  "price" numeric(10, 2) NOT NULL CHECK ("price" > 0),
+  "uri" varchar NOT NULL,
+  "signer" varchar(42),
+  "signature" varchar(132),
+  "status" varchar(20) NOT NULL DEFAULT 'unsigned',
+  "buyer_id" varchar(7),
+  "nft_id" varchar(7),
+  "redeemed_at" timestamptz,
+  "created_at" timestamp NOT NULL DEFAULT now(),
+  "update_at" timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ON "vouchers" ("creator_id");
+CREATE INDEX ON "vouchers" ("status", "collection_id");
+
+ALTER TABLE "vouchers" ADD FOREIGN KEY ("creator_id") REFERENCES "users" ("id");
+ALTER TABLE "vouchers" ADD FOREIGN KEY ("collection_id") REFERENCES "collections" ("id");
+ALTER TABLE "vouchers" ADD FOREIGN KEY ("buyer_id") REFERENCES "users" ("id");
+ALTER TABLE "vouchers" ADD FOREIGN KEY ("nft_id") REFERENCES "nfts" ("id");
+
+CREATE TRIGGER update_vouchers_updated_at BEFORE UPDATE ON "vouchers" FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+COMMIT;
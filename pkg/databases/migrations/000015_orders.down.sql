@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS update_orders_updated_at ON "orders";
+DROP TRIGGER IF EXISTS update_order_counters_updated_at ON "order_counters";
+
+DROP TABLE IF EXISTS "order_counters" CASCADE;
+DROP TABLE IF EXISTS "orders" CASCADE;
+
+COMMIT;
@@ -0,0 +1,40 @@
+BEGIN;
+
+-- off-chain maker orders, offer and consideration are arrays of items as signed by the offerer
+CREATE TABLE "orders" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "order_hash" varchar(66) NOT NULL UNIQUE,
+  "offerer_id" varchar(7) NOT NULL,
+  "offerer" varchar(42) NOT NULL,
+  "side" varchar(10) NOT NULL,
+  "offer" jsonb NOT NULL,
+  "consideration" jsonb NOT NULL,
+  "start_time" timestamptz NOT NULL,
+  "end_time" timestamptz NOT NULL,
+  "salt" varchar(78) NOT NULL,
+  "counter" bigint NOT NULL,
+  "signature" varchar(132) NOT NULL,
+  "status" varchar(20) NOT NULL DEFAULT 'open',
+  "created_at" timestamp NOT NULL DEFAULT now(),
+  "update_at" timestamp NOT NULL DEFAULT now(),
+  CHECK ("end_time" > "start_time")
+);
+
+-- bumping an offerer's counter invalidates every order signed with the previous value
+CREATE TABLE "order_counters" (
+  "address" varchar(42) NOT NULL PRIMARY KEY,
+  "counter" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamp NOT NULL DEFAULT now(),
+  "update_at" timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ON "orders" ("offerer", "status");
+CREATE INDEX ON "orders" USING GIN ("offer" jsonb_path_ops);
+CREATE INDEX ON "orders" USING GIN ("consideration" jsonb_path_ops);
+
+ALTER TABLE "orders" ADD FOREIGN KEY ("offerer_id") REFERENCES "users" ("id");
+
+CREATE TRIGGER update_orders_updated_at BEFORE UPDATE ON "orders" FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+CREATE TRIGGER update_order_counters_updated_at BEFORE UPDATE ON "order_counters" FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+COMMIT;
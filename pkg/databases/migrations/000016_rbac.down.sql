@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "user_roles" CASCADE;
+DROP TABLE IF EXISTS "role_permissions" CASCADE;
+DROP TABLE IF EXISTS "permissions" CASCADE;
+
+COMMIT;
@@ -0,0 +1,71 @@
+BEGIN;
+
+CREATE TABLE "permissions" (
+  "id" serial PRIMARY KEY,
+  "name" varchar(64) NOT NULL UNIQUE,
+  "description" varchar NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL DEFAULT now()
+);
+
+CREATE TABLE "role_permissions" (
+  "role_id" int NOT NULL,
+  "permission_id" int NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT now(),
+  PRIMARY KEY ("role_id", "permission_id")
+);
+
+-- users."role_id" stays as the role a user signed up with, user_roles is what grants permissions
+CREATE TABLE "user_roles" (
+  "user_id" varchar(7) NOT NULL,
+  "role_id" int NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT now(),
+  PRIMARY KEY ("user_id", "role_id")
+);
+
+CREATE INDEX ON "user_roles" ("role_id");
+
+ALTER TABLE "role_permissions" ADD FOREIGN KEY ("role_id") REFERENCES "roles" ("id") ON DELETE CASCADE;
+ALTER TABLE "role_permissions" ADD FOREIGN KEY ("permission_id") REFERENCES "permissions" ("id") ON DELETE CASCADE;
+ALTER TABLE "user_roles" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+ALTER TABLE "user_roles" ADD FOREIGN KEY ("role_id") REFERENCES "roles" ("id");
+
+INSERT INTO "permissions" ("name", "description") VALUES
+  ('collections:write', 'create, update and archive own collections'),
+  ('nfts:write', 'mint nfts'),
+  ('listings:write', 'create, cancel and purchase listings'),
+  ('auctions:write', 'create and cancel auctions and place bids'),
+  ('offers:write', 'make, accept, reject and cancel offers'),
+  ('vouchers:write', 'draft, sign, cancel and redeem lazy mint vouchers'),
+  ('orders:write', 'submit and cancel signed orders'),
+  ('wallets:withdraw', 'withdraw from own wallet'),
+  ('wallets:deposit', 'credit any user wallet'),
+  ('appinfo:write', 'generate api keys and manage categories'),
+  ('files:write', 'upload and delete bucket files'),
+  ('users:admin', 'sign up admins and generate admin tokens'),
+  ('indexer:read', 'read the chain indexer status'),
+  ('roles:manage', 'manage roles, permissions and user roles');
+
+-- customers get every self service permission, admins get everything
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+SELECT "r"."id", "p"."id"
+FROM "roles" "r"
+CROSS JOIN "permissions" "p"
+WHERE "r"."title" = 'admin'
+OR (
+  "r"."title" = 'customer'
+  AND "p"."name" IN (
+    'collections:write',
+    'nfts:write',
+    'listings:write',
+    'auctions:write',
+    'offers:write',
+    'vouchers:write',
+    'orders:write',
+    'wallets:withdraw'
+  )
+);
+
+INSERT INTO "user_roles" ("user_id", "role_id")
+SELECT "id", "role_id" FROM "users";
+
+COMMIT;
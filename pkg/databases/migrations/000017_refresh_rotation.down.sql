@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "oauth_refresh_history" CASCADE;
+
+-- digests cannot be turned back into tokens, every session signs in again
+DELETE FROM "oauth";
+DROP INDEX IF EXISTS "oauth_refresh_token_hash_idx";
+ALTER TABLE "oauth" DROP COLUMN IF EXISTS "refresh_token_hash";
+ALTER TABLE "oauth" ADD COLUMN "refresh_token" varchar NOT NULL;
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+-- refresh tokens are kept as sha-256 digests, a rotated digest moves to the history of its family
+ALTER TABLE "oauth" ADD COLUMN "refresh_token_hash" varchar(64);
+UPDATE "oauth" SET "refresh_token_hash" = encode(sha256("refresh_token"::bytea), 'hex');
+ALTER TABLE "oauth" ALTER COLUMN "refresh_token_hash" SET NOT NULL;
+ALTER TABLE "oauth" DROP COLUMN "refresh_token";
+CREATE UNIQUE INDEX "oauth_refresh_token_hash_idx" ON "oauth" ("refresh_token_hash");
+
+CREATE TABLE "oauth_refresh_history" (
+  "token_hash" varchar(64) PRIMARY KEY,
+  "oauth_id" uuid NOT NULL REFERENCES "oauth" ("id") ON DELETE CASCADE,
+  "rotated_at" timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX "oauth_refresh_history_oauth_id_idx" ON "oauth_refresh_history" ("oauth_id");
+
+COMMIT;
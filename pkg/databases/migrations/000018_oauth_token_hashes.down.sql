@@ -0,0 +1,13 @@
+BEGIN;
+
+ALTER TABLE "oauth_refresh_history" DROP COLUMN IF EXISTS "refresh_jti";
+
+-- digests cannot be turned back into tokens, every session signs in again
+DELETE FROM "oauth";
+DROP INDEX IF EXISTS "oauth_user_id_access_token_hash_idx";
+ALTER TABLE "oauth" DROP COLUMN IF EXISTS "refresh_jti";
+ALTER TABLE "oauth" DROP COLUMN IF EXISTS "access_jti";
+ALTER TABLE "oauth" DROP COLUMN IF EXISTS "access_token_hash";
+ALTER TABLE "oauth" ADD COLUMN "access_token" varchar NOT NULL;
+
+COMMIT;
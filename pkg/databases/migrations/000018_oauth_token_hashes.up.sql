@@ -0,0 +1,16 @@
+BEGIN;
+
+-- raw access tokens cannot be hashed in place for every row written so far, all sessions sign in again
+DELETE FROM "oauth_refresh_history";
+DELETE FROM "oauth";
+
+ALTER TABLE "oauth" DROP COLUMN "access_token";
+ALTER TABLE "oauth" ADD COLUMN "access_token_hash" varchar(64) NOT NULL;
+ALTER TABLE "oauth" ADD COLUMN "access_jti" uuid NOT NULL;
+ALTER TABLE "oauth" ADD COLUMN "refresh_jti" uuid NOT NULL;
+
+CREATE INDEX "oauth_user_id_access_token_hash_idx" ON "oauth" ("user_id", "access_token_hash");
+
+ALTER TABLE "oauth_refresh_history" ADD COLUMN "refresh_jti" uuid;
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+-- every oauth row is a session, the client it was opened from is kept for the session list
+ALTER TABLE "oauth" ADD COLUMN "ip" varchar(45) NOT NULL DEFAULT '';
+ALTER TABLE "oauth" ADD COLUMN "user_agent" text NOT NULL DEFAULT '';
+ALTER TABLE "oauth" ADD COLUMN "last_used_at" timestamp NOT NULL DEFAULT now();
+
+CREATE INDEX "oauth_user_id_idx" ON "oauth" ("user_id");
+
+COMMIT;
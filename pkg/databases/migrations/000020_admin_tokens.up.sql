@@ -0,0 +1,15 @@
+BEGIN;
+
+-- admin tokens are single use, the row is the audit of who minted and who spent each one
+CREATE TABLE "admin_tokens" (
+  "jti" uuid PRIMARY KEY,
+  "minted_by" varchar NOT NULL REFERENCES "users" ("id"),
+  "expires_at" timestamp NOT NULL,
+  "consumed_by" varchar REFERENCES "users" ("id"),
+  "consumed_at" timestamp,
+  "created_at" timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX "admin_tokens_created_at_idx" ON "admin_tokens" ("created_at" DESC);
+
+COMMIT;
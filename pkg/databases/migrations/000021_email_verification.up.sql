@@ -0,0 +1,7 @@
+BEGIN;
+
+-- customers stay pending until the emailed link is opened, accounts that already exist are trusted
+ALTER TABLE "users" ADD COLUMN "email_verified_at" timestamp;
+UPDATE "users" SET "email_verified_at" = now() WHERE "email" IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+-- reset tokens are emailed once and only their digest is kept
+CREATE TABLE "password_resets" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "user_id" varchar NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "token_hash" varchar(64) NOT NULL UNIQUE,
+  "expires_at" timestamp NOT NULL,
+  "used_at" timestamp,
+  "created_at" timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX "password_resets_user_id_idx" ON "password_resets" ("user_id");
+
+COMMIT;
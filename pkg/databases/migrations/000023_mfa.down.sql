@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE "oauth" DROP COLUMN IF EXISTS "mfa_verified_at";
+DROP TABLE IF EXISTS "mfa_recovery_codes" CASCADE;
+DROP TABLE IF EXISTS "user_mfa" CASCADE;
+
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+
+-- the totp secret is kept as is since codes are computed from it, enabled_at stays null until the first code is confirmed
+CREATE TABLE "user_mfa" (
+  "user_id" varchar PRIMARY KEY REFERENCES "users" ("id") ON DELETE CASCADE,
+  "secret" varchar(64) NOT NULL,
+  "enabled_at" timestamp,
+  "last_step" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamp NOT NULL DEFAULT now(),
+  "update_at" timestamp NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER update_user_mfa_updated_at BEFORE UPDATE ON "user_mfa" FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE TABLE "mfa_recovery_codes" (
+  "id" uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "user_id" varchar NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "code_hash" varchar(64) NOT NULL,
+  "used_at" timestamp,
+  UNIQUE ("user_id", "code_hash")
+);
+
+-- step-up verification is tracked per session
+ALTER TABLE "oauth" ADD COLUMN "mfa_verified_at" timestamp;
+
+COMMIT;
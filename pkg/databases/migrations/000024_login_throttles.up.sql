@@ -0,0 +1,16 @@
+BEGIN;
+
+-- failed sign ins are counted per username and per client ip, the key is not tied to a user row
+-- so unknown usernames are throttled the same as real ones
+CREATE TABLE "login_throttles" (
+  "scope" varchar(16) NOT NULL,
+  "key" varchar NOT NULL,
+  "failures" int NOT NULL DEFAULT 0,
+  "locked_until" timestamp,
+  "last_failed_at" timestamp NOT NULL DEFAULT now(),
+  PRIMARY KEY ("scope", "key")
+);
+
+CREATE INDEX "login_throttles_last_failed_at_idx" ON "login_throttles" ("last_failed_at");
+
+COMMIT;
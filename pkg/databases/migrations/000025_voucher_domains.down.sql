@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE "vouchers" DROP COLUMN IF EXISTS "domain_verifying_contract";
+ALTER TABLE "vouchers" DROP COLUMN IF EXISTS "domain_chain_id";
+ALTER TABLE "vouchers" DROP COLUMN IF EXISTS "domain_version";
+ALTER TABLE "vouchers" DROP COLUMN IF EXISTS "domain_name";
+
+COMMIT;
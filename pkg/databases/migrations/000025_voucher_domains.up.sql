@@ -0,0 +1,11 @@
+BEGIN;
+
+-- the EIP-712 domain a voucher was signed under, so renaming the app or setting the nft contract
+-- later does not invalidate vouchers that are already signed; vouchers signed before this
+-- keep verifying against the current domain
+ALTER TABLE "vouchers" ADD COLUMN "domain_name" varchar;
+ALTER TABLE "vouchers" ADD COLUMN "domain_version" varchar;
+ALTER TABLE "vouchers" ADD COLUMN "domain_chain_id" bigint;
+ALTER TABLE "vouchers" ADD COLUMN "domain_verifying_contract" varchar(42);
+
+COMMIT;
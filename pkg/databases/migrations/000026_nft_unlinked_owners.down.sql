@@ -0,0 +1,15 @@
+BEGIN;
+
+-- tokens without a local owner go back to their creator
+UPDATE "indexer_owner_snapshots" "s" SET
+  "owner_id" = "n"."author_id"
+FROM "nfts" "n"
+WHERE "n"."token_id" = "s"."token_id"
+AND "s"."owner_id" IS NULL;
+DELETE FROM "indexer_owner_snapshots" WHERE "owner_id" IS NULL;
+UPDATE "nfts" SET "owner_id" = "author_id" WHERE "owner_id" IS NULL;
+
+ALTER TABLE "indexer_owner_snapshots" ALTER COLUMN "owner_id" SET NOT NULL;
+ALTER TABLE "nfts" ALTER COLUMN "owner_id" SET NOT NULL;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+-- a token that moves on-chain to an address no user has linked has no local owner, it cannot
+-- be listed, auctioned or offered on until the address is linked or it moves back
+ALTER TABLE "nfts" ALTER COLUMN "owner_id" DROP NOT NULL;
+ALTER TABLE "indexer_owner_snapshots" ALTER COLUMN "owner_id" DROP NOT NULL;
+
+COMMIT;
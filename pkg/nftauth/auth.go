@@ -1,12 +1,16 @@
 package nftauth
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/muhammadfarhankt/nft-marketplace/config"
 	"github.com/muhammadfarhankt/nft-marketplace/modules/users"
 )
@@ -36,6 +40,8 @@ type nftApiKey struct {
 type INftAuth interface {
 	//NewAuth(tokenType TokenType, cfg config.IJwtConfig, claims *users.UserClaims) (nftAuth, error)
 	SignToken() string
+	Jti() string
+	ExpiresAt() time.Time
 }
 
 type INftAdmin interface {
@@ -65,7 +71,15 @@ func (n *nftAdmin) SignToken() string {
 	return tokenString
 }
 
+// SignToken uses the configured private key when there is one, the kid header tells verifiers which public key to use
 func (n *nftAuth) SignToken() string {
+	if n.cfg.SigningMethod() != "HS256" {
+		token := jwt.NewWithClaims(jwt.GetSigningMethod(n.cfg.SigningMethod()), n.mapClaims)
+		token.Header["kid"] = n.cfg.SigningKid()
+		tokenString, _ := token.SignedString(n.cfg.SigningKey())
+		return tokenString
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, n.mapClaims)
 	tokenString, _ := token.SignedString(n.cfg.SecretKey())
 	return tokenString
@@ -81,30 +95,49 @@ func (n *nftApiKey) SignToken() string {
 	return tokenString
 }
 
-func RepeatToken(cfg config.IJwtConfig, claims *users.UserClaims, exp int64) string {
-	obj := &nftAuth{
-		cfg: cfg,
-		mapClaims: &nftMapClaims{
-			Claims: claims,
-			RegisteredClaims: jwt.RegisteredClaims{
-				Issuer:    "nft-marketplace",
-				Subject:   "refresh-token",
-				Audience:  []string{"customer", "admin"},
-				ExpiresAt: jwtTimeRepeatAdapter(exp),
-				NotBefore: jwt.NewNumericDate(time.Now()),
-				IssuedAt:  jwt.NewNumericDate(time.Now()),
-			},
-		},
+// Jti identifies the token, so two tokens signed in the same second never collide
+func (n *nftAuth) Jti() string {
+	return n.mapClaims.ID
+}
+
+func (n *nftAuth) ExpiresAt() time.Time {
+	return n.mapClaims.ExpiresAt.Time
+}
+
+// HashToken is the digest stored in place of a signed token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// purposeKey derives a separate HS256 key per single purpose token from the secret key,
+// so an emailed or challenge token can never verify as a session token
+func purposeKey(cfg config.IJwtConfig, purpose string) []byte {
+	mac := hmac.New(sha256.New, cfg.SecretKey())
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// tokenKey picks the verification key from the kid header, a token must use the configured alg
+func tokenKey(cfg config.IJwtConfig, token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() != cfg.SigningMethod() {
+		return nil, fmt.Errorf("invalid signing method ")
+	}
+	if cfg.SigningMethod() == "HS256" {
+		return cfg.SecretKey(), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := cfg.VerifyKeys()[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
 	}
-	return obj.SignToken()
+	return key, nil
 }
 
 func ParseToken(cfg config.IJwtConfig, tokenString string) (*nftMapClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &nftMapClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("invalid signing method ")
-		}
-		return cfg.SecretKey(), nil
+		return tokenKey(cfg, token)
 	})
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenMalformed) {
@@ -119,11 +152,15 @@ func ParseToken(cfg config.IJwtConfig, tokenString string) (*nftMapClaims, error
 		// }
 	}
 
-	if claims, ok := token.Claims.(*nftMapClaims); ok {
-		return claims, nil
-	} else {
-		return nil, fmt.Errorf("invalid claim type %v", err)
+	// only session tokens carry user claims, any other token signed with the same key is refused
+	claims, ok := token.Claims.(*nftMapClaims)
+	if !ok || claims.Claims == nil {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+	if claims.Subject != "access-token" && claims.Subject != "refresh-token" {
+		return nil, fmt.Errorf("invalid token subject")
 	}
+	return claims, nil
 }
 
 func ParseAdminToken(cfg config.IJwtConfig, tokenString string) (*nftMapClaims, error) {
@@ -205,6 +242,26 @@ func NewAuth(tokenType TokenType, cfg config.IJwtConfig, claims *users.UserClaim
 	// nil,
 }
 
+// RepeatToken reissues a refresh token with the expiry of the one it replaces,
+// rotation hands out a new jti but never extends the session
+func RepeatToken(cfg config.IJwtConfig, claims *users.UserClaims, exp int64) INftAuth {
+	return &nftAuth{
+		cfg: cfg,
+		mapClaims: &nftMapClaims{
+			Claims: claims,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    "nft-marketplace",
+				Subject:   "refresh-token",
+				Audience:  []string{"customer", "admin"},
+				ExpiresAt: jwtTimeRepeatAdapter(exp),
+				NotBefore: jwt.NewNumericDate(time.Now()),
+				IssuedAt:  jwt.NewNumericDate(time.Now()),
+				ID:        uuid.NewString(),
+			},
+		},
+	}
+}
+
 func newAccessToken(cfg config.IJwtConfig, claims *users.UserClaims) INftAuth {
 	return &nftAuth{
 		cfg: cfg,
@@ -217,6 +274,7 @@ func newAccessToken(cfg config.IJwtConfig, claims *users.UserClaims) INftAuth {
 				ExpiresAt: jwtTimeDurationCalc(cfg.AccessExpiresAt()),
 				NotBefore: jwt.NewNumericDate(time.Now()),
 				IssuedAt:  jwt.NewNumericDate(time.Now()),
+				ID:        uuid.NewString(),
 			},
 		},
 	}
@@ -234,6 +292,7 @@ func newRefreshToken(cfg config.IJwtConfig, claims *users.UserClaims) INftAuth {
 				ExpiresAt: jwtTimeDurationCalc(cfg.RefreshExpiresAt()),
 				NotBefore: jwt.NewNumericDate(time.Now()),
 				IssuedAt:  jwt.NewNumericDate(time.Now()),
+				ID:        uuid.NewString(),
 			},
 		},
 	}
@@ -252,6 +311,7 @@ func newAdminToken(cfg config.IJwtConfig) INftAuth {
 					ExpiresAt: jwtTimeDurationCalc(300),
 					NotBefore: jwt.NewNumericDate(time.Now()),
 					IssuedAt:  jwt.NewNumericDate(time.Now()),
+					ID:        uuid.NewString(),
 				},
 			},
 		},
@@ -271,6 +331,7 @@ func newApiKey(cfg config.IJwtConfig) INftAuth {
 					ExpiresAt: jwt.NewNumericDate(time.Now().AddDate(0, 0, 7)),
 					NotBefore: jwt.NewNumericDate(time.Now()),
 					IssuedAt:  jwt.NewNumericDate(time.Now()),
+					ID:        uuid.NewString(),
 				},
 			},
 		},
@@ -0,0 +1,57 @@
+package nftauth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+)
+
+const emailVerificationSubject = "email-verification"
+
+// EmailClaims binds a verification link to the address it was sent to, changing the email voids old links
+type EmailClaims struct {
+	UserId string `json:"user_id"`
+	Email  string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// NewEmailToken is always HS256 with a key of its own, nobody outside this service verifies it
+func NewEmailToken(cfg config.IJwtConfig, userId, email string, expires time.Duration) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &EmailClaims{
+		UserId: userId,
+		Email:  email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "nft-marketplace",
+			Subject:   emailVerificationSubject,
+			Audience:  []string{emailVerificationSubject},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expires)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	})
+	tokenString, _ := token.SignedString(purposeKey(cfg, emailVerificationSubject))
+	return tokenString
+}
+
+func ParseEmailToken(cfg config.IJwtConfig, tokenString string) (*EmailClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &EmailClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("invalid signing method ")
+		}
+		return purposeKey(cfg, emailVerificationSubject), nil
+	}, jwt.WithAudience(emailVerificationSubject))
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, fmt.Errorf("verification link expired")
+		}
+		return nil, fmt.Errorf("invalid verification link")
+	}
+
+	claims, ok := token.Claims.(*EmailClaims)
+	if !ok || claims.Subject != emailVerificationSubject {
+		return nil, fmt.Errorf("invalid verification link")
+	}
+	return claims, nil
+}
@@ -0,0 +1,64 @@
+package nftauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"sort"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+)
+
+// Jwk is a public key in RFC 7517 form
+type Jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JwkSet struct {
+	Keys []*Jwk `json:"keys"`
+}
+
+// Jwks lists every key access tokens are verified with, it is empty while tokens use the shared secret
+func Jwks(cfg config.IJwtConfig) *JwkSet {
+	set := &JwkSet{
+		Keys: make([]*Jwk, 0),
+	}
+	if cfg.SigningMethod() == "HS256" {
+		return set
+	}
+
+	for kid, key := range cfg.VerifyKeys() {
+		switch key := key.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, &Jwk{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				Alg: "RS256",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, &Jwk{
+				Kty: "OKP",
+				Kid: kid,
+				Use: "sig",
+				Alg: "EdDSA",
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(key),
+			})
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+	return set
+}
@@ -0,0 +1,55 @@
+package nftauth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+)
+
+const mfaChallengeSubject = "mfa-challenge"
+
+// MfaClaims proves the password step of a sign in passed, only a second factor turns it into a passport
+type MfaClaims struct {
+	UserId string `json:"user_id"`
+	jwt.RegisteredClaims
+}
+
+func NewMfaToken(cfg config.IJwtConfig, userId string, expires time.Duration) (string, time.Time) {
+	expiresAt := time.Now().Add(expires)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &MfaClaims{
+		UserId: userId,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "nft-marketplace",
+			Subject:   mfaChallengeSubject,
+			Audience:  []string{mfaChallengeSubject},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	})
+	tokenString, _ := token.SignedString(purposeKey(cfg, mfaChallengeSubject))
+	return tokenString, expiresAt
+}
+
+func ParseMfaToken(cfg config.IJwtConfig, tokenString string) (*MfaClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &MfaClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("invalid signing method ")
+		}
+		return purposeKey(cfg, mfaChallengeSubject), nil
+	}, jwt.WithAudience(mfaChallengeSubject))
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, fmt.Errorf("mfa challenge expired")
+		}
+		return nil, fmt.Errorf("invalid mfa challenge")
+	}
+
+	claims, ok := token.Claims.(*MfaClaims)
+	if !ok || claims.Subject != mfaChallengeSubject {
+		return nil, fmt.Errorf("invalid mfa challenge")
+	}
+	return claims, nil
+}
@@ -0,0 +1,114 @@
+package nftcache
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type INftCache interface {
+	Get(key string) (any, bool)
+	Set(key string, value any)
+	Delete(key string)
+	DeletePrefix(prefix string)
+	Sweep() int
+	Stats() *Stats
+}
+
+type Stats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"`
+}
+
+type nftEntry struct {
+	value     any
+	expiresAt time.Time
+}
+
+type nftCache struct {
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[string]*nftEntry
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+}
+
+func NewCache(ttl time.Duration) INftCache {
+	return &nftCache{
+		ttl:     ttl,
+		entries: make(map[string]*nftEntry),
+	}
+}
+
+// Get counts a miss for absent and expired keys alike
+func (c *nftCache) Get(key string) (any, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	return entry.value, true
+}
+
+func (c *nftCache) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = &nftEntry{
+		value:     value,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+}
+
+func (c *nftCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// DeletePrefix drops every key sharing the prefix, used to invalidate a whole namespace
+func (c *nftCache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Sweep removes expired entries and returns how many were dropped
+func (c *nftCache) Sweep() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	removed := 0
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (c *nftCache) Stats() *Stats {
+	c.mu.RLock()
+	entries := len(c.entries)
+	c.mu.RUnlock()
+
+	return &Stats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: entries,
+	}
+}
@@ -0,0 +1,336 @@
+package nfteth
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	arrayTypeRegexp = regexp.MustCompile(`^(.+)\[(\d*)\]$`)
+	intTypeRegexp   = regexp.MustCompile(`^(u?)int(\d*)$`)
+	bytesTypeRegexp = regexp.MustCompile(`^bytes(\d+)$`)
+)
+
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedData is an EIP-712 payload in the shape eth_signTypedData_v4 expects, so it can be
+// handed to the wallet as is, integers in the message are decimal strings to survive json
+type TypedData struct {
+	Types       map[string][]*TypedDataField `json:"types"`
+	PrimaryType string                       `json:"primaryType"`
+	Domain      map[string]any               `json:"domain"`
+	Message     map[string]any               `json:"message"`
+}
+
+type TypedDataDomain struct {
+	Name              string
+	Version           string
+	ChainId           int64
+	VerifyingContract string
+}
+
+// NewTypedData builds the EIP712Domain type from the domain fields that are set, the order
+// of the fields is fixed by the standard
+func NewTypedData(domain *TypedDataDomain, primaryType string, types map[string][]*TypedDataField, message map[string]any) *TypedData {
+	domainFields := make([]*TypedDataField, 0)
+	domainValues := make(map[string]any)
+	if domain.Name != "" {
+		domainFields = append(domainFields, &TypedDataField{Name: "name", Type: "string"})
+		domainValues["name"] = domain.Name
+	}
+	if domain.Version != "" {
+		domainFields = append(domainFields, &TypedDataField{Name: "version", Type: "string"})
+		domainValues["version"] = domain.Version
+	}
+	if domain.ChainId != 0 {
+		domainFields = append(domainFields, &TypedDataField{Name: "chainId", Type: "uint256"})
+		domainValues["chainId"] = domain.ChainId
+	}
+	if domain.VerifyingContract != "" {
+		domainFields = append(domainFields, &TypedDataField{Name: "verifyingContract", Type: "address"})
+		domainValues["verifyingContract"] = domain.VerifyingContract
+	}
+
+	allTypes := map[string][]*TypedDataField{
+		"EIP712Domain": domainFields,
+	}
+	for name, fields := range types {
+		allTypes[name] = fields
+	}
+	return &TypedData{
+		Types:       allTypes,
+		PrimaryType: primaryType,
+		Domain:      domainValues,
+		Message:     message,
+	}
+}
+
+// Hash is the digest signed by eth_signTypedData_v4, keccak256(0x1901 || domainSeparator || hashStruct(message))
+func (obj *TypedData) Hash() ([]byte, error) {
+	domainSeparator, err := obj.hashStruct("EIP712Domain", obj.Domain)
+	if err != nil {
+		return nil, err
+	}
+	messageHash, err := obj.hashStruct(obj.PrimaryType, obj.Message)
+	if err != nil {
+		return nil, err
+	}
+	return Keccak256([]byte{0x19, 0x01}, domainSeparator, messageHash), nil
+}
+
+// StructHash is hashStruct of the message alone, which marketplaces use as the order hash
+func (obj *TypedData) StructHash() ([]byte, error) {
+	return obj.hashStruct(obj.PrimaryType, obj.Message)
+}
+
+// EncodeType is the primary type followed by its referenced struct types sorted by name
+func (obj *TypedData) EncodeType(primaryType string) string {
+	deps := make(map[string]bool)
+	obj.findDependencies(primaryType, deps)
+	delete(deps, primaryType)
+
+	sorted := make([]string, 0, len(deps))
+	for dep := range deps {
+		sorted = append(sorted, dep)
+	}
+	sort.Strings(sorted)
+
+	var b strings.Builder
+	for _, name := range append([]string{primaryType}, sorted...) {
+		fields := make([]string, 0, len(obj.Types[name]))
+		for _, field := range obj.Types[name] {
+			fields = append(fields, field.Type+" "+field.Name)
+		}
+		b.WriteString(name + "(" + strings.Join(fields, ",") + ")")
+	}
+	return b.String()
+}
+
+func (obj *TypedData) findDependencies(typeName string, deps map[string]bool) {
+	typeName = baseType(typeName)
+	if deps[typeName] {
+		return
+	}
+	if _, ok := obj.Types[typeName]; !ok {
+		return
+	}
+	deps[typeName] = true
+	for _, field := range obj.Types[typeName] {
+		obj.findDependencies(field.Type, deps)
+	}
+}
+
+func baseType(typeName string) string {
+	for {
+		match := arrayTypeRegexp.FindStringSubmatch(typeName)
+		if match == nil {
+			return typeName
+		}
+		typeName = match[1]
+	}
+}
+
+func (obj *TypedData) hashStruct(typeName string, data map[string]any) ([]byte, error) {
+	fields, ok := obj.Types[typeName]
+	if !ok {
+		return nil, fmt.Errorf("typed data type %s is not defined", typeName)
+	}
+
+	encoded := Keccak256([]byte(obj.EncodeType(typeName)))
+	for _, field := range fields {
+		value, ok := data[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("typed data %s is missing %s", typeName, field.Name)
+		}
+		word, err := obj.encodeValue(field.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("typed data %s.%s: %v", typeName, field.Name, err)
+		}
+		encoded = append(encoded, word...)
+	}
+	return Keccak256(encoded), nil
+}
+
+// encodeValue returns the 32 byte word of one field, dynamic and nested values are hashed
+func (obj *TypedData) encodeValue(typeName string, value any) ([]byte, error) {
+	if match := arrayTypeRegexp.FindStringSubmatch(typeName); match != nil {
+		items, ok := value.([]any)
+		if !ok {
+			if maps, isMaps := value.([]map[string]any); isMaps {
+				items = make([]any, len(maps))
+				for i, m := range maps {
+					items[i] = m
+				}
+			} else {
+				return nil, fmt.Errorf("expected an array")
+			}
+		}
+		if match[2] != "" {
+			if n, _ := strconv.Atoi(match[2]); n != len(items) {
+				return nil, fmt.Errorf("expected %d items", n)
+			}
+		}
+		encoded := make([]byte, 0, 32*len(items))
+		for _, item := range items {
+			word, err := obj.encodeValue(match[1], item)
+			if err != nil {
+				return nil, err
+			}
+			encoded = append(encoded, word...)
+		}
+		return Keccak256(encoded), nil
+	}
+
+	if _, ok := obj.Types[typeName]; ok {
+		data, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected a %s struct", typeName)
+		}
+		return obj.hashStruct(typeName, data)
+	}
+
+	switch typeName {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string")
+		}
+		return Keccak256([]byte(s)), nil
+	case "bytes":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected hex bytes")
+		}
+		raw, err := DecodeHex(s)
+		if err != nil {
+			return nil, fmt.Errorf("expected hex bytes")
+		}
+		return Keccak256(raw), nil
+	case "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a boolean")
+		}
+		word := make([]byte, 32)
+		if b {
+			word[31] = 1
+		}
+		return word, nil
+	case "address":
+		s, ok := value.(string)
+		if !ok || !IsHexAddress(s) {
+			return nil, fmt.Errorf("expected an address")
+		}
+		raw, _ := DecodeHex(s)
+		return leftPad(raw), nil
+	}
+
+	if match := bytesTypeRegexp.FindStringSubmatch(typeName); match != nil {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected hex bytes")
+		}
+		raw, err := DecodeHex(s)
+		if n, _ := strconv.Atoi(match[1]); err != nil || len(raw) != n {
+			return nil, fmt.Errorf("expected %s", typeName)
+		}
+		word := make([]byte, 32)
+		copy(word, raw)
+		return word, nil
+	}
+
+	if match := intTypeRegexp.FindStringSubmatch(typeName); match != nil {
+		n, err := toBigInt(value)
+		if err != nil {
+			return nil, err
+		}
+		bits := 256
+		if match[2] != "" {
+			bits, _ = strconv.Atoi(match[2])
+		}
+		if match[1] == "u" {
+			if n.Sign() < 0 || n.BitLen() > bits {
+				return nil, fmt.Errorf("value out of range for %s", typeName)
+			}
+			return leftPad(n.Bytes()), nil
+		}
+		limit := new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+		if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+			return nil, fmt.Errorf("value out of range for %s", typeName)
+		}
+		// negative numbers are encoded in two's complement over 256 bits
+		if n.Sign() < 0 {
+			n = new(big.Int).Add(n, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		return leftPad(n.Bytes()), nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", typeName)
+}
+
+func leftPad(b []byte) []byte {
+	word := make([]byte, 32)
+	copy(word[32-len(b):], b)
+	return word
+}
+
+func toBigInt(value any) (*big.Int, error) {
+	switch v := value.(type) {
+	case *big.Int:
+		return v, nil
+	case int:
+		return big.NewInt(int64(v)), nil
+	case int64:
+		return big.NewInt(v), nil
+	case uint64:
+		return new(big.Int).SetUint64(v), nil
+	case float64:
+		if v != float64(int64(v)) {
+			return nil, fmt.Errorf("expected an integer")
+		}
+		return big.NewInt(int64(v)), nil
+	case string:
+		n, ok := new(big.Int).SetString(v, 0)
+		if !ok {
+			return nil, fmt.Errorf("expected an integer")
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("expected an integer")
+}
+
+// VerifyTypedData checks that signatureHex is address's eth_signTypedData_v4 signature over data
+func VerifyTypedData(address string, data *TypedData, signatureHex string) error {
+	expected, err := NormalizeAddress(address)
+	if err != nil {
+		return err
+	}
+	recovered, err := RecoverTypedData(data, signatureHex)
+	if err != nil {
+		return err
+	}
+	if recovered != expected {
+		return fmt.Errorf("signature does not match address")
+	}
+	return nil
+}
+
+// RecoverTypedData returns the checksummed address that signed data
+func RecoverTypedData(data *TypedData, signatureHex string) (string, error) {
+	signature, err := DecodeHex(signatureHex)
+	if err != nil {
+		return "", fmt.Errorf("signature must be hex encoded")
+	}
+	hash, err := data.Hash()
+	if err != nil {
+		return "", err
+	}
+	return RecoverAddress(hash, signature)
+}
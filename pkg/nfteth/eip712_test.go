@@ -0,0 +1,163 @@
+package nfteth
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// mailTypedData is the Mail example of the EIP-712 specification, signed with keccak256("cow")
+func mailTypedData() *TypedData {
+	return NewTypedData(
+		&TypedDataDomain{
+			Name:              "Ether Mail",
+			Version:           "1",
+			ChainId:           1,
+			VerifyingContract: "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC",
+		},
+		"Mail",
+		map[string][]*TypedDataField{
+			"Person": {
+				{Name: "name", Type: "string"},
+				{Name: "wallet", Type: "address"},
+			},
+			"Mail": {
+				{Name: "from", Type: "Person"},
+				{Name: "to", Type: "Person"},
+				{Name: "contents", Type: "string"},
+			},
+		},
+		map[string]any{
+			"from": map[string]any{
+				"name":   "Cow",
+				"wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826",
+			},
+			"to": map[string]any{
+				"name":   "Bob",
+				"wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB",
+			},
+			"contents": "Hello, Bob!",
+		},
+	)
+}
+
+const (
+	mailSigner    = "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"
+	mailSignature = "0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d" +
+		"07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562" + "1c"
+)
+
+func TestEncodeType(t *testing.T) {
+	data := mailTypedData()
+	if got, want := data.EncodeType("Mail"), "Mail(Person from,Person to,string contents)Person(string name,address wallet)"; got != want {
+		t.Errorf("EncodeType(Mail) = %s, want %s", got, want)
+	}
+	if got, want := hex.EncodeToString(Keccak256([]byte(data.EncodeType("Mail")))), "a0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2"; got != want {
+		t.Errorf("typeHash(Mail) = %s, want %s", got, want)
+	}
+	if got, want := data.EncodeType("EIP712Domain"), "EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"; got != want {
+		t.Errorf("EncodeType(EIP712Domain) = %s, want %s", got, want)
+	}
+}
+
+func TestTypedDataHash(t *testing.T) {
+	data := mailTypedData()
+
+	domainSeparator, err := data.hashStruct("EIP712Domain", data.Domain)
+	if err != nil {
+		t.Fatalf("domain separator: %v", err)
+	}
+	if got, want := hex.EncodeToString(domainSeparator), "f2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f"; got != want {
+		t.Errorf("domain separator = %s, want %s", got, want)
+	}
+
+	structHash, err := data.StructHash()
+	if err != nil {
+		t.Fatalf("struct hash: %v", err)
+	}
+	if got, want := hex.EncodeToString(structHash), "c52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e"; got != want {
+		t.Errorf("hashStruct(message) = %s, want %s", got, want)
+	}
+
+	digest, err := data.Hash()
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if got, want := hex.EncodeToString(digest), "be609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"; got != want {
+		t.Errorf("digest = %s, want %s", got, want)
+	}
+}
+
+func TestRecoverTypedData(t *testing.T) {
+	signer, err := RecoverTypedData(mailTypedData(), mailSignature)
+	if err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	if signer != mailSigner {
+		t.Errorf("signer = %s, want %s", signer, mailSigner)
+	}
+	if err := VerifyTypedData(strings.ToLower(mailSigner), mailTypedData(), mailSignature); err != nil {
+		t.Errorf("verify: %v", err)
+	}
+
+	tampered := mailTypedData()
+	tampered.Message["contents"] = "Hello, Alice!"
+	if err := VerifyTypedData(mailSigner, tampered, mailSignature); err == nil {
+		t.Error("expected a tampered message to fail verification")
+	}
+}
+
+func TestEncodeValue(t *testing.T) {
+	data := mailTypedData()
+	tests := []struct {
+		typeName string
+		value    any
+		want     string
+	}{
+		{"uint8", 255, "00000000000000000000000000000000000000000000000000000000000000ff"},
+		{"uint256", "0x10", "0000000000000000000000000000000000000000000000000000000000000010"},
+		{"int8", -1, "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{"int8", -128, "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff80"},
+		{"int256", "127", "000000000000000000000000000000000000000000000000000000000000007f"},
+		{"bool", true, "0000000000000000000000000000000000000000000000000000000000000001"},
+		{"bytes4", "0xdeadbeef", "deadbeef00000000000000000000000000000000000000000000000000000000"},
+	}
+	for _, tt := range tests {
+		word, err := data.encodeValue(tt.typeName, tt.value)
+		if err != nil {
+			t.Errorf("encodeValue(%s, %v): %v", tt.typeName, tt.value, err)
+			continue
+		}
+		if got := hex.EncodeToString(word); got != tt.want {
+			t.Errorf("encodeValue(%s, %v) = %s, want %s", tt.typeName, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeValueRejects(t *testing.T) {
+	data := mailTypedData()
+	tests := []struct {
+		typeName string
+		value    any
+	}{
+		{"uint8", 256},
+		{"uint256", -1},
+		{"uint256", "0x1" + strings.Repeat("0", 64)},
+		{"int8", 128},
+		{"int8", -129},
+		{"int256", 1.5},
+		{"bytes4", "0xdeadbe"},
+		{"bytes4", "0xdeadbeef00"},
+		{"bytes32", "not hex"},
+		{"address", "0x1234"},
+		{"Person[2]", []any{map[string]any{"name": "Cow", "wallet": mailSigner}}},
+		{"Person", "Cow"},
+		{"uint", "ten"},
+		{"fixed128x18", 1},
+	}
+	for _, tt := range tests {
+		if _, err := data.encodeValue(tt.typeName, tt.value); err == nil {
+			t.Errorf("encodeValue(%s, %v) expected an error", tt.typeName, tt.value)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package nfteth
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+var addressRegexp = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// Keccak256 is the legacy keccak hash used by ethereum, not the standardised sha3-256
+func Keccak256(data ...[]byte) []byte {
+	hasher := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		hasher.Write(d)
+	}
+	return hasher.Sum(nil)
+}
+
+// PersonalMessageHash is the EIP-191 version 0x45 hash signed by personal_sign
+func PersonalMessageHash(message []byte) []byte {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))
+	return Keccak256([]byte(prefix), message)
+}
+
+func IsHexAddress(address string) bool {
+	return addressRegexp.MatchString(address)
+}
+
+// ChecksumAddress returns the EIP-55 mixed case form of a 20 byte address
+func ChecksumAddress(address []byte) string {
+	lower := hex.EncodeToString(address)
+	hash := hex.EncodeToString(Keccak256([]byte(lower)))
+
+	result := []byte(lower)
+	for i, c := range result {
+		if c >= 'a' && c <= 'f' && hash[i] >= '8' {
+			result[i] = c - 32
+		}
+	}
+	return "0x" + string(result)
+}
+
+// NormalizeAddress validates a hex address and returns its checksummed form, mixed case input
+// must already carry a valid checksum
+func NormalizeAddress(address string) (string, error) {
+	if !IsHexAddress(address) {
+		return "", fmt.Errorf("invalid address")
+	}
+	raw, _ := hex.DecodeString(address[2:])
+	checksummed := ChecksumAddress(raw)
+
+	body := address[2:]
+	if body != strings.ToLower(body) && body != strings.ToUpper(body) && address != checksummed {
+		return "", fmt.Errorf("invalid address checksum")
+	}
+	return checksummed, nil
+}
+
+func DecodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"))
+}
+
+// RecoverAddress returns the checksummed address that produced a 65 byte r || s || v signature over hash
+func RecoverAddress(hash, signature []byte) (string, error) {
+	if len(signature) != 65 {
+		return "", fmt.Errorf("signature must be 65 bytes")
+	}
+
+	// wallets send v as 27/28, some hardware wallets as 0/1
+	v := signature[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return "", fmt.Errorf("invalid signature recovery id")
+	}
+
+	// decred expects the recovery code first, 27 + id marks an uncompressed key
+	compact := make([]byte, 65)
+	compact[0] = 27 + v
+	copy(compact[1:], signature[:64])
+
+	publicKey, _, err := ecdsa.RecoverCompact(compact, hash)
+	if err != nil {
+		return "", fmt.Errorf("recover public key failed: %v", err)
+	}
+	// the address is the last 20 bytes of the hash of the uncompressed key without its 0x04 prefix
+	return ChecksumAddress(Keccak256(publicKey.SerializeUncompressed()[1:])[12:]), nil
+}
+
+// VerifyPersonalSign checks that signatureHex is address's personal_sign signature over message
+func VerifyPersonalSign(address, message, signatureHex string) error {
+	expected, err := NormalizeAddress(address)
+	if err != nil {
+		return err
+	}
+	signature, err := DecodeHex(signatureHex)
+	if err != nil {
+		return fmt.Errorf("signature must be hex encoded")
+	}
+
+	recovered, err := RecoverAddress(PersonalMessageHash([]byte(message)), signature)
+	if err != nil {
+		return err
+	}
+	if recovered != expected {
+		return fmt.Errorf("signature does not match address")
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package nfteth
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// eip55Vectors are the test cases of the EIP-55 specification
+var eip55Vectors = []string{
+	"0x52908400098527886E0F7030069857D2E4169EE7",
+	"0x8617E340B3D01FA5F11F306F4090FD50E238070D",
+	"0xde709f2102306220921060314715629080e2fb77",
+	"0x27b1fdb04752bbc536007a920d24acb045561c26",
+	"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+	"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+	"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+}
+
+func TestChecksumAddress(t *testing.T) {
+	for _, want := range eip55Vectors {
+		raw, _ := hex.DecodeString(want[2:])
+		if got := ChecksumAddress(raw); got != want {
+			t.Errorf("ChecksumAddress(%x) = %s, want %s", raw, got, want)
+		}
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	for _, want := range eip55Vectors {
+		for _, address := range []string{want, "0x" + strings.ToLower(want[2:]), "0x" + strings.ToUpper(want[2:])} {
+			got, err := NormalizeAddress(address)
+			if err != nil {
+				t.Errorf("NormalizeAddress(%s): %v", address, err)
+				continue
+			}
+			if got != want {
+				t.Errorf("NormalizeAddress(%s) = %s, want %s", address, got, want)
+			}
+		}
+	}
+
+	for _, address := range []string{
+		// one letter of a checksummed address flipped to the other case
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD",
+		"0xfb6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA",
+		"5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xZaAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	} {
+		if _, err := NormalizeAddress(address); err == nil {
+			t.Errorf("NormalizeAddress(%s) expected an error", address)
+		}
+	}
+}
+
+// cowKey is keccak256("cow"), the key behind mailSigner in the EIP-712 specification
+func cowKey() *secp256k1.PrivateKey {
+	return secp256k1.PrivKeyFromBytes(Keccak256([]byte("cow")))
+}
+
+// personalSign signs like a wallet does, r || s || v with v as 27/28
+func personalSign(key *secp256k1.PrivateKey, message string) []byte {
+	compact := ecdsa.SignCompact(key, PersonalMessageHash([]byte(message)), false)
+	return append(compact[1:], compact[0])
+}
+
+func TestRecoverAddress(t *testing.T) {
+	signature, _ := DecodeHex(mailSignature)
+	hash, err := mailTypedData().Hash()
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+
+	for _, v := range []byte{28, 1} {
+		sig := append(append([]byte{}, signature[:64]...), v)
+		got, err := RecoverAddress(hash, sig)
+		if err != nil {
+			t.Errorf("recover with v=%d: %v", v, err)
+			continue
+		}
+		if got != mailSigner {
+			t.Errorf("recover with v=%d = %s, want %s", v, got, mailSigner)
+		}
+	}
+
+	// the other recovery id yields some other key
+	for _, v := range []byte{27, 0} {
+		sig := append(append([]byte{}, signature[:64]...), v)
+		if got, err := RecoverAddress(hash, sig); err == nil && got == mailSigner {
+			t.Errorf("recover with v=%d should not yield the signer", v)
+		}
+	}
+
+	for _, sig := range [][]byte{
+		signature[:64],
+		append(append([]byte{}, signature[:64]...), 2),
+		append(append([]byte{}, signature[:64]...), 29),
+	} {
+		if _, err := RecoverAddress(hash, sig); err == nil {
+			t.Errorf("RecoverAddress(%x) expected an error", sig)
+		}
+	}
+}
+
+func TestVerifyPersonalSign(t *testing.T) {
+	const message = "nft-marketplace wants you to link 0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"
+	signature := personalSign(cowKey(), message)
+	if v := signature[64]; v != 27 && v != 28 {
+		t.Fatalf("wallet signature v = %d, want 27 or 28", v)
+	}
+
+	zeroBased := append([]byte{}, signature...)
+	zeroBased[64] -= 27
+
+	for _, sig := range [][]byte{signature, zeroBased} {
+		if err := VerifyPersonalSign(mailSigner, message, "0x"+hex.EncodeToString(sig)); err != nil {
+			t.Errorf("verify with v=%d: %v", sig[64], err)
+		}
+	}
+
+	if err := VerifyPersonalSign(mailSigner, message+" ", "0x"+hex.EncodeToString(signature)); err == nil {
+		t.Error("expected another message to fail verification")
+	}
+	if err := VerifyPersonalSign("0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB", message, "0x"+hex.EncodeToString(signature)); err == nil {
+		t.Error("expected another address to fail verification")
+	}
+	if err := VerifyPersonalSign(mailSigner, message, "0xnothex"); err == nil {
+		t.Error("expected a signature that is not hex to fail verification")
+	}
+}
@@ -0,0 +1,155 @@
+package nfteth
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const siweHeaderSuffix = " wants you to sign in with your Ethereum account:"
+
+// siweClockSkew is how far ahead of the server clock a wallet may date its message
+const siweClockSkew = time.Minute
+
+var siweNonceRegexp = regexp.MustCompile(`^[a-zA-Z0-9]{8,}$`)
+
+// SiweMessage is a parsed EIP-4361 sign-in with ethereum message
+type SiweMessage struct {
+	Domain         string
+	Address        string
+	Statement      string
+	Uri            string
+	Version        string
+	ChainId        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestId      string
+	Resources      []string
+}
+
+// ParseSiweMessage reads the EIP-4361 text format, fields must appear in the order the spec defines
+func ParseSiweMessage(message string) (*SiweMessage, error) {
+	lines := strings.Split(message, "\n")
+	if len(lines) < 8 {
+		return nil, fmt.Errorf("invalid siwe message")
+	}
+
+	msg := new(SiweMessage)
+	if !strings.HasSuffix(lines[0], siweHeaderSuffix) {
+		return nil, fmt.Errorf("invalid siwe message: missing header")
+	}
+	msg.Domain = strings.TrimSuffix(lines[0], siweHeaderSuffix)
+	msg.Address = lines[1]
+	if lines[2] != "" {
+		return nil, fmt.Errorf("invalid siwe message: missing blank line after address")
+	}
+
+	i := 3
+	if !strings.HasPrefix(lines[i], "URI: ") {
+		msg.Statement = lines[i]
+		if i+1 >= len(lines) || lines[i+1] != "" {
+			return nil, fmt.Errorf("invalid siwe message: missing blank line after statement")
+		}
+		i += 2
+	}
+
+	field := func(name string, required bool) (string, error) {
+		prefix := name + ": "
+		if i < len(lines) && strings.HasPrefix(lines[i], prefix) {
+			value := strings.TrimPrefix(lines[i], prefix)
+			i++
+			return value, nil
+		}
+		if required {
+			return "", fmt.Errorf("invalid siwe message: missing %s", strings.ToLower(name))
+		}
+		return "", nil
+	}
+	timeField := func(name string, required bool) (*time.Time, error) {
+		value, err := field(name, required)
+		if err != nil || value == "" {
+			return nil, err
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid siwe message: %s must be RFC 3339", strings.ToLower(name))
+		}
+		return &t, nil
+	}
+
+	var err error
+	if msg.Uri, err = field("URI", true); err != nil {
+		return nil, err
+	}
+	if msg.Version, err = field("Version", true); err != nil {
+		return nil, err
+	}
+	chainId, err := field("Chain ID", true)
+	if err != nil {
+		return nil, err
+	}
+	if msg.ChainId, err = strconv.ParseInt(chainId, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid siwe message: chain id must be a number")
+	}
+	if msg.Nonce, err = field("Nonce", true); err != nil {
+		return nil, err
+	}
+	issuedAt, err := timeField("Issued At", true)
+	if err != nil {
+		return nil, err
+	}
+	msg.IssuedAt = *issuedAt
+	if msg.ExpirationTime, err = timeField("Expiration Time", false); err != nil {
+		return nil, err
+	}
+	if msg.NotBefore, err = timeField("Not Before", false); err != nil {
+		return nil, err
+	}
+	if msg.RequestId, err = field("Request ID", false); err != nil {
+		return nil, err
+	}
+
+	if i < len(lines) && lines[i] == "Resources:" {
+		for i++; i < len(lines) && strings.HasPrefix(lines[i], "- "); i++ {
+			msg.Resources = append(msg.Resources, strings.TrimPrefix(lines[i], "- "))
+		}
+	}
+	if i != len(lines) {
+		return nil, fmt.Errorf("invalid siwe message: unexpected line %q", lines[i])
+	}
+	return msg, nil
+}
+
+// Validate checks the message was issued for domain on chainId and is usable at now, the signature is checked separately
+func (obj *SiweMessage) Validate(domain string, chainId int64, now time.Time) error {
+	if obj.Domain != domain {
+		return fmt.Errorf("siwe domain mismatch")
+	}
+	if obj.ChainId != chainId {
+		return fmt.Errorf("siwe chain id mismatch")
+	}
+	if obj.Version != "1" {
+		return fmt.Errorf("unsupported siwe version")
+	}
+	if !siweNonceRegexp.MatchString(obj.Nonce) {
+		return fmt.Errorf("invalid siwe nonce")
+	}
+	// EIP-4361 requires the address in its EIP-55 checksummed form
+	if address, err := NormalizeAddress(obj.Address); err != nil || address != obj.Address {
+		return fmt.Errorf("siwe address must be checksummed")
+	}
+	if obj.IssuedAt.After(now.Add(siweClockSkew)) {
+		return fmt.Errorf("siwe message is issued in the future")
+	}
+	if obj.ExpirationTime != nil && !now.Before(*obj.ExpirationTime) {
+		return fmt.Errorf("siwe message has expired")
+	}
+	if obj.NotBefore != nil && now.Before(*obj.NotBefore) {
+		return fmt.Errorf("siwe message is not yet valid")
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package nfteth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// the example messages of the EIP-4361 specification
+const (
+	siweExample = `service.invalid wants you to sign in with your Ethereum account:
+0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2
+
+I accept the ServiceOrg Terms of Service: https://service.invalid/tos
+
+URI: https://service.invalid/login
+Version: 1
+Chain ID: 1
+Nonce: 32891756
+Issued At: 2021-09-30T16:25:24Z
+Resources:
+- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/
+- https://example.com/my-web2-claim.json`
+
+	siweExampleNoStatement = `service.invalid wants you to sign in with your Ethereum account:
+0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2
+
+URI: https://service.invalid/login
+Version: 1
+Chain ID: 1
+Nonce: 32891756
+Issued At: 2021-09-30T16:25:24Z`
+)
+
+var siweIssuedAt = time.Date(2021, 9, 30, 16, 25, 24, 0, time.UTC)
+
+func TestParseSiweMessage(t *testing.T) {
+	msg, err := ParseSiweMessage(siweExample)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if msg.Domain != "service.invalid" {
+		t.Errorf("domain = %s", msg.Domain)
+	}
+	if msg.Address != "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2" {
+		t.Errorf("address = %s", msg.Address)
+	}
+	if msg.Statement != "I accept the ServiceOrg Terms of Service: https://service.invalid/tos" {
+		t.Errorf("statement = %s", msg.Statement)
+	}
+	if msg.Uri != "https://service.invalid/login" || msg.Version != "1" || msg.ChainId != 1 || msg.Nonce != "32891756" {
+		t.Errorf("fields = %s %s %d %s", msg.Uri, msg.Version, msg.ChainId, msg.Nonce)
+	}
+	if !msg.IssuedAt.Equal(siweIssuedAt) {
+		t.Errorf("issued at = %v", msg.IssuedAt)
+	}
+	if msg.ExpirationTime != nil || msg.NotBefore != nil || msg.RequestId != "" {
+		t.Errorf("optional fields should be empty")
+	}
+	if len(msg.Resources) != 2 ||
+		msg.Resources[0] != "ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/" ||
+		msg.Resources[1] != "https://example.com/my-web2-claim.json" {
+		t.Errorf("resources = %v", msg.Resources)
+	}
+
+	msg, err = ParseSiweMessage(siweExampleNoStatement)
+	if err != nil {
+		t.Fatalf("parse without statement: %v", err)
+	}
+	if msg.Statement != "" || msg.Resources != nil {
+		t.Errorf("statement = %q, resources = %v", msg.Statement, msg.Resources)
+	}
+	if msg.Uri != "https://service.invalid/login" {
+		t.Errorf("uri = %s", msg.Uri)
+	}
+}
+
+func TestParseSiweMessageOptionalFields(t *testing.T) {
+	msg, err := ParseSiweMessage(siweExampleNoStatement + `
+Expiration Time: 2021-09-30T16:35:24Z
+Not Before: 2021-09-30T16:26:24Z
+Request ID: 4f2e1b`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if msg.ExpirationTime == nil || !msg.ExpirationTime.Equal(siweIssuedAt.Add(10*time.Minute)) {
+		t.Errorf("expiration time = %v", msg.ExpirationTime)
+	}
+	if msg.NotBefore == nil || !msg.NotBefore.Equal(siweIssuedAt.Add(time.Minute)) {
+		t.Errorf("not before = %v", msg.NotBefore)
+	}
+	if msg.RequestId != "4f2e1b" {
+		t.Errorf("request id = %s", msg.RequestId)
+	}
+}
+
+func TestParseSiweMessageRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+	}{
+		{"missing blank line after address", strings.Replace(siweExample, "Cc2\n\n", "Cc2\n", 1)},
+		{"missing blank line after statement", strings.Replace(siweExample, "tos\n\n", "tos\n", 1)},
+		{"missing header", strings.Replace(siweExample, " wants you to sign in", " wants you to log in", 1)},
+		{"missing nonce", strings.Replace(siweExampleNoStatement, "Nonce: 32891756\n", "", 1)},
+		{"fields out of order", strings.Replace(siweExampleNoStatement, "Version: 1\nChain ID: 1", "Chain ID: 1\nVersion: 1", 1)},
+		{"chain id is not a number", strings.Replace(siweExampleNoStatement, "Chain ID: 1", "Chain ID: one", 1)},
+		{"issued at is not RFC 3339", strings.Replace(siweExampleNoStatement, "2021-09-30T16:25:24Z", "30/09/2021 16:25", 1)},
+		{"trailing line", siweExample + "\nhello"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseSiweMessage(tt.message); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestSiweMessageValidate(t *testing.T) {
+	parse := func(message string) *SiweMessage {
+		msg, err := ParseSiweMessage(message)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		return msg
+	}
+
+	now := siweIssuedAt.Add(time.Minute)
+	if err := parse(siweExample).Validate("service.invalid", 1, now); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	expiring := siweExampleNoStatement + "\nExpiration Time: 2021-09-30T16:35:24Z"
+	notBefore := siweExampleNoStatement + "\nNot Before: 2021-09-30T16:35:24Z"
+	tests := []struct {
+		name    string
+		message string
+		domain  string
+		chainId int64
+		now     time.Time
+		want    string
+	}{
+		{"another domain", siweExample, "evil.invalid", 1, now, "siwe domain mismatch"},
+		{"another chain", siweExample, "service.invalid", 5, now, "siwe chain id mismatch"},
+		{"lower case address", strings.Replace(siweExample, "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", 1), "service.invalid", 1, now, "siwe address must be checksummed"},
+		{"broken checksum", strings.Replace(siweExample, "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756CC2", 1), "service.invalid", 1, now, "siwe address must be checksummed"},
+		{"short nonce", strings.Replace(siweExample, "Nonce: 32891756", "Nonce: 1234", 1), "service.invalid", 1, now, "invalid siwe nonce"},
+		{"unsupported version", strings.Replace(siweExample, "Version: 1", "Version: 2", 1), "service.invalid", 1, now, "unsupported siwe version"},
+		{"expired", expiring, "service.invalid", 1, siweIssuedAt.Add(10 * time.Minute), "siwe message has expired"},
+		{"not yet valid", notBefore, "service.invalid", 1, siweIssuedAt.Add(5 * time.Minute), "siwe message is not yet valid"},
+		{"issued in the future", siweExample, "service.invalid", 1, siweIssuedAt.Add(-2 * time.Minute), "siwe message is issued in the future"},
+	}
+	for _, tt := range tests {
+		err := parse(tt.message).Validate(tt.domain, tt.chainId, tt.now)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: err = %v, want %s", tt.name, err, tt.want)
+		}
+	}
+
+	// within the clock skew, before the expiration and after not before
+	if err := parse(expiring).Validate("service.invalid", 1, siweIssuedAt.Add(-30*time.Second)); err != nil {
+		t.Errorf("issued within the clock skew: %v", err)
+	}
+	if err := parse(notBefore).Validate("service.invalid", 1, siweIssuedAt.Add(10*time.Minute)); err != nil {
+		t.Errorf("at not before: %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package nftindexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type fileChain struct {
+	Blocks []*fileBlock `json:"blocks"`
+}
+
+type fileBlock struct {
+	Block
+	Logs []*Log `json:"logs"`
+}
+
+type fileSource struct {
+	path string
+}
+
+// NewFileSource serves a chain described by a json file of blocks with their logs, the file is
+// read on every call so replacing its tail simulates new blocks and reorgs
+func NewFileSource(path string) ChainSource {
+	return &fileSource{
+		path: path,
+	}
+}
+
+func (s *fileSource) load() (*fileChain, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("read chain file failed: %v", err)
+	}
+	chain := new(fileChain)
+	if err := json.Unmarshal(data, chain); err != nil {
+		return nil, fmt.Errorf("unmarshal chain file failed: %v", err)
+	}
+	return chain, nil
+}
+
+func (s *fileSource) LatestBlockNumber(ctx context.Context) (uint64, error) {
+	chain, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+	var latest uint64
+	for _, block := range chain.Blocks {
+		if block.Number > latest {
+			latest = block.Number
+		}
+	}
+	return latest, nil
+}
+
+func (s *fileSource) BlockByNumber(ctx context.Context, number uint64) (*Block, error) {
+	chain, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range chain.Blocks {
+		if block.Number == number {
+			b := block.Block
+			return &b, nil
+		}
+	}
+	return nil, fmt.Errorf("block %d not found", number)
+}
+
+func (s *fileSource) Logs(ctx context.Context, filter *LogFilter) ([]*Log, error) {
+	chain, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make([]*Log, 0)
+	for _, block := range chain.Blocks {
+		if block.Number < filter.FromBlock || block.Number > filter.ToBlock {
+			continue
+		}
+		for _, log := range block.Logs {
+			if filter.Address != "" && !strings.EqualFold(log.Address, filter.Address) {
+				continue
+			}
+			if filter.Topic != "" && (len(log.Topics) == 0 || !strings.EqualFold(log.Topics[0], filter.Topic)) {
+				continue
+			}
+			// the block fields are implied by the enclosing block in the file
+			l := *log
+			l.BlockNumber = block.Number
+			l.BlockHash = block.Hash
+			logs = append(logs, &l)
+		}
+	}
+	return logs, nil
+}
@@ -0,0 +1,100 @@
+package nftindexer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/muhammadfarhankt/nft-marketplace/pkg/nfteth"
+)
+
+// TransferTopic is keccak256("Transfer(address,address,uint256)"), shared by ERC-20 and ERC-721
+var TransferTopic = "0x" + fmt.Sprintf("%x", nfteth.Keccak256([]byte("Transfer(address,address,uint256)")))
+
+type Block struct {
+	Number     uint64 `json:"number"`
+	Hash       string `json:"hash"`
+	ParentHash string `json:"parent_hash"`
+}
+
+type Log struct {
+	Address     string   `json:"address"`
+	Topics      []string `json:"topics"`
+	Data        string   `json:"data"`
+	BlockNumber uint64   `json:"block_number"`
+	BlockHash   string   `json:"block_hash"`
+	TxHash      string   `json:"tx_hash"`
+	LogIndex    uint     `json:"log_index"`
+}
+
+type LogFilter struct {
+	FromBlock uint64
+	ToBlock   uint64
+	Address   string
+	Topic     string
+}
+
+// ChainSource is where the indexer reads blocks and logs from, a node over json-rpc in
+// production and a json file in development and tests
+type ChainSource interface {
+	LatestBlockNumber(ctx context.Context) (uint64, error)
+	BlockByNumber(ctx context.Context, number uint64) (*Block, error)
+	Logs(ctx context.Context, filter *LogFilter) ([]*Log, error)
+}
+
+// Transfer is a decoded ERC-721 Transfer event, addresses are checksummed
+type Transfer struct {
+	BlockNumber uint64
+	BlockHash   string
+	TxHash      string
+	LogIndex    uint
+	From        string
+	To          string
+	TokenId     int64
+}
+
+// DecodeTransfer decodes an ERC-721 Transfer log, ERC-20 transfers carry the amount in data
+// instead of a third indexed topic and are reported as not ok
+func DecodeTransfer(log *Log) (*Transfer, bool, error) {
+	if len(log.Topics) != 4 || !strings.EqualFold(log.Topics[0], TransferTopic) {
+		return nil, false, nil
+	}
+
+	from, err := topicAddress(log.Topics[1])
+	if err != nil {
+		return nil, false, err
+	}
+	to, err := topicAddress(log.Topics[2])
+	if err != nil {
+		return nil, false, err
+	}
+
+	raw, err := nfteth.DecodeHex(log.Topics[3])
+	if err != nil || len(raw) != 32 {
+		return nil, false, fmt.Errorf("invalid token id topic %s", log.Topics[3])
+	}
+	tokenId := new(big.Int).SetBytes(raw)
+	if !tokenId.IsInt64() {
+		return nil, false, fmt.Errorf("token id %s does not fit the local token ids", tokenId)
+	}
+
+	return &Transfer{
+		BlockNumber: log.BlockNumber,
+		BlockHash:   log.BlockHash,
+		TxHash:      log.TxHash,
+		LogIndex:    log.LogIndex,
+		From:        from,
+		To:          to,
+		TokenId:     tokenId.Int64(),
+	}, true, nil
+}
+
+// topicAddress reads an address left padded to 32 bytes in an indexed topic
+func topicAddress(topic string) (string, error) {
+	raw, err := nfteth.DecodeHex(topic)
+	if err != nil || len(raw) != 32 {
+		return "", fmt.Errorf("invalid address topic %s", topic)
+	}
+	return nfteth.ChecksumAddress(raw[12:]), nil
+}
@@ -0,0 +1,170 @@
+package nftindexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type rpcSource struct {
+	url    string
+	client *http.Client
+}
+
+// NewRpcSource reads the chain from an ethereum json-rpc node
+func NewRpcSource(url string) ChainSource {
+	return &rpcSource{
+		url: url,
+		client: &http.Client{
+			Timeout: time.Second * 30,
+		},
+	}
+}
+
+type rpcRequest struct {
+	JsonRpc string `json:"jsonrpc"`
+	Id      int    `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (s *rpcSource) call(ctx context.Context, result any, method string, params ...any) error {
+	if params == nil {
+		params = make([]any, 0)
+	}
+	body, err := json.Marshal(&rpcRequest{
+		JsonRpc: "2.0",
+		Id:      1,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s failed: %v", method, err)
+	}
+	defer res.Body.Close()
+
+	rpcRes := new(rpcResponse)
+	if err := json.NewDecoder(res.Body).Decode(rpcRes); err != nil {
+		return fmt.Errorf("%s failed: %v", method, err)
+	}
+	if rpcRes.Error != nil {
+		return fmt.Errorf("%s failed: %s", method, rpcRes.Error.Message)
+	}
+	if string(rpcRes.Result) == "null" {
+		return fmt.Errorf("%s failed: not found", method)
+	}
+	return json.Unmarshal(rpcRes.Result, result)
+}
+
+func parseQuantity(q string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(q, "0x"), 16, 64)
+}
+
+func quantity(n uint64) string {
+	return fmt.Sprintf("0x%x", n)
+}
+
+func (s *rpcSource) LatestBlockNumber(ctx context.Context) (uint64, error) {
+	var number string
+	if err := s.call(ctx, &number, "eth_blockNumber"); err != nil {
+		return 0, err
+	}
+	return parseQuantity(number)
+}
+
+func (s *rpcSource) BlockByNumber(ctx context.Context, number uint64) (*Block, error) {
+	raw := new(struct {
+		Number     string `json:"number"`
+		Hash       string `json:"hash"`
+		ParentHash string `json:"parentHash"`
+	})
+	if err := s.call(ctx, raw, "eth_getBlockByNumber", quantity(number), false); err != nil {
+		return nil, err
+	}
+
+	n, err := parseQuantity(raw.Number)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block number %s", raw.Number)
+	}
+	return &Block{
+		Number:     n,
+		Hash:       raw.Hash,
+		ParentHash: raw.ParentHash,
+	}, nil
+}
+
+func (s *rpcSource) Logs(ctx context.Context, filter *LogFilter) ([]*Log, error) {
+	params := map[string]any{
+		"fromBlock": quantity(filter.FromBlock),
+		"toBlock":   quantity(filter.ToBlock),
+	}
+	if filter.Address != "" {
+		params["address"] = filter.Address
+	}
+	if filter.Topic != "" {
+		params["topics"] = []string{filter.Topic}
+	}
+
+	raws := make([]*struct {
+		Address     string   `json:"address"`
+		Topics      []string `json:"topics"`
+		Data        string   `json:"data"`
+		BlockNumber string   `json:"blockNumber"`
+		BlockHash   string   `json:"blockHash"`
+		TxHash      string   `json:"transactionHash"`
+		LogIndex    string   `json:"logIndex"`
+		Removed     bool     `json:"removed"`
+	}, 0)
+	if err := s.call(ctx, &raws, "eth_getLogs", params); err != nil {
+		return nil, err
+	}
+
+	logs := make([]*Log, 0, len(raws))
+	for _, raw := range raws {
+		if raw.Removed {
+			continue
+		}
+		blockNumber, err := parseQuantity(raw.BlockNumber)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block number %s", raw.BlockNumber)
+		}
+		logIndex, err := parseQuantity(raw.LogIndex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log index %s", raw.LogIndex)
+		}
+		logs = append(logs, &Log{
+			Address:     raw.Address,
+			Topics:      raw.Topics,
+			Data:        raw.Data,
+			BlockNumber: blockNumber,
+			BlockHash:   raw.BlockHash,
+			TxHash:      raw.TxHash,
+			LogIndex:    uint(logIndex),
+		})
+	}
+	return logs, nil
+}
@@ -0,0 +1,54 @@
+package nftmailer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type INftMailer interface {
+	Send(msg *Message) error
+}
+
+// NewMailer picks the transport from config, local setups default to the outbox
+func NewMailer(cfg config.IMailConfig) INftMailer {
+	switch cfg.Driver() {
+	case "smtp":
+		return NewSmtpMailer(cfg)
+	default:
+		return NewOutboxMailer(cfg.OutboxDir(), cfg.From())
+	}
+}
+
+// encode renders a plain text message with the headers both transports need
+func encode(from string, msg *Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// validate keeps header injection out of the address and subject
+func validate(msg *Message) error {
+	if msg.To == "" {
+		return fmt.Errorf("mail recipient is required")
+	}
+	if strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+		return fmt.Errorf("mail headers must not contain line breaks")
+	}
+	return nil
+}
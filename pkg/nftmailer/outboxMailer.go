@@ -0,0 +1,40 @@
+package nftmailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+// outboxMailer writes every message to a .eml file instead of sending it, for local development
+type outboxMailer struct {
+	dir  string
+	from string
+}
+
+func NewOutboxMailer(dir, from string) INftMailer {
+	return &outboxMailer{
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (m *outboxMailer) Send(msg *Message) error {
+	if err := validate(msg); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("create outbox failed: %v", err)
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	if err := os.WriteFile(filepath.Join(m.dir, name), encode(m.from, msg), 0o644); err != nil {
+		return fmt.Errorf("write outbox failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package nftmailer
+
+import (
+	"fmt"
+	"net/smtp"
+
+	"github.com/muhammadfarhankt/nft-marketplace/config"
+)
+
+type smtpMailer struct {
+	cfg config.IMailConfig
+}
+
+func NewSmtpMailer(cfg config.IMailConfig) INftMailer {
+	return &smtpMailer{
+		cfg: cfg,
+	}
+}
+
+func (m *smtpMailer) Send(msg *Message) error {
+	if err := validate(msg); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.cfg.Username() != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username(), m.cfg.Password(), m.cfg.SmtpHost())
+	}
+
+	addr := fmt.Sprintf("%s:%d", m.cfg.SmtpHost(), m.cfg.SmtpPort())
+	if err := smtp.SendMail(addr, auth, m.cfg.From(), []string{msg.To}, encode(m.cfg.From(), msg)); err != nil {
+		return fmt.Errorf("send mail failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package nftscheduler
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+type INftScheduler interface {
+	Every(name string, interval time.Duration, job func() error)
+	Start()
+	Stop()
+}
+
+type nftJob struct {
+	name     string
+	interval time.Duration
+	run      func() error
+}
+
+type nftScheduler struct {
+	jobs []*nftJob
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewScheduler() INftScheduler {
+	return &nftScheduler{
+		jobs: make([]*nftJob, 0),
+		stop: make(chan struct{}),
+	}
+}
+
+// Every registers a job to run once per interval after Start is called
+func (s *nftScheduler) Every(name string, interval time.Duration, job func() error) {
+	s.jobs = append(s.jobs, &nftJob{
+		name:     name,
+		interval: interval,
+		run:      job,
+	})
+}
+
+func (s *nftScheduler) Start() {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(job)
+	}
+}
+
+// Stop waits for running jobs to finish their current tick
+func (s *nftScheduler) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+func (s *nftScheduler) loop(job *nftJob) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+
+	log.Printf("scheduler job %s started every %v", job.name, job.interval)
+	for {
+		select {
+		case <-s.stop:
+			log.Printf("scheduler job %s stopped", job.name)
+			return
+		case <-ticker.C:
+			if err := job.run(); err != nil {
+				log.Printf("scheduler job %s failed: %v", job.name, err)
+			}
+		}
+	}
+}
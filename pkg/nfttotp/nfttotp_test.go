@@ -0,0 +1,79 @@
+package nfttotp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 seed "12345678901234567890" of RFC 6238 Appendix B in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// the Appendix B values are 8 digits, the last 6 are the codes for Digits = 6
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCode(t *testing.T) {
+	for _, tt := range rfc6238Vectors {
+		code, err := Code(rfc6238Secret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("code at %d: %v", tt.unix, err)
+		}
+		if code != tt.code {
+			t.Errorf("code at %d = %s, want %s", tt.unix, code, tt.code)
+		}
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Fatal("expected an error for a secret that is not base32")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+	code := func(step int64) string {
+		c, err := Code(rfc6238Secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantStep int64
+		wantOk   bool
+	}{
+		{"current step", rfc6238Secret, code(current), current, true},
+		{"previous step", rfc6238Secret, code(current - 1), current - 1, true},
+		{"next step", rfc6238Secret, code(current + 1), current + 1, true},
+		{"surrounding whitespace", rfc6238Secret, " " + code(current) + " ", current, true},
+		{"lowercase secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code(current), current, true},
+		{"two steps behind", rfc6238Secret, code(current - 2), 0, false},
+		{"two steps ahead", rfc6238Secret, code(current + 2), 0, false},
+		{"too short", rfc6238Secret, code(current)[1:], 0, false},
+		{"too long", rfc6238Secret, code(current) + "0", 0, false},
+		{"invalid secret", "not base32!", code(current), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(tt.secret, tt.code, now)
+			if ok != tt.wantOk || step != tt.wantStep {
+				t.Fatalf("Validate = (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"net"
+	"strings"
+)
+
+// ClientIp returns the ip login throttles and sessions are keyed on. Every proxy appends the peer
+// it saw to X-Forwarded-For, so only entries added by trusted proxies can be believed: the list is
+// walked from the right skipping trusted proxies and the first other entry is the client, anything
+// left of it was written by the client itself. A peer that is not a trusted proxy is the client
+func ClientIp(remoteIp string, forwardedFor []string, trustedProxies []string) string {
+	if !isTrustedProxy(remoteIp, trustedProxies) {
+		return remoteIp
+	}
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(forwardedFor[i])
+		if net.ParseIP(ip) == nil {
+			break
+		}
+		if !isTrustedProxy(ip, trustedProxies) {
+			return ip
+		}
+	}
+	return remoteIp
+}
+
+// isTrustedProxy matches ip against the configured ips and cidrs
+func isTrustedProxy(ip string, trustedProxies []string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, proxy := range trustedProxies {
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			if network.Contains(parsed) {
+				return true
+			}
+			continue
+		}
+		if proxyIp := net.ParseIP(proxy); proxyIp != nil && proxyIp.Equal(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package utils
+
+import "testing"
+
+func TestClientIp(t *testing.T) {
+	trusted := []string{"10.0.0.1", "172.16.0.0/12"}
+	tests := []struct {
+		name         string
+		remoteIp     string
+		forwardedFor []string
+		want         string
+	}{
+		{"direct client", "203.0.113.7", nil, "203.0.113.7"},
+		{"direct client sending the header", "203.0.113.7", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"one trusted proxy", "10.0.0.1", []string{"203.0.113.7"}, "203.0.113.7"},
+		{"spoofed left-most entry", "10.0.0.1", []string{"198.51.100.1", "203.0.113.7"}, "203.0.113.7"},
+		{"several spoofed entries", "10.0.0.1", []string{"1.1.1.1", "2.2.2.2", "3.3.3.3", "203.0.113.7"}, "203.0.113.7"},
+		{"chain of trusted proxies", "10.0.0.1", []string{"198.51.100.1", "203.0.113.7", "172.16.4.2", "172.20.0.9"}, "203.0.113.7"},
+		{"spoofed entry looking like a proxy", "10.0.0.1", []string{"203.0.113.7", "172.16.4.2"}, "203.0.113.7"},
+		{"entries with spaces", "10.0.0.1", []string{" 198.51.100.1", " 203.0.113.7 "}, "203.0.113.7"},
+		{"garbage after the client", "10.0.0.1", []string{"203.0.113.7", "not-an-ip"}, "10.0.0.1"},
+		{"only trusted proxies", "10.0.0.1", []string{"172.16.4.2"}, "10.0.0.1"},
+		{"no header from a trusted proxy", "10.0.0.1", nil, "10.0.0.1"},
+		{"ipv6 client", "172.16.0.5", []string{"2001:db8::1"}, "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		if got := ClientIp(tt.remoteIp, tt.forwardedFor, trusted); got != tt.want {
+			t.Errorf("%s: ClientIp = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
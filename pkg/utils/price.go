@@ -0,0 +1,8 @@
+package utils
+
+import "math"
+
+// RoundPrice rounds to the two decimals prices are stored with
+func RoundPrice(price float64) float64 {
+	return math.Round(price*100) / 100
+}